@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/rs/zerolog"
+
+	"github.com/AlisskaPie/project-xm/internal/company/event_sender/amqp"
+	"github.com/AlisskaPie/project-xm/internal/company/event_sender/cloudevents"
+	"github.com/AlisskaPie/project-xm/internal/company/event_sender/kafka"
+	"github.com/AlisskaPie/project-xm/internal/company/event_sender/noop"
+	"github.com/AlisskaPie/project-xm/internal/config"
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+)
+
+// newEventSender creates the company event sender selected by the config
+func newEventSender(conf config.EventSender, logger zerolog.Logger) (domain.CompanyEventSender, error) {
+	encoder := cloudevents.NewEncoder(conf.Source)
+
+	switch conf.Type {
+	case "noop":
+		return noop.NewCompanyEventSenderNoop(logger, encoder), nil
+	case "kafka":
+		return kafka.NewCompanyEventSenderKafka(conf.Kafka, encoder)
+	case "amqp":
+		return amqp.NewCompanyEventSenderAMQP(conf.AMQP, encoder)
+	default:
+		return nil, fmt.Errorf("unknown event sender type %q", conf.Type)
+	}
+}
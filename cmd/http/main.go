@@ -3,24 +3,33 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"time"
 
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/database/sqlite"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/jmoiron/sqlx"
 	"github.com/labstack/echo/v4"
 	emiddleware "github.com/labstack/echo/v4/middleware"
-	_ "github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/zerolog"
 
 	delivery "github.com/AlisskaPie/project-xm/internal/company/delivery/http"
-	"github.com/AlisskaPie/project-xm/internal/company/event_sender/noop"
+	"github.com/AlisskaPie/project-xm/internal/company/outbox"
+	"github.com/AlisskaPie/project-xm/internal/company/repository/cache"
+	"github.com/AlisskaPie/project-xm/internal/company/repository/eventsender"
+	"github.com/AlisskaPie/project-xm/internal/company/repository/memory"
 	"github.com/AlisskaPie/project-xm/internal/company/repository/postgres"
+	"github.com/AlisskaPie/project-xm/internal/company/repository/sqlite"
 	"github.com/AlisskaPie/project-xm/internal/company/usecase"
+	"github.com/AlisskaPie/project-xm/internal/config"
 	"github.com/AlisskaPie/project-xm/internal/config/viper"
 	"github.com/AlisskaPie/project-xm/internal/user/delivery/http/middleware"
+	"github.com/AlisskaPie/project-xm/pkg/domain"
 )
 
 func main() {
@@ -34,38 +43,147 @@ func main() {
 		log.Fatal(fmt.Errorf("failed to get config: %w", err))
 	}
 
-	dbConn, err := sqlx.Open("postgres", conf.DB.DSN)
-	if err != nil {
-		log.Fatal(fmt.Errorf("failed to sql.Open: %w", err))
-	}
+	var eventSender domain.CompanyEventSender
+	if conf.EventSender.Type != "" {
+		eventSender, err = newEventSender(conf.EventSender, logger)
+		if err != nil {
+			log.Fatal(fmt.Errorf("failed to create event sender: %w", err))
+		}
 
-	if err := dbConn.Ping(); err != nil {
-		log.Fatal(fmt.Errorf("failed to Ping db: %w", err))
+		if closer, ok := eventSender.(io.Closer); ok {
+			defer func() {
+				if err := closer.Close(); err != nil {
+					log.Fatal(fmt.Errorf("failed to close event sender: %w", err))
+				}
+			}()
+		}
 	}
 
-	defer func() {
-		if err := dbConn.Close(); err != nil {
-			log.Fatal(fmt.Errorf("failed to close db:  %w", err))
+	var companyRepo domain.CompanyRepository
+	var auditRepo domain.CompanyAuditRepository
+	// the transactions pass the company repository with all of its wrappers to the units of work
+	var newTxManager func(repo domain.CompanyRepository) domain.TxManager
+	switch conf.DB.Storage() {
+	case "memory":
+		logger.Warn().Msg("companies are kept in memory and lost on exit")
+
+		companyRepo, auditRepo = memory.NewCompanyRepository(conf.DB.UniqueNamesPerType)
+		newTxManager = memory.NewTxManager
+		// there is no outbox without a database, so the events are sent right after the changes
+		if eventSender != nil {
+			companyRepo = eventsender.NewWrapper(companyRepo, eventSender)
+		}
+	case sqlite.Scheme:
+		dbConn, err := sqlite.Open(conf.DB.DSN)
+		if err != nil {
+			log.Fatal(fmt.Errorf("failed to open sqlite db: %w", err))
 		}
-	}()
 
-	addMigrations(conf)
+		if err := dbConn.Ping(); err != nil {
+			log.Fatal(fmt.Errorf("failed to Ping db: %w", err))
+		}
 
-	e := echo.New()
-	e.Use(emiddleware.Logger())
+		defer func() {
+			if err := dbConn.Close(); err != nil {
+				log.Fatal(fmt.Errorf("failed to close db:  %w", err))
+			}
+		}()
 
-	auth := middleware.KeyAuth([]byte(conf.Auth.JWTKey))
+		addMigrations("file://migrations/sqlite", conf.DB.DSN)
+		addUniqueNames(ctx, dbConn, conf.DB, sqlite.FindNameDuplicates, sqlite.EnsureUniqueNames)
 
-	companyRepo := postgres.NewCompanyRepository(ctx, dbConn)
-	if conf.EventSender {
-		companyRepo = postgres.NewEventSenderWrapper(
-			companyRepo,
-			noop.NewCompanyEventSenderNoop(logger),
-		)
+		companyRepo = sqlite.NewAuditWrapper(dbConn, sqlite.NewCompanyRepository(dbConn))
+		auditRepo = sqlite.NewCompanyAuditRepository(dbConn)
+		newTxManager = func(repo domain.CompanyRepository) domain.TxManager {
+			return sqlite.NewTxManager(dbConn, repo)
+		}
+		// there is no outbox in the sqlite db, so the events are sent right after the changes
+		if eventSender != nil {
+			companyRepo = eventsender.NewWrapper(companyRepo, eventSender)
+		}
+	case "postgres":
+		dbConn, err := postgres.Connect(ctx, conf.DB.DSN, conf.DB, logger)
+		if err != nil {
+			log.Fatal(fmt.Errorf("failed to connect to db: %w", err))
+		}
+
+		defer func() {
+			if err := dbConn.Close(); err != nil {
+				log.Fatal(fmt.Errorf("failed to close db:  %w", err))
+			}
+		}()
+
+		addMigrations("file://migrations", conf.DB.DSN)
+		addUniqueNames(ctx, dbConn, conf.DB, postgres.FindNameDuplicates, postgres.EnsureUniqueNames)
+
+		// the replicas are not required to be up, their reads go to the primary until they are
+		var replicas *postgres.Replicas
+		if len(conf.DB.Replicas) > 0 {
+			replicaConns := openReplicas(conf.DB)
+			defer func() {
+				for _, replicaConn := range replicaConns {
+					if err := replicaConn.Close(); err != nil {
+						log.Fatal(fmt.Errorf("failed to close replica db:  %w", err))
+					}
+				}
+			}()
+
+			replicas = postgres.NewReplicas(replicaConns, conf.DB.ReplicaStickiness, logger)
+			go replicas.Run(ctx, conf.DB.ReplicaCheckInterval)
+		}
+
+		companyRepo = postgres.NewAuditWrapper(dbConn, postgres.NewReplicatedCompanyRepository(ctx, dbConn, replicas))
+		auditRepo = postgres.NewCompanyAuditRepository(dbConn)
+		newTxManager = func(repo domain.CompanyRepository) domain.TxManager {
+			return postgres.NewTxManager(dbConn, repo, conf.DB.Retry)
+		}
+		if eventSender != nil {
+			companyRepo = postgres.NewOutboxWrapper(dbConn, companyRepo)
+
+			relay := outbox.NewRelay(
+				postgres.NewCompanyOutboxRepository(dbConn),
+				eventSender,
+				conf.Outbox,
+				prometheus.DefaultRegisterer,
+				logger,
+			)
+			go relay.Run(ctx)
+		}
+		// the retries repeat the whole transactions of the audit and outbox wrappers
+		companyRepo = postgres.NewRetryWrapper(companyRepo, conf.DB.Retry)
+	default:
+		log.Fatal(fmt.Errorf("unknown db type %q", conf.DB.Storage()))
 	}
 
-	companyUsecase := usecase.NewCompanyUsecase(companyRepo)
-	delivery.NewCompanyHandler(e, companyUsecase, auth, logger)
+	if conf.Cache.Enabled {
+		companyRepo = cache.NewWrapper(companyRepo, conf.Cache, prometheus.DefaultRegisterer)
+	}
+
+	e := echo.New()
+	e.HTTPErrorHandler = delivery.NewHTTPErrorHandler(logger)
+	e.Use(emiddleware.RequestID())
+	e.Use(emiddleware.Logger())
+	e.GET("/metrics", echo.WrapHandler(promhttp.Handler()))
+
+	auth := middleware.Auth([]byte(conf.Auth.JWTKey), conf.Auth.TenantClaim)
+
+	companyUsecase := usecase.NewCompanyUsecase(companyRepo, auditRepo, newTxManager(companyRepo))
+	delivery.NewCompanyHandler(e, companyUsecase, auth, conf.HTTP, logger)
 
 	e.Logger.Fatal(e.Start(conf.HTTP.ListenHostPort))
 }
+
+// openReplicas opens the postgres read replicas of the DB with its pool limits
+func openReplicas(conf config.DB) []*sqlx.DB {
+	replicaConns := make([]*sqlx.DB, 0, len(conf.Replicas))
+	for _, dsn := range conf.Replicas {
+		replicaConn, err := postgres.Open(dsn, conf)
+		if err != nil {
+			log.Fatal(fmt.Errorf("failed to open replica db: %w", err))
+		}
+
+		replicaConns = append(replicaConns, replicaConn)
+	}
+
+	return replicaConns
+}
@@ -1,17 +1,21 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
+	"strings"
 
 	"github.com/AlisskaPie/project-xm/internal/config"
 
 	"github.com/golang-migrate/migrate/v4"
+	"github.com/jmoiron/sqlx"
 )
 
-func addMigrations(conf config.Config) {
-	m, err := migrate.New("file://migrations", conf.DB.DSN)
+// addMigrations applies the migrations of the source directory to the database of the DSN
+func addMigrations(source string, dsn string) {
+	m, err := migrate.New(source, dsn)
 	if err != nil {
 		log.Fatal(fmt.Errorf("failed to create migration handler: %w", err))
 	}
@@ -20,3 +24,31 @@ func addMigrations(conf config.Config) {
 		log.Fatal(fmt.Errorf("failed to apply migration: %w", err))
 	}
 }
+
+// addUniqueNames creates the unique index of the company names in the configured scope,
+// it's not a migration as the scope is configurable. The existing duplicates are reported before,
+// they have to be renamed or deleted for the index to be created.
+func addUniqueNames[D fmt.Stringer](
+	ctx context.Context,
+	db *sqlx.DB,
+	conf config.DB,
+	find func(ctx context.Context, db *sqlx.DB, perType bool) ([]D, error),
+	ensure func(ctx context.Context, db *sqlx.DB, perType bool) error,
+) {
+	duplicates, err := find(ctx, db, conf.UniqueNamesPerType)
+	if err != nil {
+		log.Fatal(fmt.Errorf("failed to find duplicate company names: %w", err))
+	}
+
+	if len(duplicates) > 0 {
+		report := make([]string, 0, len(duplicates))
+		for _, d := range duplicates {
+			report = append(report, d.String())
+		}
+		log.Fatalf("company names are not unique, rename or delete the duplicates:\n%s", strings.Join(report, "\n"))
+	}
+
+	if err := ensure(ctx, db, conf.UniqueNamesPerType); err != nil {
+		log.Fatal(fmt.Errorf("failed to add unique company names index: %w", err))
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/AlisskaPie/project-xm/internal/company/importer"
+	"github.com/AlisskaPie/project-xm/internal/company/repository/postgres"
+	"github.com/AlisskaPie/project-xm/internal/company/usecase"
+	"github.com/AlisskaPie/project-xm/internal/config/viper"
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+)
+
+func main() {
+	file := flag.String("file", "", "CSV or NDJSON file of the companies to import")
+	format := flag.String("format", "", "format of the file, csv or ndjson, by its extension if empty")
+	mapping := flag.String("map", "", "comma separated field=column pairs, e.g. name=Company,type=Kind")
+	errorsFile := flag.String("errors", "", "file of the rejected rows, <file>.errors.ndjson if empty")
+	progressFile := flag.String("progress", "", "file of the import progress, <file>.progress if empty")
+	batchSize := flag.Int("batch-size", 100, "number of companies committed at once")
+	dryRun := flag.Bool("dry-run", false, "validate the rows without importing them")
+	actor := flag.String("actor", "import", "subject recorded in the audit history of the imported companies")
+	tenant := flag.String("tenant", "", "tenant owning the imported companies")
+	flag.Parse()
+
+	if *file == "" || *tenant == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *errorsFile == "" {
+		*errorsFile = *file + ".errors.ndjson"
+	}
+	if *progressFile == "" {
+		*progressFile = *file + ".progress"
+	}
+
+	output := zerolog.ConsoleWriter{Out: os.Stdout, TimeFormat: time.RFC3339}
+	logger := zerolog.New(output).With().Timestamp().Logger()
+
+	conf, err := viper.GetConfig()
+	if err != nil {
+		log.Fatal(fmt.Errorf("failed to get config: %w", err))
+	}
+
+	m, err := importer.ParseMapping(*mapping)
+	if err != nil {
+		log.Fatal(fmt.Errorf("failed to parse mapping: %w", err))
+	}
+
+	skip := 0
+	if !*dryRun {
+		skip, err = readProgress(*progressFile)
+		if err != nil {
+			log.Fatal(fmt.Errorf("failed to read progress: %w", err))
+		}
+	}
+
+	in, err := os.Open(*file)
+	if err != nil {
+		log.Fatal(fmt.Errorf("failed to open file: %w", err))
+	}
+	defer in.Close()
+
+	r, err := newReader(in, *format, *file)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// the rejections of the committed rows are kept when the import is resumed
+	errFlags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if skip > 0 {
+		errFlags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	rejects, err := os.OpenFile(*errorsFile, errFlags, 0o644)
+	if err != nil {
+		log.Fatal(fmt.Errorf("failed to open errors file: %w", err))
+	}
+	defer rejects.Close()
+
+	ctx := domain.ContextWithActor(context.Background(), domain.Actor{Subject: *actor})
+	ctx = domain.ContextWithTenant(ctx, *tenant)
+
+	// the imported companies need the outbox to send their events, so only postgres is supported
+	if conf.DB.Storage() != "postgres" {
+		log.Fatal(fmt.Errorf("import supports only the postgres db, not %q", conf.DB.Storage()))
+	}
+
+	dbConn, err := postgres.Connect(ctx, conf.DB.DSN, conf.DB, logger)
+	if err != nil {
+		log.Fatal(fmt.Errorf("failed to connect to db: %w", err))
+	}
+	defer dbConn.Close()
+
+	// the companies events are relayed from the outbox by the http server
+	companyRepo := postgres.NewAuditWrapper(dbConn, postgres.NewCompanyRepository(ctx, dbConn))
+	if conf.EventSender.Type != "" {
+		companyRepo = postgres.NewOutboxWrapper(dbConn, companyRepo)
+	}
+	companyRepo = postgres.NewRetryWrapper(companyRepo, conf.DB.Retry)
+	companyUsecase := usecase.NewCompanyUsecase(
+		companyRepo,
+		postgres.NewCompanyAuditRepository(dbConn),
+		postgres.NewTxManager(dbConn, companyRepo, conf.DB.Retry),
+	)
+
+	im, err := importer.New(companyUsecase, importer.Options{
+		Mapping:   m,
+		BatchSize: *batchSize,
+		DryRun:    *dryRun,
+		Checkpoint: func(rows int) error {
+			if err := rejects.Sync(); err != nil {
+				return err
+			}
+			return writeProgress(*progressFile, rows)
+		},
+	}, logger)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if skip > 0 {
+		logger.Info().Int("rows", skip).Msg("resuming import")
+	}
+
+	res, err := im.Import(ctx, r, skip, rejects)
+	if err != nil {
+		log.Fatal(fmt.Errorf("import stopped after row %d, rerun to resume: %w", res.Rows, err))
+	}
+
+	if !*dryRun {
+		if err := os.Remove(*progressFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Fatal(fmt.Errorf("failed to remove progress: %w", err))
+		}
+	}
+
+	logger.Info().Int("rows", res.Rows).Int("imported", res.Imported).Int("rejected", res.Rejected).
+		Bool("dry_run", *dryRun).Str("errors", *errorsFile).Msg("import finished")
+}
+
+func newReader(in io.Reader, format, file string) (importer.Reader, error) {
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(file)), ".")
+	}
+
+	switch format {
+	case "csv":
+		return importer.NewCSVReader(in)
+	case "ndjson", "jsonl":
+		return importer.NewNDJSONReader(in), nil
+	default:
+		return nil, fmt.Errorf("unknown format %q, want csv or ndjson", format)
+	}
+}
+
+// readProgress returns the number of the rows imported by the interrupted import, 0 if there is none
+func readProgress(path string) (int, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(strings.TrimSpace(string(b)))
+}
+
+// writeProgress replaces the progress atomically, so an interrupted write doesn't lose it
+func writeProgress(path string, rows int) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strconv.Itoa(rows)+"\n"), 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
@@ -1,8 +1,11 @@
 package http
 
 import (
+	"fmt"
 	"net/http"
 
+	"github.com/AlisskaPie/project-xm/internal/config"
+	"github.com/AlisskaPie/project-xm/internal/user/delivery/http/middleware"
 	"github.com/AlisskaPie/project-xm/pkg/domain"
 
 	"github.com/labstack/echo/v4"
@@ -12,19 +15,33 @@ import (
 // CompanyHandler represent the httphandler for company
 type CompanyHandler struct {
 	Usecase domain.CompanyUsecase
+	conf    config.HTTP
 	log     zerolog.Logger
 }
 
 // NewCompanyHandler will initialize the companies resources endpoint
-func NewCompanyHandler(e *echo.Echo, us domain.CompanyUsecase, auth echo.MiddlewareFunc, log zerolog.Logger) *CompanyHandler {
+func NewCompanyHandler(
+	e *echo.Echo,
+	us domain.CompanyUsecase,
+	auth echo.MiddlewareFunc,
+	conf config.HTTP,
+	log zerolog.Logger,
+) *CompanyHandler {
 	handler := &CompanyHandler{
 		Usecase: us,
+		conf:    conf,
 		log:     log,
 	}
 	e.PATCH("/companies/:id", handler.Patch, auth)
 	e.POST("/companies", handler.Create, auth)
-	e.GET("/companies/:id", handler.GetByID)
+	e.POST(`/companies\:batch`, handler.CreateBatch, auth)
+	e.GET("/companies", handler.List, auth)
+	e.GET("/companies/search", handler.Search, auth)
+	e.GET("/companies/export", handler.Export, auth)
+	e.GET("/companies/:id", handler.GetByID, auth)
+	e.GET("/companies/:id/history", handler.History, auth)
 	e.DELETE("/companies/:id", handler.Delete, auth)
+	e.POST("/companies/:id/restore", handler.Restore, auth)
 
 	return handler
 }
@@ -35,62 +52,275 @@ func (h *CompanyHandler) Create(c echo.Context) error {
 
 	if err := req.BindValidate(c); err != nil {
 		h.log.Err(err).Msg("error while create binding")
-		return c.JSON(http.StatusUnprocessableEntity, NewErrorResponse(domain.ErrBadRequest))
+		return domain.NewError(domain.ErrBadRequest, "", err)
 	}
 
-	if err := h.Usecase.Create(c.Request().Context(), req.ToCreateCompany()); err != nil {
-		h.log.Err(err).Msg("failed to create company by use case")
-		return c.JSON(http.StatusInternalServerError, NewErrorResponse(domain.ErrInternalError))
+	company, err := h.Usecase.Create(c.Request().Context(), req.ToCreateCompany())
+	if err != nil {
+		return fmt.Errorf("failed to create company by use case: %w", err)
 	}
 
-	return c.NoContent(http.StatusCreated)
+	c.Response().Header().Set(echo.HeaderLocation, "/companies/"+company.ID.String())
+	c.Response().Header().Set(HeaderETag, ETag(company.Version))
+	return c.JSON(http.StatusCreated, GetCompanyResponseFromDomain(company))
 }
 
-// GetByID gets company by given id
+// CreateBatch creates the companies of the request body at once.
+// Either all of them or none are created, unless the request is in the best effort mode.
+func (h *CompanyHandler) CreateBatch(c echo.Context) error {
+	req := CreateCompaniesRequest{}
+	if err := req.BindValidate(c); err != nil {
+		h.log.Err(err).Msg("error while create batch binding")
+		return domain.NewError(domain.ErrBadRequest, "", err)
+	}
+
+	invalid := req.ValidateItems()
+	if len(invalid) > 0 && req.Mode != domain.BestEffortBatchMode {
+		return domain.NewError(domain.ErrBadRequest, "", invalid)
+	}
+
+	resp := CreateCompaniesResponse{
+		Results: make([]CreateCompanyResultResponse, len(req.Companies)),
+	}
+	for _, item := range invalid {
+		err := domain.NewError(domain.ErrBadRequest, "", item.Err)
+		resp.Results[item.Index] = GetCreateCompanyResultResponse(domain.Company{}, err)
+	}
+
+	batch := domain.CreateCompanies{Mode: req.Mode}
+	// indexes of the batch companies in the request
+	var indexes []int
+	for i, company := range req.Companies {
+		if resp.Results[i].Error == nil {
+			indexes = append(indexes, i)
+			batch.Companies = append(batch.Companies, company.ToCreateCompany())
+		}
+	}
+
+	results, err := h.Usecase.CreateBatch(c.Request().Context(), batch)
+	if err != nil {
+		return fmt.Errorf("failed to create companies by use case: %w", err)
+	}
+
+	status := http.StatusCreated
+	if len(invalid) > 0 {
+		status = http.StatusMultiStatus
+	}
+	for i, res := range results {
+		resp.Results[indexes[i]] = GetCreateCompanyResultResponse(res.Company, res.Err)
+		if res.Err != nil {
+			status = http.StatusMultiStatus
+		}
+	}
+
+	return c.JSON(status, resp)
+}
+
+// GetByID gets company by given id, as it was at the as_of instant if it's given
 func (h *CompanyHandler) GetByID(c echo.Context) error {
-	idReq := &IDPathRequest{}
-	if err := idReq.BindValidate(c); err != nil {
-		h.log.Err(err).Msg("failed to bind IDPathRequest")
-		return c.JSON(http.StatusUnprocessableEntity, NewErrorResponse(domain.ErrBadRequest))
+	req := &GetCompanyRequest{}
+	if err := req.BindValidate(c); err != nil {
+		h.log.Err(err).Msg("failed to bind GetCompanyRequest")
+		return domain.NewError(domain.ErrBadRequest, "", err)
 	}
 
-	company, err := h.Usecase.GetByID(c.Request().Context(), idReq.ID)
+	if !req.AsOf.IsZero() {
+		company, err := h.Usecase.GetByIDAsOf(c.Request().Context(), req.ID, req.AsOf)
+		if err != nil {
+			return fmt.Errorf("GetByIDAsOf error: %w", err)
+		}
+
+		// a past version would only fail If-Match, so it has no ETag
+		return c.JSON(http.StatusOK, GetCompanyResponseFromDomain(company))
+	}
+
+	company, err := h.Usecase.GetByID(c.Request().Context(), req.ID)
 	if err != nil {
-		h.log.Err(err).Msg("GetByID error")
-		return c.JSON(http.StatusInternalServerError, NewErrorResponse(domain.ErrInternalError))
+		return fmt.Errorf("GetByID error: %w", err)
 	}
 
+	c.Response().Header().Set(HeaderETag, ETag(company.Version))
 	return c.JSON(http.StatusOK, GetCompanyResponseFromDomain(company))
 }
 
+// List lists the companies by given query params
+func (h *CompanyHandler) List(c echo.Context) error {
+	req := &ListCompaniesRequest{}
+	if err := req.BindValidate(c); err != nil {
+		h.log.Err(err).Msg("failed to bind ListCompaniesRequest")
+		return domain.NewError(domain.ErrBadRequest, "", err)
+	}
+
+	page, err := h.Usecase.List(c.Request().Context(), req.ToListCompanies())
+	if err != nil {
+		return fmt.Errorf("failed to list by usecase: %w", err)
+	}
+
+	return c.JSON(http.StatusOK, GetCompanyListResponseFromDomain(page))
+}
+
+// Export streams all the companies matching the query in the format of the Accept header.
+// The response is flushed as it goes, so an error after the first company can only cut it short.
+func (h *CompanyHandler) Export(c echo.Context) error {
+	req := &ExportCompaniesRequest{}
+	if err := req.BindValidate(c); err != nil {
+		h.log.Err(err).Msg("failed to bind ExportCompaniesRequest")
+		return domain.NewError(domain.ErrBadRequest, "", err)
+	}
+
+	contentType, ok := exportContentType(c.Request().Header.Get(echo.HeaderAccept))
+	if !ok {
+		return echo.NewHTTPError(http.StatusNotAcceptable)
+	}
+
+	resp := c.Response()
+	var w companyWriter
+	// the response starts with the first company, so the errors before it are still reported as problems
+	start := func() error {
+		resp.Header().Set(echo.HeaderContentType, contentType)
+		resp.WriteHeader(http.StatusOK)
+
+		var err error
+		w, err = newCompanyWriter(contentType, resp)
+		return err
+	}
+
+	exported := 0
+	err := h.Usecase.Export(c.Request().Context(), req.ToExportCompanies(), func(company domain.Company) error {
+		if w == nil {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+
+		if err := w.Write(company); err != nil {
+			return err
+		}
+
+		exported++
+		if exported%exportFlushRows == 0 {
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			resp.Flush()
+		}
+
+		return nil
+	})
+	if err == nil && w == nil {
+		err = start()
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err != nil {
+		if resp.Committed {
+			h.log.Err(err).Int("exported", exported).Msg("export interrupted")
+		}
+		return fmt.Errorf("failed to export by usecase: %w", err)
+	}
+
+	return nil
+}
+
+// Search finds the companies by given query text
+func (h *CompanyHandler) Search(c echo.Context) error {
+	req := &SearchCompaniesRequest{}
+	if err := req.BindValidate(c); err != nil {
+		h.log.Err(err).Msg("failed to bind SearchCompaniesRequest")
+		return domain.NewError(domain.ErrBadRequest, "", err)
+	}
+
+	results, err := h.Usecase.Search(c.Request().Context(), req.ToSearchCompanies())
+	if err != nil {
+		return fmt.Errorf("failed to search by usecase: %w", err)
+	}
+
+	return c.JSON(http.StatusOK, GetCompanySearchResponseFromDomain(results))
+}
+
+// History lists the audit entries of the company, the newest first
+func (h *CompanyHandler) History(c echo.Context) error {
+	req := &CompanyHistoryRequest{}
+	if err := req.BindValidate(c); err != nil {
+		h.log.Err(err).Msg("failed to bind CompanyHistoryRequest")
+		return domain.NewError(domain.ErrBadRequest, "", err)
+	}
+
+	page, err := h.Usecase.History(c.Request().Context(), req.ToCompanyHistory())
+	if err != nil {
+		return fmt.Errorf("failed to get history by usecase: %w", err)
+	}
+
+	return c.JSON(http.StatusOK, GetCompanyHistoryResponseFromDomain(page))
+}
+
 // Patch patches the company by given request body
 func (h *CompanyHandler) Patch(c echo.Context) (err error) {
 	req := &CompanyPatchRequest{}
 	if err := req.BindValidate(c); err != nil {
 		h.log.Err(err).Msg("failed to bind CompanyPatchRequest")
-		return c.JSON(http.StatusUnprocessableEntity, NewErrorResponse(domain.ErrBadRequest))
+		return domain.NewError(domain.ErrBadRequest, "", err)
+	}
+
+	version, err := ifMatchVersion(c, h.conf.RequireIfMatch)
+	if err != nil {
+		return err
 	}
 
-	company, err := h.Usecase.Patch(c.Request().Context(), req.ID, req.ToPatchCompany())
+	patch := req.ToPatchCompany()
+	patch.Version = version
+
+	company, err := h.Usecase.Patch(c.Request().Context(), req.ID, patch)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, NewErrorResponse(domain.ErrInternalError))
+		return fmt.Errorf("failed to patch by usecase: %w", err)
 	}
 
+	c.Response().Header().Set(HeaderETag, ETag(company.Version))
 	return c.JSON(http.StatusOK, GetCompanyResponseFromDomain(company))
 }
 
-// Delete deletes company by given param
+// Delete deletes company by given param, only admins may purge it
 func (h *CompanyHandler) Delete(c echo.Context) error {
+	req := &DeleteCompanyRequest{}
+	if err := req.BindValidate(c); err != nil {
+		h.log.Err(err).Msg("failed to bind DeleteCompanyRequest")
+		return domain.NewError(domain.ErrBadRequest, "", err)
+	}
+
+	if req.Purge && !middleware.IsAdmin(c) {
+		return echo.ErrForbidden
+	}
+
+	version, err := ifMatchVersion(c, h.conf.RequireIfMatch)
+	if err != nil {
+		return err
+	}
+
+	d := domain.DeleteCompany{
+		Version: version,
+		Purge:   req.Purge,
+	}
+	if err := h.Usecase.Delete(c.Request().Context(), req.ID, d); err != nil {
+		return fmt.Errorf("failed to delete by usecase: %w", err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Restore restores the deleted company by given param
+func (h *CompanyHandler) Restore(c echo.Context) error {
 	idReq := &IDPathRequest{}
 	if err := idReq.BindValidate(c); err != nil {
 		h.log.Err(err).Msg("failed to bind IDPathRequest")
-		return c.JSON(http.StatusUnprocessableEntity, NewErrorResponse(domain.ErrBadRequest))
+		return domain.NewError(domain.ErrBadRequest, "", err)
 	}
 
-	if err := h.Usecase.Delete(c.Request().Context(), idReq.ID); err != nil {
-		h.log.Err(err).Msg("failed to delete by usecase")
-		return c.JSON(http.StatusInternalServerError, NewErrorResponse(domain.ErrInternalError))
+	company, err := h.Usecase.Restore(c.Request().Context(), idReq.ID)
+	if err != nil {
+		return fmt.Errorf("failed to restore by usecase: %w", err)
 	}
 
-	return c.NoContent(http.StatusNoContent)
+	c.Response().Header().Set(HeaderETag, ETag(company.Version))
+	return c.JSON(http.StatusOK, GetCompanyResponseFromDomain(company))
 }
@@ -2,6 +2,7 @@ package http
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -10,8 +11,10 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/brianvoe/gofakeit/v6"
+	"github.com/golang-jwt/jwt"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/rs/zerolog"
@@ -19,6 +22,8 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/AlisskaPie/project-xm/internal/config"
+	"github.com/AlisskaPie/project-xm/internal/user/delivery/http/middleware"
 	"github.com/AlisskaPie/project-xm/pkg/domain"
 	"github.com/AlisskaPie/project-xm/pkg/domain/mocks"
 )
@@ -31,9 +36,21 @@ func TestCreateSuccess(t *testing.T) {
 	js, err := json.Marshal(mockCompanyPostRequest)
 	assert.NoError(t, err)
 
+	mockCompany := domain.Company{
+		ID:                uuid.New(),
+		Name:              mockCompanyPostRequest.Name,
+		Description:       mockCompanyPostRequest.Description,
+		AmountOfEmployees: mockCompanyPostRequest.AmountOfEmployees,
+		Registered:        mockCompanyPostRequest.Registered,
+		CompanyType:       mockCompanyPostRequest.CompanyType,
+		Version:           1,
+	}
+	js2, err := json.Marshal(GetCompanyResponseFromDomain(mockCompany))
+	assert.NoError(t, err)
+
 	mockUseCase := &mocks.CompanyUsecase{}
 	mockUseCase.On("Create", mock.Anything, mockCompanyPostRequest.ToCreateCompany()).
-		Return(nil)
+		Return(mockCompany, nil)
 
 	e := echo.New()
 	req, err := http.NewRequest(echo.POST, "/companies", bytes.NewReader(js))
@@ -43,11 +60,17 @@ func TestCreateSuccess(t *testing.T) {
 
 	rec := httptest.NewRecorder()
 	c := e.NewContext(req, rec)
-	handler := NewCompanyHandler(e, mockUseCase, nil, zerolog.New(io.Discard))
+	handler := NewCompanyHandler(e, mockUseCase, nil, config.HTTP{}, zerolog.New(io.Discard))
 	err = handler.Create(c)
 	require.NoError(t, err)
 
 	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "/companies/"+mockCompany.ID.String(), rec.Header().Get(echo.HeaderLocation))
+	assert.Equal(t, `"1"`, rec.Header().Get(HeaderETag))
+	assert.Equal(t,
+		strings.Trim(string(js2), " \n"),
+		strings.Trim(rec.Body.String(), " \n"),
+	)
 	mockUseCase.AssertExpectations(t)
 }
 
@@ -67,13 +90,14 @@ func TestCreateFailed_Validation(t *testing.T) {
 
 	rec := httptest.NewRecorder()
 	c := e.NewContext(req, rec)
-	handler := NewCompanyHandler(e, mockUseCase, nil, zerolog.New(io.Discard))
+	handler := NewCompanyHandler(e, mockUseCase, nil, config.HTTP{}, zerolog.New(io.Discard))
 	err = handler.Create(c)
-	require.NoError(t, err)
+	require.Error(t, err)
+	NewHTTPErrorHandler(zerolog.New(io.Discard))(err, c)
 
 	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
 	assert.Equal(t,
-		strings.Trim(`{"message":"failed with invalid request parameters"}`, " \n"),
+		strings.Trim(`{"type":"urn:projectxm:problem:invalid-request","title":"Invalid request parameters","status":422,"errors":[{"field":"name","rule":"required"},{"field":"amount_of_employees","rule":"required"},{"field":"type","rule":"required"}]}`, " \n"),
 		strings.Trim(rec.Body.String(), " \n"),
 	)
 	mockUseCase.AssertExpectations(t)
@@ -89,7 +113,7 @@ func TestCreateFailed_InternalError(t *testing.T) {
 
 	expError := errors.New("some error")
 	mockUseCase := &mocks.CompanyUsecase{}
-	mockUseCase.On("Create", mock.Anything, mock.Anything).Return(expError)
+	mockUseCase.On("Create", mock.Anything, mock.Anything).Return(domain.Company{}, expError)
 
 	e := echo.New()
 	req, err := http.NewRequest(echo.POST, "/companies", bytes.NewReader(js))
@@ -99,13 +123,163 @@ func TestCreateFailed_InternalError(t *testing.T) {
 
 	rec := httptest.NewRecorder()
 	c := e.NewContext(req, rec)
-	handler := NewCompanyHandler(e, mockUseCase, nil, zerolog.New(io.Discard))
+	handler := NewCompanyHandler(e, mockUseCase, nil, config.HTTP{}, zerolog.New(io.Discard))
 	err = handler.Create(c)
-	require.NoError(t, err)
+	require.Error(t, err)
+	NewHTTPErrorHandler(zerolog.New(io.Discard))(err, c)
 
 	assert.Equal(t, http.StatusInternalServerError, rec.Code)
 	assert.Equal(t,
-		strings.Trim(`{"message":"failed with internal error"}`, " \n"),
+		strings.Trim(`{"type":"urn:projectxm:problem:internal-error","title":"Internal error","status":500}`, " \n"),
+		strings.Trim(rec.Body.String(), " \n"),
+	)
+	mockUseCase.AssertExpectations(t)
+}
+
+func TestCreateBatchSuccess(t *testing.T) {
+	mockCompany := domain.Company{
+		ID:                uuid.MustParse("10000000-0000-0000-0000-000000000000"),
+		Name:              "1",
+		AmountOfEmployees: 10,
+		CompanyType:       domain.CooperativeType,
+		Version:           1,
+	}
+	batch := domain.CreateCompanies{
+		Companies: []domain.CreateCompany{{
+			ID:                mockCompany.ID,
+			Name:              "1",
+			AmountOfEmployees: 10,
+			CompanyType:       domain.CooperativeType,
+		}},
+	}
+
+	mockUseCase := &mocks.CompanyUsecase{}
+	mockUseCase.On("CreateBatch", mock.Anything, batch).
+		Return([]domain.CreateCompanyResult{{Company: mockCompany}}, nil)
+
+	e := echo.New()
+	req, err := http.NewRequest(echo.POST, "/companies:batch", strings.NewReader(
+		`[{"id":"10000000-0000-0000-0000-000000000000","name":"1","amount_of_employees":10,"type":"Cooperative"}]`,
+	))
+	assert.NoError(t, err)
+
+	req.Header.Add("Content-Type", "application/json")
+
+	rec := httptest.NewRecorder()
+	passthrough := func(next echo.HandlerFunc) echo.HandlerFunc { return next }
+	NewCompanyHandler(e, mockUseCase, passthrough, config.HTTP{}, zerolog.New(io.Discard))
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t,
+		strings.Trim(`{"results":[{"status":201,"company":{"id":"10000000-0000-0000-0000-000000000000","name":"1",`+
+			`"amount_of_employees":10,"registered":false,"type":"Cooperative","version":1}}]}`, " \n"),
+		strings.Trim(rec.Body.String(), " \n"),
+	)
+	mockUseCase.AssertExpectations(t)
+}
+
+func TestCreateBatchSuccess_BestEffort(t *testing.T) {
+	mockCompany := domain.Company{
+		ID:                uuid.MustParse("10000000-0000-0000-0000-000000000000"),
+		Name:              "1",
+		AmountOfEmployees: 10,
+		CompanyType:       domain.CooperativeType,
+		Version:           1,
+	}
+	batch := domain.CreateCompanies{
+		Companies: []domain.CreateCompany{
+			{ID: mockCompany.ID, Name: "1", AmountOfEmployees: 10, CompanyType: domain.CooperativeType},
+			{ID: mockCompany.ID, Name: "3", AmountOfEmployees: 10, CompanyType: domain.CooperativeType},
+		},
+		Mode: domain.BestEffortBatchMode,
+	}
+
+	mockUseCase := &mocks.CompanyUsecase{}
+	mockUseCase.On("CreateBatch", mock.Anything, batch).
+		Return([]domain.CreateCompanyResult{
+			{Company: mockCompany},
+			{Err: domain.NewError(domain.ErrConflict, "company already exists", nil)},
+		}, nil)
+
+	e := echo.New()
+	req, err := http.NewRequest(echo.POST, "/companies:batch?mode=best_effort", strings.NewReader(
+		`[{"id":"10000000-0000-0000-0000-000000000000","name":"1","amount_of_employees":10,"type":"Cooperative"},`+
+			`{"name":"2","type":"Other"},`+
+			`{"id":"10000000-0000-0000-0000-000000000000","name":"3","amount_of_employees":10,"type":"Cooperative"}]`,
+	))
+	assert.NoError(t, err)
+
+	req.Header.Add("Content-Type", "application/json")
+
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	handler := NewCompanyHandler(e, mockUseCase, nil, config.HTTP{}, zerolog.New(io.Discard))
+	err = handler.CreateBatch(c)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusMultiStatus, rec.Code)
+	assert.Equal(t,
+		strings.Trim(`{"results":[`+
+			`{"status":201,"company":{"id":"10000000-0000-0000-0000-000000000000","name":"1",`+
+			`"amount_of_employees":10,"registered":false,"type":"Cooperative","version":1}},`+
+			`{"status":422,"error":{"type":"urn:projectxm:problem:invalid-request","title":"Invalid request parameters",`+
+			`"status":422,"errors":[{"field":"amount_of_employees","rule":"required"},`+
+			`{"field":"type","rule":"company_type"}]}},`+
+			`{"status":409,"error":{"type":"urn:projectxm:problem:conflict","title":"Conflicting resource state",`+
+			`"status":409,"detail":"company already exists"}}]}`, " \n"),
+		strings.Trim(rec.Body.String(), " \n"),
+	)
+	mockUseCase.AssertExpectations(t)
+}
+
+func TestCreateBatchFailed_Validation(t *testing.T) {
+	mockUseCase := &mocks.CompanyUsecase{}
+
+	e := echo.New()
+	req, err := http.NewRequest(echo.POST, "/companies:batch", strings.NewReader(
+		`[{"name":"1","amount_of_employees":10,"type":"Cooperative"},{"amount_of_employees":10,"type":"Cooperative"}]`,
+	))
+	assert.NoError(t, err)
+
+	req.Header.Add("Content-Type", "application/json")
+
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	handler := NewCompanyHandler(e, mockUseCase, nil, config.HTTP{}, zerolog.New(io.Discard))
+	err = handler.CreateBatch(c)
+	require.Error(t, err)
+	NewHTTPErrorHandler(zerolog.New(io.Discard))(err, c)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t,
+		strings.Trim(`{"type":"urn:projectxm:problem:invalid-request","title":"Invalid request parameters","status":422,`+
+			`"errors":[{"field":"[1].name","rule":"required"}]}`, " \n"),
+		strings.Trim(rec.Body.String(), " \n"),
+	)
+	mockUseCase.AssertExpectations(t)
+}
+
+func TestCreateBatchFailed_Empty(t *testing.T) {
+	mockUseCase := &mocks.CompanyUsecase{}
+
+	e := echo.New()
+	req, err := http.NewRequest(echo.POST, "/companies:batch?mode=best_effort", strings.NewReader(`[]`))
+	assert.NoError(t, err)
+
+	req.Header.Add("Content-Type", "application/json")
+
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	handler := NewCompanyHandler(e, mockUseCase, nil, config.HTTP{}, zerolog.New(io.Discard))
+	err = handler.CreateBatch(c)
+	require.Error(t, err)
+	NewHTTPErrorHandler(zerolog.New(io.Discard))(err, c)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t,
+		strings.Trim(`{"type":"urn:projectxm:problem:invalid-request","title":"Invalid request parameters","status":422,`+
+			`"errors":[{"field":"companies","rule":"min"}]}`, " \n"),
 		strings.Trim(rec.Body.String(), " \n"),
 	)
 	mockUseCase.AssertExpectations(t)
@@ -142,7 +316,7 @@ func TestGetByIDSuccess(t *testing.T) {
 	c.SetPath("/companies/:id")
 	c.SetParamNames("id")
 	c.SetParamValues(mockCompanyIDRequest.ID.String())
-	handler := NewCompanyHandler(e, mockUseCase, nil, zerolog.New(io.Discard))
+	handler := NewCompanyHandler(e, mockUseCase, nil, config.HTTP{}, zerolog.New(io.Discard))
 	err = handler.GetByID(c)
 	require.NoError(t, err)
 
@@ -154,6 +328,113 @@ func TestGetByIDSuccess(t *testing.T) {
 	mockUseCase.AssertExpectations(t)
 }
 
+func TestGetByID_Tenant(t *testing.T) {
+	key := []byte("secret")
+	id := uuid.New()
+
+	mockUseCase := &mocks.CompanyUsecase{}
+	inTenant := mock.MatchedBy(func(ctx context.Context) bool {
+		return domain.TenantFromContext(ctx) == "acme"
+	})
+	mockUseCase.On("GetByID", inTenant, id).Return(domain.Company{ID: id}, nil).Once()
+
+	e := echo.New()
+	NewCompanyHandler(e, mockUseCase, middleware.Auth(key, "tenant_id"), config.HTTP{}, zerolog.New(io.Discard))
+
+	tests := []struct {
+		name     string
+		claims   jwt.MapClaims
+		wantCode int
+	}{
+		{name: "Tenant", claims: jwt.MapClaims{"sub": "1", "tenant_id": "acme"}, wantCode: http.StatusOK},
+		{name: "NoTenant", claims: jwt.MapClaims{"sub": "1"}, wantCode: http.StatusForbidden},
+		{name: "NoToken", wantCode: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(echo.GET, "/companies/"+id.String(), nil)
+			require.NoError(t, err)
+			if tt.claims != nil {
+				token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, tt.claims).SignedString(key)
+				require.NoError(t, err)
+				req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+			}
+
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			assert.Equal(t, tt.wantCode, rec.Code)
+		})
+	}
+
+	mockUseCase.AssertExpectations(t)
+}
+
+func TestGetByIDSuccess_AsOf(t *testing.T) {
+	mockCompany := domain.Company{
+		ID:          uuid.New(),
+		Name:        "1",
+		CompanyType: domain.CooperativeType,
+		Version:     2,
+	}
+	at := time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)
+
+	mockUseCase := &mocks.CompanyUsecase{}
+	mockUseCase.On("GetByIDAsOf", mock.Anything, mockCompany.ID, at).Return(mockCompany, nil)
+
+	e := echo.New()
+	req, err := http.NewRequest(
+		echo.GET,
+		fmt.Sprintf("/companies/%s?as_of=2026-03-31T00:00:00Z", mockCompany.ID.String()),
+		nil,
+	)
+	assert.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/companies/:id")
+	c.SetParamNames("id")
+	c.SetParamValues(mockCompany.ID.String())
+	handler := NewCompanyHandler(e, mockUseCase, nil, config.HTTP{}, zerolog.New(io.Discard))
+	err = handler.GetByID(c)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get(HeaderETag))
+	assert.JSONEq(t,
+		fmt.Sprintf(`{"id":"%s","name":"1","amount_of_employees":0,"registered":false,"type":"Cooperative","version":2}`,
+			mockCompany.ID),
+		rec.Body.String(),
+	)
+	mockUseCase.AssertExpectations(t)
+}
+
+func TestGetByIDFailed_AsOfValidation(t *testing.T) {
+	id := uuid.New()
+	mockUseCase := &mocks.CompanyUsecase{}
+
+	e := echo.New()
+	req, err := http.NewRequest(echo.GET, fmt.Sprintf("/companies/%s?as_of=2026-03-31", id), nil)
+	assert.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/companies/:id")
+	c.SetParamNames("id")
+	c.SetParamValues(id.String())
+	handler := NewCompanyHandler(e, mockUseCase, nil, config.HTTP{}, zerolog.New(io.Discard))
+	err = handler.GetByID(c)
+	require.Error(t, err)
+	NewHTTPErrorHandler(zerolog.New(io.Discard))(err, c)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t,
+		strings.Trim(`{"type":"urn:projectxm:problem:invalid-request","title":"Invalid request parameters","status":422,`+
+			`"errors":[{"field":"as_of","rule":"type"}]}`, " \n"),
+		strings.Trim(rec.Body.String(), " \n"),
+	)
+	mockUseCase.AssertExpectations(t)
+}
+
 func TestGetByIDFailed_Validation(t *testing.T) {
 	var mockCompany domain.Company
 	err := gofakeit.Struct(&mockCompany)
@@ -184,13 +465,14 @@ func TestGetByIDFailed_Validation(t *testing.T) {
 	c := e.NewContext(req, rec)
 	c.SetPath("/companies/:id")
 	c.SetParamNames("id")
-	handler := NewCompanyHandler(e, mockUseCase, nil, zerolog.New(io.Discard))
+	handler := NewCompanyHandler(e, mockUseCase, nil, config.HTTP{}, zerolog.New(io.Discard))
 	err = handler.GetByID(c)
-	require.NoError(t, err)
+	require.Error(t, err)
+	NewHTTPErrorHandler(zerolog.New(io.Discard))(err, c)
 
 	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
 	assert.Equal(t,
-		strings.Trim(`{"message":"failed with invalid request parameters"}`, " \n"),
+		strings.Trim(`{"type":"urn:projectxm:problem:invalid-request","title":"Invalid request parameters","status":422,"errors":[{"field":"id","rule":"required"}]}`, " \n"),
 		strings.Trim(rec.Body.String(), " \n"),
 	)
 	mockUseCase.AssertExpectations(t)
@@ -227,13 +509,14 @@ func TestGetByIDFailed_InternalError(t *testing.T) {
 	c.SetPath("/companies/:id")
 	c.SetParamNames("id")
 	c.SetParamValues(mockCompanyIDRequest.ID.String())
-	handler := NewCompanyHandler(e, mockUseCase, nil, zerolog.New(io.Discard))
+	handler := NewCompanyHandler(e, mockUseCase, nil, config.HTTP{}, zerolog.New(io.Discard))
 	err = handler.GetByID(c)
-	require.NoError(t, err)
+	require.Error(t, err)
+	NewHTTPErrorHandler(zerolog.New(io.Discard))(err, c)
 
 	assert.Equal(t, http.StatusInternalServerError, rec.Code)
 	assert.Equal(t,
-		strings.Trim(`{"message":"failed with internal error"}`, " \n"),
+		strings.Trim(`{"type":"urn:projectxm:problem:internal-error","title":"Internal error","status":500}`, " \n"),
 		strings.Trim(rec.Body.String(), " \n"),
 	)
 	mockUseCase.AssertExpectations(t)
@@ -242,6 +525,7 @@ func TestGetByIDFailed_InternalError(t *testing.T) {
 func TestPatchSuccess(t *testing.T) {
 	var mockCompanyPatchRequest CompanyPatchRequest
 	err := gofakeit.Struct(&mockCompanyPatchRequest)
+	mockCompanyPatchRequest.CompanyType = getPointer(domain.CorporationsType)
 	assert.NoError(t, err)
 	js1, err := json.Marshal(mockCompanyPatchRequest)
 	assert.NoError(t, err)
@@ -272,7 +556,7 @@ func TestPatchSuccess(t *testing.T) {
 	c.SetParamNames("id")
 	c.SetParamValues(mockCompanyPatchRequest.ID.String())
 
-	handler := NewCompanyHandler(e, mockUseCase, nil, zerolog.New(io.Discard))
+	handler := NewCompanyHandler(e, mockUseCase, nil, config.HTTP{}, zerolog.New(io.Discard))
 	err = handler.Patch(c)
 	require.NoError(t, err)
 
@@ -284,6 +568,87 @@ func TestPatchSuccess(t *testing.T) {
 	mockUseCase.AssertExpectations(t)
 }
 
+func TestPatchSuccess_IfMatch(t *testing.T) {
+	var mockCompanyPatchRequest CompanyPatchRequest
+	err := gofakeit.Struct(&mockCompanyPatchRequest)
+	mockCompanyPatchRequest.CompanyType = getPointer(domain.CorporationsType)
+	assert.NoError(t, err)
+	js, err := json.Marshal(mockCompanyPatchRequest)
+	assert.NoError(t, err)
+
+	mockCompany := domain.Company{ID: mockCompanyPatchRequest.ID, Version: 4}
+	expPatch := mockCompanyPatchRequest.ToPatchCompany()
+	expPatch.Version = getPointer(int64(3))
+
+	mockUseCase := &mocks.CompanyUsecase{}
+	mockUseCase.On("Patch", mock.Anything, mockCompanyPatchRequest.ID, expPatch).Return(mockCompany, nil)
+
+	req, err := http.NewRequest(
+		echo.PATCH,
+		fmt.Sprintf("/companies/%s", mockCompanyPatchRequest.ID.String()),
+		bytes.NewReader(js),
+	)
+	assert.NoError(t, err)
+
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add(HeaderIfMatch, `"3"`)
+
+	rec := httptest.NewRecorder()
+	e := echo.New()
+	c := e.NewContext(req, rec)
+	c.SetPath("/companies/:id")
+	c.SetParamNames("id")
+	c.SetParamValues(mockCompanyPatchRequest.ID.String())
+
+	handler := NewCompanyHandler(e, mockUseCase, nil, config.HTTP{RequireIfMatch: true}, zerolog.New(io.Discard))
+	err = handler.Patch(c)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `"4"`, rec.Header().Get(HeaderETag))
+	mockUseCase.AssertExpectations(t)
+}
+
+func TestPatchFailed_PreconditionRequired(t *testing.T) {
+	var mockCompanyPatchRequest CompanyPatchRequest
+	err := gofakeit.Struct(&mockCompanyPatchRequest)
+	mockCompanyPatchRequest.CompanyType = getPointer(domain.CorporationsType)
+	assert.NoError(t, err)
+	js, err := json.Marshal(mockCompanyPatchRequest)
+	assert.NoError(t, err)
+
+	mockUseCase := &mocks.CompanyUsecase{}
+
+	req, err := http.NewRequest(
+		echo.PATCH,
+		fmt.Sprintf("/companies/%s", mockCompanyPatchRequest.ID.String()),
+		bytes.NewReader(js),
+	)
+	assert.NoError(t, err)
+
+	req.Header.Add("Content-Type", "application/json")
+
+	rec := httptest.NewRecorder()
+	e := echo.New()
+	c := e.NewContext(req, rec)
+	c.SetPath("/companies/:id")
+	c.SetParamNames("id")
+	c.SetParamValues(mockCompanyPatchRequest.ID.String())
+
+	handler := NewCompanyHandler(e, mockUseCase, nil, config.HTTP{RequireIfMatch: true}, zerolog.New(io.Discard))
+	err = handler.Patch(c)
+	require.Error(t, err)
+	NewHTTPErrorHandler(zerolog.New(io.Discard))(err, c)
+
+	assert.Equal(t, http.StatusPreconditionRequired, rec.Code)
+	assert.Equal(t,
+		strings.Trim(`{"type":"urn:projectxm:problem:precondition-required","title":"Resource version required",`+
+			`"status":428,"detail":"If-Match header is required"}`, " \n"),
+		strings.Trim(rec.Body.String(), " \n"),
+	)
+	mockUseCase.AssertExpectations(t)
+}
+
 func TestPatchFailed_Validation(t *testing.T) {
 	mockCompanyPatchRequest := CompanyPatchRequest{}
 	js, err := json.Marshal(mockCompanyPatchRequest)
@@ -299,13 +664,51 @@ func TestPatchFailed_Validation(t *testing.T) {
 
 	rec := httptest.NewRecorder()
 	c := e.NewContext(req, rec)
-	handler := NewCompanyHandler(e, mockUseCase, nil, zerolog.New(io.Discard))
+	handler := NewCompanyHandler(e, mockUseCase, nil, config.HTTP{}, zerolog.New(io.Discard))
 	err = handler.Patch(c)
-	require.NoError(t, err)
+	require.Error(t, err)
+	NewHTTPErrorHandler(zerolog.New(io.Discard))(err, c)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t,
+		strings.Trim(`{"type":"urn:projectxm:problem:invalid-request","title":"Invalid request parameters","status":422,"errors":[{"field":"id","rule":"required"}]}`, " \n"),
+		strings.Trim(rec.Body.String(), " \n"),
+	)
+	mockUseCase.AssertExpectations(t)
+}
+
+func TestPatchFailed_FieldValidation(t *testing.T) {
+	js, err := json.Marshal(CompanyPatchRequest{
+		ID:          uuid.MustParse(testUUID),
+		Name:        getPointer(strings.Repeat("a", 16)),
+		Description: getPointer(strings.Repeat("a", 3001)),
+		CompanyType: getPointer(domain.CompanyType("Partnership")),
+	})
+	assert.NoError(t, err)
+
+	mockUseCase := &mocks.CompanyUsecase{}
+
+	e := echo.New()
+	req, err := http.NewRequest(echo.PATCH, "/companies/"+testUUID, bytes.NewReader(js))
+	assert.NoError(t, err)
+
+	req.Header.Add("Content-Type", "application/json")
+
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/companies/:id")
+	c.SetParamNames("id")
+	c.SetParamValues(testUUID)
+	handler := NewCompanyHandler(e, mockUseCase, nil, config.HTTP{}, zerolog.New(io.Discard))
+	err = handler.Patch(c)
+	require.Error(t, err)
+	NewHTTPErrorHandler(zerolog.New(io.Discard))(err, c)
 
 	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
 	assert.Equal(t,
-		strings.Trim(`{"message":"failed with invalid request parameters"}`, " \n"),
+		`{"type":"urn:projectxm:problem:invalid-request","title":"Invalid request parameters","status":422,`+
+			`"errors":[{"field":"name","rule":"max"},{"field":"description","rule":"max"},`+
+			`{"field":"type","rule":"company_type"}]}`,
 		strings.Trim(rec.Body.String(), " \n"),
 	)
 	mockUseCase.AssertExpectations(t)
@@ -314,6 +717,7 @@ func TestPatchFailed_Validation(t *testing.T) {
 func TestPatchFailed_InternalError(t *testing.T) {
 	var mockCompanyPatchRequest CompanyPatchRequest
 	err := gofakeit.Struct(&mockCompanyPatchRequest)
+	mockCompanyPatchRequest.CompanyType = getPointer(domain.CorporationsType)
 	assert.NoError(t, err)
 	js, err := json.Marshal(mockCompanyPatchRequest)
 	assert.NoError(t, err)
@@ -323,20 +727,28 @@ func TestPatchFailed_InternalError(t *testing.T) {
 	mockUseCase.On("Patch", mock.Anything, mock.Anything, mock.Anything).Return(domain.Company{}, expError)
 
 	e := echo.New()
-	req, err := http.NewRequest(echo.PATCH, "/companies/123", bytes.NewReader(js))
+	req, err := http.NewRequest(
+		echo.PATCH,
+		fmt.Sprintf("/companies/%s", mockCompanyPatchRequest.ID.String()),
+		bytes.NewReader(js),
+	)
 	assert.NoError(t, err)
 
 	req.Header.Add("Content-Type", "application/json")
 
 	rec := httptest.NewRecorder()
 	c := e.NewContext(req, rec)
-	handler := NewCompanyHandler(e, mockUseCase, nil, zerolog.New(io.Discard))
+	c.SetPath("/companies/:id")
+	c.SetParamNames("id")
+	c.SetParamValues(mockCompanyPatchRequest.ID.String())
+	handler := NewCompanyHandler(e, mockUseCase, nil, config.HTTP{}, zerolog.New(io.Discard))
 	err = handler.Patch(c)
-	require.NoError(t, err)
+	require.Error(t, err)
+	NewHTTPErrorHandler(zerolog.New(io.Discard))(err, c)
 
 	assert.Equal(t, http.StatusInternalServerError, rec.Code)
 	assert.Equal(t,
-		strings.Trim(`{"message":"failed with internal error"}`, " \n"),
+		strings.Trim(`{"type":"urn:projectxm:problem:internal-error","title":"Internal error","status":500}`, " \n"),
 		strings.Trim(rec.Body.String(), " \n"),
 	)
 	mockUseCase.AssertExpectations(t)
@@ -348,7 +760,7 @@ func TestDeleteSuccess(t *testing.T) {
 	assert.NoError(t, err)
 
 	mockUseCase := &mocks.CompanyUsecase{}
-	mockUseCase.On("Delete", mock.Anything, mockIDRequest.ID).Return(nil)
+	mockUseCase.On("Delete", mock.Anything, mockIDRequest.ID, domain.DeleteCompany{}).Return(nil)
 
 	req, err := http.NewRequest(
 		echo.DELETE,
@@ -362,7 +774,7 @@ func TestDeleteSuccess(t *testing.T) {
 	rec := httptest.NewRecorder()
 	e := echo.New()
 
-	handler := NewCompanyHandler(e, mockUseCase, nil, zerolog.New(io.Discard))
+	handler := NewCompanyHandler(e, mockUseCase, nil, config.HTTP{}, zerolog.New(io.Discard))
 	c := e.NewContext(req, rec)
 	c.SetPath("/companies/:id")
 	c.SetParamNames("id")
@@ -374,39 +786,141 @@ func TestDeleteSuccess(t *testing.T) {
 	mockUseCase.AssertExpectations(t)
 }
 
-func TestDeleteFailed_Validation(t *testing.T) {
+func TestDeleteSuccess_Purge(t *testing.T) {
+	mockIDRequest := &IDPathRequest{}
+	err := gofakeit.Struct(&mockIDRequest)
+	assert.NoError(t, err)
+
 	mockUseCase := &mocks.CompanyUsecase{}
+	mockUseCase.On("Delete", mock.Anything, mockIDRequest.ID, domain.DeleteCompany{Purge: true}).Return(nil)
 
-	e := echo.New()
-	req, err := http.NewRequest(echo.DELETE, "/companies/123", nil)
+	req, err := http.NewRequest(
+		echo.DELETE,
+		fmt.Sprintf("/companies/%s?purge=true", mockIDRequest.ID.String()),
+		nil,
+	)
 	assert.NoError(t, err)
 
-	req.Header.Add("Content-Type", "application/json")
-
 	rec := httptest.NewRecorder()
+	e := echo.New()
+
+	handler := NewCompanyHandler(e, mockUseCase, nil, config.HTTP{}, zerolog.New(io.Discard))
 	c := e.NewContext(req, rec)
-	handler := NewCompanyHandler(e, mockUseCase, nil, zerolog.New(io.Discard))
+	c.SetPath("/companies/:id")
+	c.SetParamNames("id")
+	c.SetParamValues(mockIDRequest.ID.String())
+	c.Set("user", &jwt.Token{Claims: jwt.MapClaims{"admin": true}})
 	err = handler.Delete(c)
 	require.NoError(t, err)
 
-	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
-	assert.Equal(t,
-		strings.Trim(`{"message":"failed with invalid request parameters"}`, " \n"),
-		strings.Trim(rec.Body.String(), " \n"),
-	)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
 	mockUseCase.AssertExpectations(t)
 }
 
-func TestDeleteFailed_InternalError(t *testing.T) {
-	var mockIDPathRequest IDPathRequest
-	err := gofakeit.Struct(&mockIDPathRequest)
+func TestDeleteFailed_PurgeForbidden(t *testing.T) {
+	mockIDRequest := &IDPathRequest{}
+	err := gofakeit.Struct(&mockIDRequest)
 	assert.NoError(t, err)
 
-	expError := errors.New("some error")
 	mockUseCase := &mocks.CompanyUsecase{}
-	mockUseCase.On("Delete", mock.Anything, mock.Anything).Return(expError)
 
-	e := echo.New()
+	req, err := http.NewRequest(
+		echo.DELETE,
+		fmt.Sprintf("/companies/%s?purge=true", mockIDRequest.ID.String()),
+		nil,
+	)
+	assert.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	e := echo.New()
+
+	handler := NewCompanyHandler(e, mockUseCase, nil, config.HTTP{}, zerolog.New(io.Discard))
+	c := e.NewContext(req, rec)
+	c.SetPath("/companies/:id")
+	c.SetParamNames("id")
+	c.SetParamValues(mockIDRequest.ID.String())
+	c.Set("user", &jwt.Token{Claims: jwt.MapClaims{"name": "John Doe"}})
+	err = handler.Delete(c)
+	require.Error(t, err)
+	NewHTTPErrorHandler(zerolog.New(io.Discard))(err, c)
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	mockUseCase.AssertExpectations(t)
+}
+
+func TestDeleteFailed_PreconditionFailed(t *testing.T) {
+	var mockIDPathRequest IDPathRequest
+	err := gofakeit.Struct(&mockIDPathRequest)
+	assert.NoError(t, err)
+
+	expError := domain.NewError(domain.ErrPreconditionFailed, "company version is 4", nil)
+	mockUseCase := &mocks.CompanyUsecase{}
+	mockUseCase.On("Delete", mock.Anything, mockIDPathRequest.ID, domain.DeleteCompany{Version: getPointer(int64(3))}).
+		Return(expError)
+
+	e := echo.New()
+	req, err := http.NewRequest(
+		echo.DELETE,
+		fmt.Sprintf("/companies/%s", mockIDPathRequest.ID.String()),
+		nil,
+	)
+	assert.NoError(t, err)
+
+	req.Header.Add(HeaderIfMatch, `"3"`)
+
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/companies/:id")
+	c.SetParamNames("id")
+	c.SetParamValues(mockIDPathRequest.ID.String())
+	handler := NewCompanyHandler(e, mockUseCase, nil, config.HTTP{}, zerolog.New(io.Discard))
+	err = handler.Delete(c)
+	require.Error(t, err)
+	NewHTTPErrorHandler(zerolog.New(io.Discard))(err, c)
+
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+	assert.Equal(t,
+		strings.Trim(`{"type":"urn:projectxm:problem:precondition-failed","title":"Resource modified",`+
+			`"status":412,"detail":"company version is 4"}`, " \n"),
+		strings.Trim(rec.Body.String(), " \n"),
+	)
+	mockUseCase.AssertExpectations(t)
+}
+
+func TestDeleteFailed_Validation(t *testing.T) {
+	mockUseCase := &mocks.CompanyUsecase{}
+
+	e := echo.New()
+	req, err := http.NewRequest(echo.DELETE, "/companies/123", nil)
+	assert.NoError(t, err)
+
+	req.Header.Add("Content-Type", "application/json")
+
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	handler := NewCompanyHandler(e, mockUseCase, nil, config.HTTP{}, zerolog.New(io.Discard))
+	err = handler.Delete(c)
+	require.Error(t, err)
+	NewHTTPErrorHandler(zerolog.New(io.Discard))(err, c)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t,
+		strings.Trim(`{"type":"urn:projectxm:problem:invalid-request","title":"Invalid request parameters","status":422,"errors":[{"field":"id","rule":"required"}]}`, " \n"),
+		strings.Trim(rec.Body.String(), " \n"),
+	)
+	mockUseCase.AssertExpectations(t)
+}
+
+func TestDeleteFailed_InternalError(t *testing.T) {
+	var mockIDPathRequest IDPathRequest
+	err := gofakeit.Struct(&mockIDPathRequest)
+	assert.NoError(t, err)
+
+	expError := errors.New("some error")
+	mockUseCase := &mocks.CompanyUsecase{}
+	mockUseCase.On("Delete", mock.Anything, mock.Anything, mock.Anything).Return(expError)
+
+	e := echo.New()
 	req, err := http.NewRequest(
 		echo.DELETE,
 		fmt.Sprintf("/companies/%s", mockIDPathRequest.ID.String()),
@@ -421,13 +935,464 @@ func TestDeleteFailed_InternalError(t *testing.T) {
 	c.SetPath("/companies/:id")
 	c.SetParamNames("id")
 	c.SetParamValues(mockIDPathRequest.ID.String())
-	handler := NewCompanyHandler(e, mockUseCase, nil, zerolog.New(io.Discard))
+	handler := NewCompanyHandler(e, mockUseCase, nil, config.HTTP{}, zerolog.New(io.Discard))
 	err = handler.Delete(c)
+	require.Error(t, err)
+	NewHTTPErrorHandler(zerolog.New(io.Discard))(err, c)
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t,
+		strings.Trim(`{"type":"urn:projectxm:problem:internal-error","title":"Internal error","status":500}`, " \n"),
+		strings.Trim(rec.Body.String(), " \n"),
+	)
+	mockUseCase.AssertExpectations(t)
+}
+
+func TestRestoreSuccess(t *testing.T) {
+	mockCompany := domain.Company{
+		ID:          uuid.New(),
+		Name:        "1",
+		CompanyType: domain.CooperativeType,
+		Version:     3,
+	}
+
+	mockUseCase := &mocks.CompanyUsecase{}
+	mockUseCase.On("Restore", mock.Anything, mockCompany.ID).Return(mockCompany, nil)
+
+	req, err := http.NewRequest(
+		echo.POST,
+		fmt.Sprintf("/companies/%s/restore", mockCompany.ID.String()),
+		nil,
+	)
+	assert.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	e := echo.New()
+
+	handler := NewCompanyHandler(e, mockUseCase, nil, config.HTTP{}, zerolog.New(io.Discard))
+	c := e.NewContext(req, rec)
+	c.SetPath("/companies/:id/restore")
+	c.SetParamNames("id")
+	c.SetParamValues(mockCompany.ID.String())
+	err = handler.Restore(c)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `"3"`, rec.Header().Get(HeaderETag))
+	mockUseCase.AssertExpectations(t)
+}
+
+func TestRestoreFailed_NotFound(t *testing.T) {
+	id := uuid.New()
+
+	mockUseCase := &mocks.CompanyUsecase{}
+	mockUseCase.On("Restore", mock.Anything, id).
+		Return(domain.Company{}, domain.NewError(domain.ErrNotFound, "deleted company does not exist", nil))
+
+	req, err := http.NewRequest(echo.POST, fmt.Sprintf("/companies/%s/restore", id.String()), nil)
+	assert.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	e := echo.New()
+
+	handler := NewCompanyHandler(e, mockUseCase, nil, config.HTTP{}, zerolog.New(io.Discard))
+	c := e.NewContext(req, rec)
+	c.SetPath("/companies/:id/restore")
+	c.SetParamNames("id")
+	c.SetParamValues(id.String())
+	err = handler.Restore(c)
+	require.Error(t, err)
+	NewHTTPErrorHandler(zerolog.New(io.Discard))(err, c)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	mockUseCase.AssertExpectations(t)
+}
+
+func TestCreateFailed_Conflict(t *testing.T) {
+	var mockCompanyPostRequest CompanyPostRequest
+	err := gofakeit.Struct(&mockCompanyPostRequest)
+	mockCompanyPostRequest.CompanyType = domain.CorporationsType
+	assert.NoError(t, err)
+	js, err := json.Marshal(mockCompanyPostRequest)
+	assert.NoError(t, err)
+
+	expError := domain.NewError(domain.ErrConflict, "company already exists", errors.New("some error"))
+	mockUseCase := &mocks.CompanyUsecase{}
+	mockUseCase.On("Create", mock.Anything, mock.Anything).Return(domain.Company{}, expError)
+
+	e := echo.New()
+	req, err := http.NewRequest(echo.POST, "/companies", bytes.NewReader(js))
+	assert.NoError(t, err)
+
+	req.Header.Add("Content-Type", "application/json")
+
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	handler := NewCompanyHandler(e, mockUseCase, nil, config.HTTP{}, zerolog.New(io.Discard))
+	err = handler.Create(c)
+	require.Error(t, err)
+	NewHTTPErrorHandler(zerolog.New(io.Discard))(err, c)
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Equal(t,
+		strings.Trim(`{"type":"urn:projectxm:problem:conflict","title":"Conflicting resource state","status":409,"detail":"company already exists"}`, " \n"),
+		strings.Trim(rec.Body.String(), " \n"),
+	)
+	mockUseCase.AssertExpectations(t)
+}
+
+func TestGetByIDFailed_NotFound(t *testing.T) {
+	var mockCompanyIDRequest IDPathRequest
+	err := gofakeit.Struct(&mockCompanyIDRequest)
+	assert.NoError(t, err)
+
+	expError := domain.NewError(domain.ErrNotFound, "company does not exist", errors.New("some error"))
+	mockUseCase := &mocks.CompanyUsecase{}
+	mockUseCase.On("GetByID", mock.Anything, mockCompanyIDRequest.ID).Return(domain.Company{}, expError)
+
+	e := echo.New()
+	req, err := http.NewRequest(
+		echo.GET,
+		fmt.Sprintf("/companies/%s", mockCompanyIDRequest.ID.String()),
+		nil,
+	)
+	assert.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/companies/:id")
+	c.SetParamNames("id")
+	c.SetParamValues(mockCompanyIDRequest.ID.String())
+	handler := NewCompanyHandler(e, mockUseCase, nil, config.HTTP{}, zerolog.New(io.Discard))
+	err = handler.GetByID(c)
+	require.Error(t, err)
+	NewHTTPErrorHandler(zerolog.New(io.Discard))(err, c)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t,
+		strings.Trim(`{"type":"urn:projectxm:problem:not-found","title":"Resource not found","status":404,"detail":"company does not exist"}`, " \n"),
+		strings.Trim(rec.Body.String(), " \n"),
+	)
+	mockUseCase.AssertExpectations(t)
+}
+
+func TestListSuccess(t *testing.T) {
+	var mockCompany domain.Company
+	err := gofakeit.Struct(&mockCompany)
+	assert.NoError(t, err)
+	mockPage := domain.CompanyPage{
+		Companies:  []domain.Company{mockCompany},
+		NextCursor: "next",
+	}
+	js, err := json.Marshal(GetCompanyListResponseFromDomain(mockPage))
+	assert.NoError(t, err)
+
+	mockUseCase := &mocks.CompanyUsecase{}
+	mockUseCase.On("List", mock.Anything, domain.ListCompanies{
+		CompanyType:  getPointer(domain.NonProfitType),
+		Registered:   getPointer(true),
+		MinEmployees: getPointer(uint32(5)),
+		MaxEmployees: getPointer(uint32(5)),
+		NamePrefix:   "ab",
+		SortBy:       domain.SortByAmountOfEmployees,
+		Desc:         true,
+		Limit:        10,
+		Cursor:       "cursor",
+	}).Return(mockPage, nil)
+
+	e := echo.New()
+	req, err := http.NewRequest(
+		echo.GET,
+		"/companies?type=NonProfit&registered=true&min_employees=5&max_employees=5&name_prefix=ab"+
+			"&sort=-amount_of_employees&limit=10&cursor=cursor",
+		nil,
+	)
+	assert.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	handler := NewCompanyHandler(e, mockUseCase, nil, config.HTTP{}, zerolog.New(io.Discard))
+	err = handler.List(c)
 	require.NoError(t, err)
 
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t,
+		strings.Trim(string(js), " \n"),
+		strings.Trim(rec.Body.String(), " \n"),
+	)
+	mockUseCase.AssertExpectations(t)
+}
+
+func TestListFailed_Validation(t *testing.T) {
+	mockUseCase := &mocks.CompanyUsecase{}
+
+	e := echo.New()
+	req, err := http.NewRequest(echo.GET, "/companies?min_employees=5&max_employees=4&sort=description&limit=1000", nil)
+	assert.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	handler := NewCompanyHandler(e, mockUseCase, nil, config.HTTP{}, zerolog.New(io.Discard))
+	err = handler.List(c)
+	require.Error(t, err)
+	NewHTTPErrorHandler(zerolog.New(io.Discard))(err, c)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t,
+		strings.Trim(`{"type":"urn:projectxm:problem:invalid-request","title":"Invalid request parameters","status":422,`+
+			`"errors":[{"field":"sort","rule":"oneof"},{"field":"limit","rule":"max"},{"field":"max_employees","rule":"gtefield"}]}`, " \n"),
+		strings.Trim(rec.Body.String(), " \n"),
+	)
+	mockUseCase.AssertExpectations(t)
+}
+
+func TestExportSuccess(t *testing.T) {
+	companies := []domain.Company{
+		{ID: uuid.MustParse("10000000-0000-0000-0000-000000000000"), Name: "a", AmountOfEmployees: 1,
+			CompanyType: domain.NonProfitType, Version: 1},
+		{ID: uuid.MustParse("20000000-0000-0000-0000-000000000000"), Name: "b", Description: "x, y",
+			AmountOfEmployees: 2, Registered: true, CompanyType: domain.CooperativeType, Version: 3},
+	}
+
+	tests := []struct {
+		name            string
+		accept          string
+		wantContentType string
+		wantBody        string
+	}{
+		{
+			name:            "NDJSON",
+			accept:          "application/json, */*;q=0.1",
+			wantContentType: MIMEApplicationNDJSON,
+			wantBody: `{"id":"10000000-0000-0000-0000-000000000000","name":"a","amount_of_employees":1,` +
+				`"registered":false,"type":"NonProfit","version":1}` + "\n" +
+				`{"id":"20000000-0000-0000-0000-000000000000","name":"b","description":"x, y","amount_of_employees":2,` +
+				`"registered":true,"type":"Cooperative","version":3}` + "\n",
+		},
+		{
+			name:            "CSV",
+			accept:          "text/csv",
+			wantContentType: MIMETextCSV,
+			wantBody: "id,name,description,amount_of_employees,registered,type,version\n" +
+				"10000000-0000-0000-0000-000000000000,a,,1,false,NonProfit,1\n" +
+				"20000000-0000-0000-0000-000000000000,b,\"x, y\",2,true,Cooperative,3\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUseCase := &mocks.CompanyUsecase{}
+			mockUseCase.On("Export", mock.Anything, domain.ExportCompanies{
+				CompanyType: getPointer(domain.NonProfitType),
+				Registered:  getPointer(true),
+			}, mock.Anything).Run(func(args mock.Arguments) {
+				fn := args.Get(2).(func(domain.Company) error)
+				for _, c := range companies {
+					require.NoError(t, fn(c))
+				}
+			}).Return(nil)
+
+			e := echo.New()
+			req, err := http.NewRequest(echo.GET, "/companies/export?type=NonProfit&registered=true", nil)
+			assert.NoError(t, err)
+			req.Header.Set(echo.HeaderAccept, tt.accept)
+
+			rec := httptest.NewRecorder()
+			passthrough := func(next echo.HandlerFunc) echo.HandlerFunc { return next }
+			NewCompanyHandler(e, mockUseCase, passthrough, config.HTTP{}, zerolog.New(io.Discard))
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, tt.wantContentType, rec.Header().Get(echo.HeaderContentType))
+			assert.Equal(t, tt.wantBody, rec.Body.String())
+			mockUseCase.AssertExpectations(t)
+		})
+	}
+}
+
+func TestExportSuccess_Empty(t *testing.T) {
+	mockUseCase := &mocks.CompanyUsecase{}
+	mockUseCase.On("Export", mock.Anything, domain.ExportCompanies{}, mock.Anything).Return(nil)
+
+	e := echo.New()
+	req, err := http.NewRequest(echo.GET, "/companies/export", nil)
+	assert.NoError(t, err)
+	req.Header.Set(echo.HeaderAccept, MIMETextCSV)
+
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	handler := NewCompanyHandler(e, mockUseCase, nil, config.HTTP{}, zerolog.New(io.Discard))
+	err = handler.Export(c)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "id,name,description,amount_of_employees,registered,type,version\n", rec.Body.String())
+	mockUseCase.AssertExpectations(t)
+}
+
+func TestExportFailed_NotAcceptable(t *testing.T) {
+	mockUseCase := &mocks.CompanyUsecase{}
+
+	e := echo.New()
+	req, err := http.NewRequest(echo.GET, "/companies/export", nil)
+	assert.NoError(t, err)
+	req.Header.Set(echo.HeaderAccept, "application/xml")
+
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	handler := NewCompanyHandler(e, mockUseCase, nil, config.HTTP{}, zerolog.New(io.Discard))
+	err = handler.Export(c)
+	require.Error(t, err)
+	NewHTTPErrorHandler(zerolog.New(io.Discard))(err, c)
+
+	assert.Equal(t, http.StatusNotAcceptable, rec.Code)
+	mockUseCase.AssertExpectations(t)
+}
+
+func TestExportFailed_InternalError(t *testing.T) {
+	mockUseCase := &mocks.CompanyUsecase{}
+	mockUseCase.On("Export", mock.Anything, domain.ExportCompanies{}, mock.Anything).Return(errors.New("some error"))
+
+	e := echo.New()
+	req, err := http.NewRequest(echo.GET, "/companies/export", nil)
+	assert.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	handler := NewCompanyHandler(e, mockUseCase, nil, config.HTTP{}, zerolog.New(io.Discard))
+	err = handler.Export(c)
+	require.Error(t, err)
+	NewHTTPErrorHandler(zerolog.New(io.Discard))(err, c)
+
 	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, MIMEApplicationProblemJSON, rec.Header().Get(echo.HeaderContentType))
+	mockUseCase.AssertExpectations(t)
+}
+
+func TestSearchSuccess(t *testing.T) {
+	var mockCompany domain.Company
+	err := gofakeit.Struct(&mockCompany)
+	assert.NoError(t, err)
+	mockResults := []domain.CompanySearchResult{{
+		Company: mockCompany,
+		Rank:    0.5,
+		Snippet: "<mark>open</mark> source",
+	}}
+	js, err := json.Marshal(GetCompanySearchResponseFromDomain(mockResults))
+	assert.NoError(t, err)
+
+	mockUseCase := &mocks.CompanyUsecase{}
+	mockUseCase.On("Search", mock.Anything, domain.SearchCompanies{Query: `"open source" dat*`, Limit: 5}).
+		Return(mockResults, nil)
+
+	e := echo.New()
+	req, err := http.NewRequest(echo.GET, "/companies/search?q=%22open+source%22+dat%2A&limit=5", nil)
+	assert.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	handler := NewCompanyHandler(e, mockUseCase, nil, config.HTTP{}, zerolog.New(io.Discard))
+	err = handler.Search(c)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t,
+		strings.Trim(string(js), " \n"),
+		strings.Trim(rec.Body.String(), " \n"),
+	)
+	mockUseCase.AssertExpectations(t)
+}
+
+func TestSearchFailed_Validation(t *testing.T) {
+	mockUseCase := &mocks.CompanyUsecase{}
+
+	e := echo.New()
+	req, err := http.NewRequest(echo.GET, "/companies/search", nil)
+	assert.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	handler := NewCompanyHandler(e, mockUseCase, nil, config.HTTP{}, zerolog.New(io.Discard))
+	err = handler.Search(c)
+	require.Error(t, err)
+	NewHTTPErrorHandler(zerolog.New(io.Discard))(err, c)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t,
+		strings.Trim(`{"type":"urn:projectxm:problem:invalid-request","title":"Invalid request parameters","status":422,`+
+			`"errors":[{"field":"q","rule":"required"}]}`, " \n"),
+		strings.Trim(rec.Body.String(), " \n"),
+	)
+	mockUseCase.AssertExpectations(t)
+}
+
+// Generic to get pointer
+func getPointer[T any](value T) *T {
+	return &value
+}
+
+func TestHistorySuccess(t *testing.T) {
+	id := uuid.New()
+	page := domain.CompanyHistoryPage{
+		Entries: []domain.CompanyAuditEntry{{
+			ID:        2,
+			CompanyID: id,
+			Action:    domain.UpdateEventActionType,
+			Actor:     domain.Actor{Subject: "1234567890", RequestID: "req"},
+			Time:      time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC),
+			Changes: []domain.FieldChange{
+				{Field: "type", Old: "NonProfit", New: "Cooperative"},
+			},
+		}},
+		NextCursor: "2",
+	}
+
+	mockUseCase := &mocks.CompanyUsecase{}
+	mockUseCase.On("History", mock.Anything, domain.CompanyHistory{CompanyID: id, Limit: 1, Cursor: "3"}).
+		Return(page, nil)
+
+	e := echo.New()
+	req, err := http.NewRequest(echo.GET, fmt.Sprintf("/companies/%s/history?limit=1&cursor=3", id), nil)
+	assert.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/companies/:id/history")
+	c.SetParamNames("id")
+	c.SetParamValues(id.String())
+	handler := NewCompanyHandler(e, mockUseCase, nil, config.HTTP{}, zerolog.New(io.Discard))
+	err = handler.History(c)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t,
+		`{"entries":[{"action":"update","actor":"1234567890","request_id":"req","time":"2022-10-01T12:00:00Z",`+
+			`"changes":[{"field":"type","old":"NonProfit","new":"Cooperative"}]}],"next_cursor":"2"}`,
+		rec.Body.String(),
+	)
+	mockUseCase.AssertExpectations(t)
+}
+
+func TestHistoryFailed_Validation(t *testing.T) {
+	mockUseCase := &mocks.CompanyUsecase{}
+
+	e := echo.New()
+	req, err := http.NewRequest(echo.GET, "/companies/123/history", nil)
+	assert.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/companies/:id/history")
+	c.SetParamNames("id")
+	c.SetParamValues("123")
+	handler := NewCompanyHandler(e, mockUseCase, nil, config.HTTP{}, zerolog.New(io.Discard))
+	err = handler.History(c)
+	require.Error(t, err)
+	NewHTTPErrorHandler(zerolog.New(io.Discard))(err, c)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
 	assert.Equal(t,
-		strings.Trim(`{"message":"failed with internal error"}`, " \n"),
+		strings.Trim(`{"type":"urn:projectxm:problem:invalid-request","title":"Invalid request parameters","status":422,`+
+			`"errors":[{"field":"id","rule":"type"}]}`, " \n"),
 		strings.Trim(rec.Body.String(), " \n"),
 	)
 	mockUseCase.AssertExpectations(t)
@@ -1,12 +1,224 @@
 package http
 
-// ErrorResponse represent the response error struct
-type ErrorResponse struct {
-	Message string `json:"message"`
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-playground/validator"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+)
+
+// MIMEApplicationProblemJSON is the content type of ProblemDetails
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+// ProblemDetails represent the RFC 7807 response error struct
+type ProblemDetails struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	// Instance is the ID of the failed request
+	Instance string       `json:"instance,omitempty"`
+	Errors   []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError describes the failed validation rule of the request field
+type FieldError struct {
+	Field string `json:"field"`
+	Rule  string `json:"rule"`
 }
 
-func NewErrorResponse(err error) ErrorResponse {
-	return ErrorResponse{
-		Message: err.Error(),
+// ItemError is the error of the item of a batch request
+type ItemError struct {
+	Index int
+	Err   error
+}
+
+// ItemErrors are the errors of the invalid items of a batch request,
+// their field errors are reported prefixed with the item index, e.g. [1].name
+type ItemErrors []ItemError
+
+func (e ItemErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, item := range e {
+		msgs = append(msgs, fmt.Sprintf("item %d: %v", item.Index, item.Err))
 	}
+
+	return strings.Join(msgs, "; ")
+}
+
+func (p ProblemDetails) Error() string {
+	if p.Detail == "" {
+		return p.Title
+	}
+
+	return p.Title + ": " + p.Detail
+}
+
+type problem struct {
+	Type   string
+	Title  string
+	Status int
+}
+
+var internalProblem = problem{
+	Type:   "urn:projectxm:problem:internal-error",
+	Title:  "Internal error",
+	Status: http.StatusInternalServerError,
+}
+
+// problems maps the kinds of domain.Error to the problem types
+var problems = map[error]problem{
+	domain.ErrNotFound: {
+		Type:   "urn:projectxm:problem:not-found",
+		Title:  "Resource not found",
+		Status: http.StatusNotFound,
+	},
+	domain.ErrConflict: {
+		Type:   "urn:projectxm:problem:conflict",
+		Title:  "Conflicting resource state",
+		Status: http.StatusConflict,
+	},
+	domain.ErrValidation: {
+		Type:   "urn:projectxm:problem:invalid-resource",
+		Title:  "Invalid resource values",
+		Status: http.StatusUnprocessableEntity,
+	},
+	domain.ErrBadRequest: {
+		Type:   "urn:projectxm:problem:invalid-request",
+		Title:  "Invalid request parameters",
+		Status: http.StatusUnprocessableEntity,
+	},
+	domain.ErrPreconditionFailed: {
+		Type:   "urn:projectxm:problem:precondition-failed",
+		Title:  "Resource modified",
+		Status: http.StatusPreconditionFailed,
+	},
+	domain.ErrPreconditionRequired: {
+		Type:   "urn:projectxm:problem:precondition-required",
+		Title:  "Resource version required",
+		Status: http.StatusPreconditionRequired,
+	},
+	domain.ErrUnavailable: {
+		Type:   "urn:projectxm:problem:unavailable",
+		Title:  "Service temporarily unavailable",
+		Status: http.StatusServiceUnavailable,
+	},
+}
+
+// NewHTTPErrorHandler creates the echo error handler responding with the ProblemDetails of the domain error kind,
+// unknown errors are reported as internal ones
+func NewHTTPErrorHandler(log zerolog.Logger) echo.HTTPErrorHandler {
+	return func(err error, c echo.Context) {
+		if c.Response().Committed {
+			return
+		}
+
+		resp := NewProblemDetails(err)
+		resp.Instance = c.Response().Header().Get(echo.HeaderXRequestID)
+		if resp.Status >= http.StatusInternalServerError {
+			log.Err(err).Str("path", c.Path()).Str("request_id", resp.Instance).Msg("request failed")
+		}
+
+		if c.Request().Method == http.MethodHead {
+			err = c.NoContent(resp.Status)
+		} else {
+			c.Response().Header().Set(echo.HeaderContentType, MIMEApplicationProblemJSON)
+			err = c.JSON(resp.Status, resp)
+		}
+		if err != nil {
+			log.Err(err).Msg("failed to send error response")
+		}
+	}
+}
+
+// NewProblemDetails describes the error without revealing its internals
+func NewProblemDetails(err error) ProblemDetails {
+	var domainErr *domain.Error
+	if errors.As(err, &domainErr) {
+		if p, ok := problems[domainErr.Kind]; ok {
+			return ProblemDetails{
+				Type:   p.Type,
+				Title:  p.Title,
+				Status: p.Status,
+				Detail: domainErr.Detail,
+				Errors: fieldErrors(err),
+			}
+		}
+	}
+
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		return ProblemDetails{
+			Type:   "about:blank",
+			Title:  http.StatusText(httpErr.Code),
+			Status: httpErr.Code,
+		}
+	}
+
+	return ProblemDetails{
+		Type:   internalProblem.Type,
+		Title:  internalProblem.Title,
+		Status: internalProblem.Status,
+	}
+}
+
+// fieldErrors lists the request fields failed to bind or validate
+func fieldErrors(err error) []FieldError {
+	var itemErrs ItemErrors
+	if errors.As(err, &itemErrs) {
+		var res []FieldError
+		for _, item := range itemErrs {
+			for _, f := range fieldErrors(item.Err) {
+				f.Field = fmt.Sprintf("[%d].%s", item.Index, f.Field)
+				res = append(res, f)
+			}
+		}
+
+		return res
+	}
+
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		res := make([]FieldError, 0, len(validationErrs))
+		for _, e := range validationErrs {
+			res = append(res, FieldError{
+				Field: e.Field(),
+				Rule:  e.Tag(),
+			})
+		}
+
+		return res
+	}
+
+	var bindingErr *echo.BindingError
+	if errors.As(err, &bindingErr) {
+		rule := "required"
+		for _, v := range bindingErr.Values {
+			if v != "" {
+				rule = "type"
+			}
+		}
+
+		return []FieldError{{
+			Field: bindingErr.Field,
+			Rule:  rule,
+		}}
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return []FieldError{{
+			Field: typeErr.Field,
+			Rule:  "type",
+		}}
+	}
+
+	return nil
 }
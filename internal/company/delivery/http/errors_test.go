@@ -0,0 +1,140 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+)
+
+func TestHTTPErrorHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "not found",
+			err:      fmt.Errorf("wrapped: %w", domain.NewError(domain.ErrNotFound, "company does not exist", nil)),
+			wantCode: http.StatusNotFound,
+			wantBody: `{"type":"urn:projectxm:problem:not-found","title":"Resource not found","status":404,"detail":"company does not exist","instance":"test-request"}`,
+		},
+		{
+			name:     "conflict",
+			err:      domain.NewError(domain.ErrConflict, "", errors.New("pq: duplicate key")),
+			wantCode: http.StatusConflict,
+			wantBody: `{"type":"urn:projectxm:problem:conflict","title":"Conflicting resource state","status":409,"instance":"test-request"}`,
+		},
+		{
+			name:     "validation",
+			err:      domain.NewError(domain.ErrValidation, "value is too long", nil),
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: `{"type":"urn:projectxm:problem:invalid-resource","title":"Invalid resource values","status":422,"detail":"value is too long","instance":"test-request"}`,
+		},
+		{
+			name:     "bad request",
+			err:      domain.NewError(domain.ErrBadRequest, "", errors.New("bind error")),
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: `{"type":"urn:projectxm:problem:invalid-request","title":"Invalid request parameters","status":422,"instance":"test-request"}`,
+		},
+		{
+			name:     "unavailable",
+			err:      domain.NewError(domain.ErrUnavailable, "", errors.New("too many connections")),
+			wantCode: http.StatusServiceUnavailable,
+			wantBody: `{"type":"urn:projectxm:problem:unavailable","title":"Service temporarily unavailable","status":503,"instance":"test-request"}`,
+		},
+		{
+			name:     "echo error",
+			err:      echo.ErrUnauthorized,
+			wantCode: http.StatusUnauthorized,
+			wantBody: `{"type":"about:blank","title":"Unauthorized","status":401,"instance":"test-request"}`,
+		},
+		{
+			name:     "unknown error",
+			err:      errors.New("some error"),
+			wantCode: http.StatusInternalServerError,
+			wantBody: `{"type":"urn:projectxm:problem:internal-error","title":"Internal error","status":500,"instance":"test-request"}`,
+		},
+	}
+
+	h := NewHTTPErrorHandler(zerolog.New(io.Discard))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(httptest.NewRequest(echo.GET, "/companies", nil), rec)
+			c.Response().Header().Set(echo.HeaderXRequestID, "test-request")
+
+			h(tt.err, c)
+
+			assert.Equal(t, tt.wantCode, rec.Code)
+			assert.Equal(t, MIMEApplicationProblemJSON, rec.Header().Get(echo.HeaderContentType))
+			assert.JSONEq(t, tt.wantBody, rec.Body.String())
+		})
+	}
+}
+
+func TestNewProblemDetails_FieldErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		path       string
+		bind       func(echo.Context) error
+		wantErrors []FieldError
+	}{
+		{
+			name: "validation",
+			body: `{"description":"1"}`,
+			path: testUUID,
+			bind: (&CompanyPostRequest{}).BindValidate,
+			wantErrors: []FieldError{
+				{Field: "name", Rule: "required"},
+				{Field: "amount_of_employees", Rule: "required"},
+				{Field: "type", Rule: "required"},
+			},
+		},
+		{
+			name:       "wrong type",
+			body:       `{"name":"1","amount_of_employees":"many"}`,
+			path:       testUUID,
+			bind:       (&CompanyPatchRequest{}).BindValidate,
+			wantErrors: []FieldError{{Field: "amount_of_employees", Rule: "type"}},
+		},
+		{
+			name:       "invalid id",
+			body:       `{}`,
+			path:       "123",
+			bind:       (&IDPathRequest{}).BindValidate,
+			wantErrors: []FieldError{{Field: "id", Rule: "type"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(echo.POST, "/companies", bytes.NewBufferString(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			c := echo.New().NewContext(req, httptest.NewRecorder())
+			c.SetPath("/companies/:id")
+			c.SetParamNames("id")
+			c.SetParamValues(tt.path)
+
+			err := tt.bind(c)
+			require.Error(t, err)
+
+			p := NewProblemDetails(domain.NewError(domain.ErrBadRequest, "", err))
+			assert.Equal(t, http.StatusUnprocessableEntity, p.Status)
+			assert.Equal(t, tt.wantErrors, p.Errors)
+		})
+	}
+}
+
+const testUUID = "10000000-0000-0000-0000-000000000000"
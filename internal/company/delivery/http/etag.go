@@ -0,0 +1,51 @@
+package http
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+)
+
+// Headers of the company versions missing in echo
+const (
+	HeaderETag    = "ETag"
+	HeaderIfMatch = "If-Match"
+)
+
+// ETag returns the strong entity tag of the company version
+func ETag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// ifMatchVersion parses the company version expected by the If-Match header,
+// nil means any version is expected: either "*" is given or the header is missing and not required
+func ifMatchVersion(c echo.Context, required bool) (*int64, error) {
+	header := strings.TrimSpace(c.Request().Header.Get(HeaderIfMatch))
+	if header == "" {
+		if required {
+			return nil, domain.NewError(domain.ErrPreconditionRequired, "If-Match header is required", nil)
+		}
+
+		return nil, nil
+	}
+
+	if header == "*" {
+		return nil, nil
+	}
+
+	// weak tags never match and a list can't be compared in a single update
+	if len(header) < 2 || !strings.HasPrefix(header, `"`) || !strings.HasSuffix(header, `"`) ||
+		strings.Contains(header, ",") {
+		return nil, domain.NewError(domain.ErrPreconditionFailed, "If-Match must be a single strong entity tag", nil)
+	}
+
+	version, err := strconv.ParseInt(header[1:len(header)-1], 10, 64)
+	if err != nil {
+		return nil, domain.NewError(domain.ErrPreconditionFailed, "If-Match doesn't match any company version", nil)
+	}
+
+	return &version, nil
+}
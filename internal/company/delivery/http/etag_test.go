@@ -0,0 +1,52 @@
+package http
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+)
+
+func TestIfMatchVersion(t *testing.T) {
+	tests := []struct {
+		name        string
+		header      string
+		required    bool
+		wantVersion *int64
+		wantErr     error
+	}{
+		{name: "Missing"},
+		{name: "MissingRequired", required: true, wantErr: domain.ErrPreconditionRequired},
+		{name: "Any", header: "*", required: true},
+		{name: "Version", header: `"3"`, required: true, wantVersion: getPointer(int64(3))},
+		{name: "Weak", header: `W/"3"`, wantErr: domain.ErrPreconditionFailed},
+		{name: "List", header: `"3", "4"`, wantErr: domain.ErrPreconditionFailed},
+		{name: "Unquoted", header: "3", wantErr: domain.ErrPreconditionFailed},
+		{name: "NotVersion", header: `"abc"`, wantErr: domain.ErrPreconditionFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(echo.PATCH, "/companies/1", nil)
+			if tt.header != "" {
+				req.Header.Set(HeaderIfMatch, tt.header)
+			}
+			c := echo.New().NewContext(req, httptest.NewRecorder())
+
+			version, err := ifMatchVersion(c, tt.required)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantVersion, version)
+		})
+	}
+}
+
+func TestETag(t *testing.T) {
+	assert.Equal(t, `"42"`, ETag(42))
+}
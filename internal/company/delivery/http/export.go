@@ -0,0 +1,95 @@
+package http
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+)
+
+// Content types of the companies export
+const (
+	MIMEApplicationNDJSON = "application/x-ndjson"
+	MIMETextCSV           = "text/csv"
+)
+
+// exportFlushRows is the number of the exported companies written between the flushes of the response
+const exportFlushRows = 100
+
+// csvHeader names the CSV columns as the fields of CompanyPostRequest, so the export can be imported back
+var csvHeader = []string{"id", "name", "description", "amount_of_employees", "registered", "type", "version"}
+
+// companyWriter writes the exported companies in the format of the content type
+type companyWriter interface {
+	Write(c domain.Company) error
+	// Flush writes the buffered companies to the underlying writer
+	Flush() error
+}
+
+// exportContentType picks the first supported type of the Accept header, NDJSON if it accepts any type
+func exportContentType(accept string) (string, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return MIMEApplicationNDJSON, true
+	}
+
+	for _, r := range strings.Split(accept, ",") {
+		mediaType, _, _ := strings.Cut(r, ";")
+		switch strings.ToLower(strings.TrimSpace(mediaType)) {
+		case MIMEApplicationNDJSON, "application/ndjson", "application/*", "*/*":
+			return MIMEApplicationNDJSON, true
+		case MIMETextCSV, "text/*":
+			return MIMETextCSV, true
+		}
+	}
+
+	return "", false
+}
+
+func newCompanyWriter(contentType string, w io.Writer) (companyWriter, error) {
+	if contentType == MIMETextCSV {
+		cw := csv.NewWriter(w)
+		if err := cw.Write(csvHeader); err != nil {
+			return nil, err
+		}
+
+		return &csvCompanyWriter{w: cw}, nil
+	}
+
+	return &ndjsonCompanyWriter{enc: json.NewEncoder(w)}, nil
+}
+
+type ndjsonCompanyWriter struct {
+	enc *json.Encoder
+}
+
+func (w *ndjsonCompanyWriter) Write(c domain.Company) error {
+	return w.enc.Encode(GetCompanyResponseFromDomain(c))
+}
+
+func (w *ndjsonCompanyWriter) Flush() error {
+	return nil
+}
+
+type csvCompanyWriter struct {
+	w *csv.Writer
+}
+
+func (w *csvCompanyWriter) Write(c domain.Company) error {
+	return w.w.Write([]string{
+		c.ID.String(),
+		c.Name,
+		c.Description,
+		strconv.FormatUint(uint64(c.AmountOfEmployees), 10),
+		strconv.FormatBool(c.Registered),
+		string(c.CompanyType),
+		strconv.FormatInt(c.Version, 10),
+	})
+}
+
+func (w *csvCompanyWriter) Flush() error {
+	w.w.Flush()
+	return w.w.Error()
+}
@@ -0,0 +1,29 @@
+package http
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportContentType(t *testing.T) {
+	tests := []struct {
+		accept          string
+		wantContentType string
+		wantOK          bool
+	}{
+		{accept: "", wantContentType: MIMEApplicationNDJSON, wantOK: true},
+		{accept: "*/*", wantContentType: MIMEApplicationNDJSON, wantOK: true},
+		{accept: "application/x-ndjson", wantContentType: MIMEApplicationNDJSON, wantOK: true},
+		{accept: "Text/CSV; charset=utf-8", wantContentType: MIMETextCSV, wantOK: true},
+		{accept: "application/json, text/*;q=0.5", wantContentType: MIMETextCSV, wantOK: true},
+		{accept: "application/xml"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			contentType, ok := exportContentType(tt.accept)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantContentType, contentType)
+		})
+	}
+}
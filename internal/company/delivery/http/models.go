@@ -2,6 +2,10 @@ package http
 
 import (
 	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
 
 	"github.com/go-playground/validator"
 	"github.com/google/uuid"
@@ -10,13 +14,45 @@ import (
 	"github.com/AlisskaPie/project-xm/pkg/domain"
 )
 
+// validate reports the failed fields by their json, param or query names
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		for _, tag := range []string{"json", "param", "query"} {
+			name := strings.SplitN(f.Tag.Get(tag), ",", 2)[0]
+			if name == "-" {
+				return ""
+			}
+			if name != "" {
+				return name
+			}
+		}
+
+		return f.Name
+	})
+	v.RegisterStructValidation(validateListCompaniesRequest, ListCompaniesRequest{})
+	// the tag of the rule is reported as the failed rule of the field
+	if err := v.RegisterValidation("company_type", validateCompanyType); err != nil {
+		panic(err)
+	}
+
+	return v
+}
+
 type CompanyPostRequest struct {
 	ID                uuid.UUID          `json:"id"`
-	Name              string             `json:"name" validate:"required"`
-	Description       string             `json:"description,omitempty"`
+	Name              string             `json:"name" validate:"required,max=15"`
+	Description       string             `json:"description,omitempty" validate:"max=3000"`
 	AmountOfEmployees uint32             `json:"amount_of_employees" validate:"required"`
 	Registered        bool               `json:"registered"`
-	CompanyType       domain.CompanyType `json:"type" validate:"required"`
+	CompanyType       domain.CompanyType `json:"type" validate:"required,company_type"`
+}
+
+// validateCompanyType checks that the field is one of the domain.CompanyType values
+func validateCompanyType(fl validator.FieldLevel) bool {
+	return domain.CompanyType(fl.Field().String()).Valid()
 }
 
 func (c *CompanyPostRequest) BindValidate(ctx echo.Context) error {
@@ -28,7 +64,6 @@ func (c *CompanyPostRequest) BindValidate(ctx echo.Context) error {
 }
 
 func (c *CompanyPostRequest) Validate() error {
-	validate := validator.New()
 	return validate.Struct(c)
 }
 
@@ -43,17 +78,55 @@ func (c *CompanyPostRequest) ToCreateCompany() domain.CreateCompany {
 	}
 }
 
+type CreateCompaniesRequest struct {
+	Mode domain.BatchMode `query:"mode" validate:"omitempty,oneof=all_or_nothing best_effort"`
+	// Companies is the array of the request body, each company is validated on its own by ValidateItems
+	Companies []CompanyPostRequest `json:"companies" validate:"min=1,max=1000"`
+}
+
+func (b *CreateCompaniesRequest) BindValidate(ctx echo.Context) error {
+	if err := echo.QueryParamsBinder(ctx).String("mode", (*string)(&b.Mode)).BindError(); err != nil {
+		return fmt.Errorf("failed to bind CreateCompaniesRequest: %w", err)
+	}
+
+	if err := (&echo.DefaultBinder{}).BindBody(ctx, &b.Companies); err != nil {
+		return fmt.Errorf("failed to bind CreateCompaniesRequest: %w", err)
+	}
+
+	return b.Validate()
+}
+
+func (b *CreateCompaniesRequest) Validate() error {
+	return validate.Struct(b)
+}
+
+// ValidateItems returns the errors of the invalid companies
+func (b *CreateCompaniesRequest) ValidateItems() ItemErrors {
+	var errs ItemErrors
+	for i := range b.Companies {
+		if err := b.Companies[i].Validate(); err != nil {
+			errs = append(errs, ItemError{Index: i, Err: err})
+		}
+	}
+
+	return errs
+}
+
 type CompanyPatchRequest struct {
 	ID                uuid.UUID           `param:"id" validate:"required"`
-	Name              *string             `json:"name"`
-	Description       *string             `json:"description,omitempty"`
+	Name              *string             `json:"name" validate:"omitempty,max=15"`
+	Description       *string             `json:"description,omitempty" validate:"omitempty,max=3000"`
 	AmountOfEmployees *uint32             `json:"amount_of_employees"`
 	Registered        *bool               `json:"registered"`
-	CompanyType       *domain.CompanyType `json:"type"`
+	CompanyType       *domain.CompanyType `json:"type" validate:"omitempty,company_type"`
 }
 
 func (c *CompanyPatchRequest) BindValidate(ctx echo.Context) error {
-	if err := ctx.Bind(c); err != nil {
+	if err := echo.PathParamsBinder(ctx).MustTextUnmarshaler("id", &c.ID).BindError(); err != nil {
+		return fmt.Errorf("failed to bind CompanyPatchRequest: %w", err)
+	}
+
+	if err := (&echo.DefaultBinder{}).BindBody(ctx, c); err != nil {
 		return fmt.Errorf("failed to bind CompanyPatchRequest: %w", err)
 	}
 
@@ -61,7 +134,6 @@ func (c *CompanyPatchRequest) BindValidate(ctx echo.Context) error {
 }
 
 func (c *CompanyPatchRequest) Validate() error {
-	validate := validator.New()
 	return validate.Struct(c)
 }
 
@@ -80,7 +152,7 @@ type IDPathRequest struct {
 }
 
 func (i *IDPathRequest) BindValidate(ctx echo.Context) error {
-	if err := ctx.Bind(i); err != nil {
+	if err := echo.PathParamsBinder(ctx).MustTextUnmarshaler("id", &i.ID).BindError(); err != nil {
 		return fmt.Errorf("failed to bind IDPathRequest: %w", err)
 	}
 
@@ -88,10 +160,177 @@ func (i *IDPathRequest) BindValidate(ctx echo.Context) error {
 }
 
 func (c *IDPathRequest) Validate() error {
-	validate := validator.New()
 	return validate.Struct(c)
 }
 
+type GetCompanyRequest struct {
+	ID uuid.UUID `param:"id" validate:"required"`
+	// AsOf is the RFC 3339 instant to get the company state at, the current state is returned if it's zero
+	AsOf time.Time `query:"as_of"`
+}
+
+func (g *GetCompanyRequest) BindValidate(ctx echo.Context) error {
+	if err := echo.PathParamsBinder(ctx).MustTextUnmarshaler("id", &g.ID).BindError(); err != nil {
+		return fmt.Errorf("failed to bind GetCompanyRequest: %w", err)
+	}
+
+	if err := echo.QueryParamsBinder(ctx).Time("as_of", &g.AsOf, time.RFC3339).BindError(); err != nil {
+		return fmt.Errorf("failed to bind GetCompanyRequest: %w", err)
+	}
+
+	return g.Validate()
+}
+
+func (g *GetCompanyRequest) Validate() error {
+	return validate.Struct(g)
+}
+
+type DeleteCompanyRequest struct {
+	ID uuid.UUID `param:"id" validate:"required"`
+	// Purge deletes the company permanently instead of hiding it until it's restored
+	Purge bool `query:"purge"`
+}
+
+func (d *DeleteCompanyRequest) BindValidate(ctx echo.Context) error {
+	if err := echo.PathParamsBinder(ctx).MustTextUnmarshaler("id", &d.ID).BindError(); err != nil {
+		return fmt.Errorf("failed to bind DeleteCompanyRequest: %w", err)
+	}
+
+	if err := echo.QueryParamsBinder(ctx).Bool("purge", &d.Purge).BindError(); err != nil {
+		return fmt.Errorf("failed to bind DeleteCompanyRequest: %w", err)
+	}
+
+	return d.Validate()
+}
+
+func (d *DeleteCompanyRequest) Validate() error {
+	return validate.Struct(d)
+}
+
+type ListCompaniesRequest struct {
+	CompanyType  *domain.CompanyType `query:"type"`
+	Registered   *bool               `query:"registered"`
+	MinEmployees *uint32             `query:"min_employees"`
+	MaxEmployees *uint32             `query:"max_employees"`
+	NamePrefix   string              `query:"name_prefix"`
+	// Sort is the field to sort by, prefixed with "-" for the descending order
+	Sort   string `query:"sort" validate:"omitempty,oneof=name -name amount_of_employees -amount_of_employees id -id"`
+	Limit  int    `query:"limit" validate:"omitempty,min=1,max=100"`
+	Cursor string `query:"cursor"`
+}
+
+func (l *ListCompaniesRequest) BindValidate(ctx echo.Context) error {
+	if err := (&echo.DefaultBinder{}).BindQueryParams(ctx, l); err != nil {
+		return fmt.Errorf("failed to bind ListCompaniesRequest: %w", err)
+	}
+
+	return l.Validate()
+}
+
+func (l *ListCompaniesRequest) Validate() error {
+	return validate.Struct(l)
+}
+
+// validateListCompaniesRequest checks the employees range, it can't be done with gtefield on optional fields
+func validateListCompaniesRequest(sl validator.StructLevel) {
+	l := sl.Current().Interface().(ListCompaniesRequest)
+	if l.MinEmployees != nil && l.MaxEmployees != nil && *l.MaxEmployees < *l.MinEmployees {
+		sl.ReportError(l.MaxEmployees, "max_employees", "MaxEmployees", "gtefield", "MinEmployees")
+	}
+}
+
+func (l *ListCompaniesRequest) ToListCompanies() domain.ListCompanies {
+	return domain.ListCompanies{
+		CompanyType:  l.CompanyType,
+		Registered:   l.Registered,
+		MinEmployees: l.MinEmployees,
+		MaxEmployees: l.MaxEmployees,
+		NamePrefix:   l.NamePrefix,
+		SortBy:       domain.CompanySortField(strings.TrimPrefix(l.Sort, "-")),
+		Desc:         strings.HasPrefix(l.Sort, "-"),
+		Limit:        l.Limit,
+		Cursor:       l.Cursor,
+	}
+}
+
+type ExportCompaniesRequest struct {
+	CompanyType *domain.CompanyType `query:"type"`
+	Registered  *bool               `query:"registered"`
+}
+
+func (x *ExportCompaniesRequest) BindValidate(ctx echo.Context) error {
+	if err := (&echo.DefaultBinder{}).BindQueryParams(ctx, x); err != nil {
+		return fmt.Errorf("failed to bind ExportCompaniesRequest: %w", err)
+	}
+
+	return x.Validate()
+}
+
+func (x *ExportCompaniesRequest) Validate() error {
+	return validate.Struct(x)
+}
+
+func (x *ExportCompaniesRequest) ToExportCompanies() domain.ExportCompanies {
+	return domain.ExportCompanies{
+		CompanyType: x.CompanyType,
+		Registered:  x.Registered,
+	}
+}
+
+type SearchCompaniesRequest struct {
+	Query string `query:"q" validate:"required,max=500"`
+	Limit int    `query:"limit" validate:"omitempty,min=1,max=100"`
+}
+
+func (s *SearchCompaniesRequest) BindValidate(ctx echo.Context) error {
+	if err := (&echo.DefaultBinder{}).BindQueryParams(ctx, s); err != nil {
+		return fmt.Errorf("failed to bind SearchCompaniesRequest: %w", err)
+	}
+
+	return s.Validate()
+}
+
+func (s *SearchCompaniesRequest) Validate() error {
+	return validate.Struct(s)
+}
+
+func (s *SearchCompaniesRequest) ToSearchCompanies() domain.SearchCompanies {
+	return domain.SearchCompanies{
+		Query: s.Query,
+		Limit: s.Limit,
+	}
+}
+
+type CompanyHistoryRequest struct {
+	ID     uuid.UUID `param:"id" validate:"required"`
+	Limit  int       `query:"limit" validate:"omitempty,min=1,max=100"`
+	Cursor string    `query:"cursor"`
+}
+
+func (h *CompanyHistoryRequest) BindValidate(ctx echo.Context) error {
+	if err := echo.PathParamsBinder(ctx).MustTextUnmarshaler("id", &h.ID).BindError(); err != nil {
+		return fmt.Errorf("failed to bind CompanyHistoryRequest: %w", err)
+	}
+
+	if err := (&echo.DefaultBinder{}).BindQueryParams(ctx, h); err != nil {
+		return fmt.Errorf("failed to bind CompanyHistoryRequest: %w", err)
+	}
+
+	return h.Validate()
+}
+
+func (h *CompanyHistoryRequest) Validate() error {
+	return validate.Struct(h)
+}
+
+func (h *CompanyHistoryRequest) ToCompanyHistory() domain.CompanyHistory {
+	return domain.CompanyHistory{
+		CompanyID: h.ID,
+		Limit:     h.Limit,
+		Cursor:    h.Cursor,
+	}
+}
+
 type CompanyResponse struct {
 	ID                uuid.UUID          `json:"id" validate:"required"`
 	Name              string             `json:"name" validate:"required"`
@@ -99,6 +338,7 @@ type CompanyResponse struct {
 	AmountOfEmployees uint32             `json:"amount_of_employees" validate:"required"`
 	Registered        bool               `json:"registered" validate:"required"`
 	CompanyType       domain.CompanyType `json:"type" validate:"required"`
+	Version           int64              `json:"version"`
 }
 
 func GetCompanyResponseFromDomain(d domain.Company) CompanyResponse {
@@ -109,5 +349,117 @@ func GetCompanyResponseFromDomain(d domain.Company) CompanyResponse {
 		AmountOfEmployees: d.AmountOfEmployees,
 		Registered:        d.Registered,
 		CompanyType:       d.CompanyType,
+		Version:           d.Version,
+	}
+}
+
+// CreateCompanyResultResponse is the outcome of the company of the batch, either Company or Error is set
+type CreateCompanyResultResponse struct {
+	Status  int              `json:"status"`
+	Company *CompanyResponse `json:"company,omitempty"`
+	Error   *ProblemDetails  `json:"error,omitempty"`
+}
+
+type CreateCompaniesResponse struct {
+	Results []CreateCompanyResultResponse `json:"results"`
+}
+
+func GetCreateCompanyResultResponse(company domain.Company, err error) CreateCompanyResultResponse {
+	if err != nil {
+		p := NewProblemDetails(err)
+		return CreateCompanyResultResponse{
+			Status: p.Status,
+			Error:  &p,
+		}
+	}
+
+	c := GetCompanyResponseFromDomain(company)
+	return CreateCompanyResultResponse{
+		Status:  http.StatusCreated,
+		Company: &c,
+	}
+}
+
+type CompanyListResponse struct {
+	Companies  []CompanyResponse `json:"companies"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
+
+func GetCompanyListResponseFromDomain(p domain.CompanyPage) CompanyListResponse {
+	res := CompanyListResponse{
+		Companies:  make([]CompanyResponse, 0, len(p.Companies)),
+		NextCursor: p.NextCursor,
+	}
+	for _, c := range p.Companies {
+		res.Companies = append(res.Companies, GetCompanyResponseFromDomain(c))
+	}
+
+	return res
+}
+
+type CompanySearchResultResponse struct {
+	CompanyResponse
+	Rank    float64 `json:"rank"`
+	Snippet string  `json:"snippet,omitempty"`
+}
+
+type CompanySearchResponse struct {
+	Companies []CompanySearchResultResponse `json:"companies"`
+}
+
+func GetCompanySearchResponseFromDomain(results []domain.CompanySearchResult) CompanySearchResponse {
+	res := CompanySearchResponse{
+		Companies: make([]CompanySearchResultResponse, 0, len(results)),
 	}
+	for _, r := range results {
+		res.Companies = append(res.Companies, CompanySearchResultResponse{
+			CompanyResponse: GetCompanyResponseFromDomain(r.Company),
+			Rank:            r.Rank,
+			Snippet:         r.Snippet,
+		})
+	}
+
+	return res
+}
+
+type FieldChangeResponse struct {
+	Field string `json:"field"`
+	Old   any    `json:"old"`
+	New   any    `json:"new"`
+}
+
+type CompanyAuditEntryResponse struct {
+	Action    domain.EventActionType `json:"action"`
+	Actor     string                 `json:"actor"`
+	RequestID string                 `json:"request_id"`
+	Time      time.Time              `json:"time"`
+	Changes   []FieldChangeResponse  `json:"changes"`
+}
+
+type CompanyHistoryResponse struct {
+	Entries    []CompanyAuditEntryResponse `json:"entries"`
+	NextCursor string                      `json:"next_cursor,omitempty"`
+}
+
+func GetCompanyHistoryResponseFromDomain(p domain.CompanyHistoryPage) CompanyHistoryResponse {
+	res := CompanyHistoryResponse{
+		Entries:    make([]CompanyAuditEntryResponse, 0, len(p.Entries)),
+		NextCursor: p.NextCursor,
+	}
+	for _, e := range p.Entries {
+		entry := CompanyAuditEntryResponse{
+			Action:    e.Action,
+			Actor:     e.Actor.Subject,
+			RequestID: e.Actor.RequestID,
+			Time:      e.Time,
+			Changes:   make([]FieldChangeResponse, 0, len(e.Changes)),
+		}
+		for _, c := range e.Changes {
+			entry.Changes = append(entry.Changes, FieldChangeResponse(c))
+		}
+
+		res.Entries = append(res.Entries, entry)
+	}
+
+	return res
 }
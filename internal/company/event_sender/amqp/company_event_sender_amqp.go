@@ -0,0 +1,180 @@
+package amqp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	amqpgo "github.com/rabbitmq/amqp091-go"
+
+	"github.com/AlisskaPie/project-xm/internal/company/event_sender/cloudevents"
+	"github.com/AlisskaPie/project-xm/internal/config"
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+)
+
+var errNack = errors.New("publishing was not acknowledged by the broker")
+
+// session publishes messages and waits for their confirmation
+type session interface {
+	Publish(ctx context.Context, key string, msg amqpgo.Publishing) error
+	IsClosed() bool
+	Close() error
+}
+
+// CompanyEventSenderAMQP publishes company events as structured CloudEvents to an AMQP 0-9-1 exchange
+// with routing keys like company.insert. Send returns once the broker confirmed the event.
+// The connection is reestablished on the next Send after it or its channel has been closed.
+type CompanyEventSenderAMQP struct {
+	conf    config.AMQP
+	encoder cloudevents.Encoder
+	dial    func(conf config.AMQP) (session, error)
+
+	mu      sync.Mutex
+	session session
+}
+
+// NewCompanyEventSenderAMQP connects to the broker and declares the configured exchange
+func NewCompanyEventSenderAMQP(conf config.AMQP, encoder cloudevents.Encoder) (*CompanyEventSenderAMQP, error) {
+	if conf.Exchange == "" {
+		return nil, fmt.Errorf("no amqp exchange configured")
+	}
+
+	s := &CompanyEventSenderAMQP{
+		conf:    conf,
+		encoder: encoder,
+		dial:    dialSession,
+	}
+
+	var err error
+	if s.session, err = s.dial(conf); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Send implements domain.CompanyEventSender
+func (s *CompanyEventSenderAMQP) Send(ctx context.Context, event domain.CompanyEvent) error {
+	body, err := s.encoder.Encode(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	msg := amqpgo.Publishing{
+		ContentType:  cloudevents.ContentType,
+		DeliveryMode: amqpgo.Persistent,
+		MessageId:    event.EventID.String(),
+		Timestamp:    event.Time,
+		Type:         cloudevents.Type(event.Action),
+		Body:         body,
+	}
+	key := s.routingKey(event.Action)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err = s.publish(ctx, key, msg)
+	if errors.Is(err, amqpgo.ErrClosed) {
+		// the channel dropped while publishing, retry once on a new one
+		err = s.publish(ctx, key, msg)
+	}
+
+	return err
+}
+
+// Close closes the channel and the connection to the broker
+func (s *CompanyEventSenderAMQP) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.session == nil {
+		return nil
+	}
+
+	err := s.session.Close()
+	s.session = nil
+
+	return err
+}
+
+func (s *CompanyEventSenderAMQP) publish(ctx context.Context, key string, msg amqpgo.Publishing) error {
+	if s.session == nil || s.session.IsClosed() {
+		if s.session != nil {
+			_ = s.session.Close()
+		}
+
+		var err error
+		if s.session, err = s.dial(s.conf); err != nil {
+			s.session = nil
+			return err
+		}
+	}
+
+	return s.session.Publish(ctx, key, msg)
+}
+
+func (s *CompanyEventSenderAMQP) routingKey(action domain.EventActionType) string {
+	return s.conf.RoutingKeyPrefix + "." + string(action)
+}
+
+type amqpSession struct {
+	exchange string
+	conn     *amqpgo.Connection
+	ch       *amqpgo.Channel
+}
+
+func dialSession(conf config.AMQP) (session, error) {
+	conn, err := amqpgo.Dial(conf.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial amqp: %w", err)
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("failed to open amqp channel: %w", err)
+	}
+
+	if err := ch.Confirm(false); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("failed to put amqp channel into confirm mode: %w", err)
+	}
+
+	if err := ch.ExchangeDeclare(conf.Exchange, conf.ExchangeType, true, false, false, false, nil); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("failed to declare amqp exchange: %w", err)
+	}
+
+	return &amqpSession{
+		exchange: conf.Exchange,
+		conn:     conn,
+		ch:       ch,
+	}, nil
+}
+
+func (s *amqpSession) Publish(ctx context.Context, key string, msg amqpgo.Publishing) error {
+	confirmation, err := s.ch.PublishWithDeferredConfirmWithContext(ctx, s.exchange, key, false, false, msg)
+	if err != nil {
+		return fmt.Errorf("PublishWithDeferredConfirmWithContext: %w", err)
+	}
+
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to wait for publisher confirm: %w", err)
+	}
+
+	if !acked {
+		return errNack
+	}
+
+	return nil
+}
+
+func (s *amqpSession) IsClosed() bool {
+	return s.ch.IsClosed() || s.conn.IsClosed()
+}
+
+func (s *amqpSession) Close() error {
+	return s.conn.Close()
+}
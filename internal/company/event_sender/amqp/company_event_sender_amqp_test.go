@@ -0,0 +1,124 @@
+package amqp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	amqpgo "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/AlisskaPie/project-xm/internal/company/event_sender/cloudevents"
+	"github.com/AlisskaPie/project-xm/internal/config"
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+)
+
+var testUUID = uuid.MustParse("10000000-0000-0000-0000-000000000000")
+
+type published struct {
+	key string
+	msg amqpgo.Publishing
+}
+
+type fakeSession struct {
+	published *[]published
+	closed    bool
+	err       error
+}
+
+func (s *fakeSession) Publish(_ context.Context, key string, msg amqpgo.Publishing) error {
+	if s.err != nil {
+		s.closed = errors.Is(s.err, amqpgo.ErrClosed)
+		return s.err
+	}
+	*s.published = append(*s.published, published{key: key, msg: msg})
+	return nil
+}
+
+func (s *fakeSession) IsClosed() bool {
+	return s.closed
+}
+
+func (s *fakeSession) Close() error {
+	s.closed = true
+	return nil
+}
+
+// newTestSender creates a sender dialing the given sessions one by one
+func newTestSender(sessions ...*fakeSession) *CompanyEventSenderAMQP {
+	return &CompanyEventSenderAMQP{
+		conf:    config.AMQP{Exchange: "company-events", RoutingKeyPrefix: "company"},
+		encoder: cloudevents.NewEncoder("/project-xm"),
+		dial: func(config.AMQP) (session, error) {
+			if len(sessions) == 0 {
+				return nil, errors.New("dial error")
+			}
+			s := sessions[0]
+			sessions = sessions[1:]
+			return s, nil
+		},
+	}
+}
+
+func TestAMQPSend_Success(t *testing.T) {
+	var got []published
+	s := newTestSender(&fakeSession{published: &got})
+
+	event := domain.NewCompanyEvent(context.TODO(), domain.InsertEventActionType, testUUID, domain.Company{ID: testUUID, Name: "1"})
+	err := s.Send(context.TODO(), event)
+	require.NoError(t, err)
+
+	require.Len(t, got, 1)
+	assert.Equal(t, "company.insert", got[0].key)
+	assert.Equal(t, amqpgo.Persistent, got[0].msg.DeliveryMode)
+	assert.Equal(t, cloudevents.ContentType, got[0].msg.ContentType)
+	assert.Equal(t, event.EventID.String(), got[0].msg.MessageId)
+
+	var gotEvent cloudevents.Event
+	require.NoError(t, json.Unmarshal(got[0].msg.Body, &gotEvent))
+	assert.Equal(t, s.encoder.Event(event), gotEvent)
+}
+
+func TestAMQPSend_ReconnectsClosedSession(t *testing.T) {
+	var got []published
+	s := newTestSender(
+		&fakeSession{published: &got, closed: true},
+		&fakeSession{published: &got},
+	)
+
+	require.NoError(t, s.Send(context.TODO(), domain.CompanyEvent{Action: domain.InsertEventActionType}))
+	require.NoError(t, s.Send(context.TODO(), domain.CompanyEvent{Action: domain.DeleteEventActionType}))
+
+	require.Len(t, got, 2)
+	assert.Equal(t, "company.delete", got[1].key)
+}
+
+func TestAMQPSend_RetriesDroppedChannel(t *testing.T) {
+	var got []published
+	s := newTestSender(
+		&fakeSession{published: &got, err: amqpgo.ErrClosed},
+		&fakeSession{published: &got},
+	)
+
+	err := s.Send(context.TODO(), domain.CompanyEvent{Action: domain.UpdateEventActionType})
+	require.NoError(t, err)
+
+	require.Len(t, got, 1)
+	assert.Equal(t, "company.update", got[0].key)
+}
+
+func TestAMQPSend_Failed(t *testing.T) {
+	var got []published
+	s := newTestSender(&fakeSession{published: &got, err: errNack})
+
+	err := s.Send(context.TODO(), domain.CompanyEvent{Action: domain.UpdateEventActionType})
+	assert.ErrorIs(t, err, errNack)
+
+	s = newTestSender()
+	err = s.Send(context.TODO(), domain.CompanyEvent{Action: domain.UpdateEventActionType})
+	assert.EqualError(t, err, "dial error")
+	assert.Empty(t, got)
+}
@@ -0,0 +1,104 @@
+package cloudevents
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+)
+
+// Wire format of company events: CloudEvents 1.0 in structured content mode
+const (
+	SpecVersion = "1.0"
+	ContentType = "application/cloudevents+json"
+	// TypePrefix is joined with the event action, e.g. com.github.alisskapie.projectxm.company.insert
+	TypePrefix = "com.github.alisskapie.projectxm.company."
+	// DataSchema identifies the version of the Company data schema,
+	// it must be bumped on every incompatible change of Company
+	DataSchema = "urn:projectxm:schema:company:1"
+)
+
+// Event is a company event in CloudEvents 1.0 structured content mode
+type Event struct {
+	SpecVersion     string    `json:"specversion"`
+	ID              string    `json:"id"`
+	Source          string    `json:"source"`
+	Type            string    `json:"type"`
+	Time            time.Time `json:"time"`
+	Subject         string    `json:"subject"`
+	DataContentType string    `json:"datacontenttype"`
+	DataSchema      string    `json:"dataschema"`
+	// TenantID is the extension attribute of the tenant owning the company
+	TenantID string   `json:"tenantid,omitempty"`
+	Data     *Company `json:"data,omitempty"`
+}
+
+// Company is the data of a company event
+type Company struct {
+	ID                uuid.UUID          `json:"id"`
+	Name              string             `json:"name"`
+	Description       string             `json:"description"`
+	AmountOfEmployees uint32             `json:"amount_of_employees"`
+	Registered        bool               `json:"registered"`
+	CompanyType       domain.CompanyType `json:"type"`
+	Version           int64              `json:"version"`
+}
+
+// Encoder encodes company events to the wire format shared by all event senders
+type Encoder struct {
+	source string
+}
+
+// NewEncoder creates an encoder of events produced by source, a URI-reference identifying this service
+func NewEncoder(source string) Encoder {
+	return Encoder{
+		source: source,
+	}
+}
+
+// Type returns the CloudEvents type of the event action
+func Type(action domain.EventActionType) string {
+	return TypePrefix + string(action)
+}
+
+// Event converts a domain event into its CloudEvents representation
+func (e Encoder) Event(event domain.CompanyEvent) Event {
+	ce := Event{
+		SpecVersion:     SpecVersion,
+		ID:              event.EventID.String(),
+		Source:          e.source,
+		Type:            Type(event.Action),
+		Time:            event.Time.UTC(),
+		Subject:         event.ID.String(),
+		DataContentType: "application/json",
+		DataSchema:      DataSchema,
+		TenantID:        event.TenantID,
+	}
+
+	if event.State.ID != uuid.Nil {
+		ce.Data = &Company{
+			ID:                event.State.ID,
+			Name:              event.State.Name,
+			Description:       event.State.Description,
+			AmountOfEmployees: event.State.AmountOfEmployees,
+			Registered:        event.State.Registered,
+			CompanyType:       event.State.CompanyType,
+			Version:           event.State.Version,
+		}
+	}
+
+	return ce
+}
+
+// Encode marshals the event into CloudEvents JSON
+func (e Encoder) Encode(event domain.CompanyEvent) ([]byte, error) {
+	b, err := json.Marshal(e.Event(event))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal cloud event: %w", err)
+	}
+
+	return b, nil
+}
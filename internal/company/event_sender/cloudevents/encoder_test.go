@@ -0,0 +1,93 @@
+package cloudevents
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+var (
+	testEventUUID = uuid.MustParse("20000000-0000-0000-0000-000000000000")
+	testUUID      = uuid.MustParse("10000000-0000-0000-0000-000000000000")
+	testTime      = time.Date(2022, 11, 14, 10, 30, 0, 123000000, time.FixedZone("CET", 3600))
+)
+
+func TestEncode_Golden(t *testing.T) {
+	company := domain.Company{
+		ID:                testUUID,
+		Name:              "Acme",
+		Description:       "Anvils",
+		AmountOfEmployees: 42,
+		Registered:        true,
+		CompanyType:       domain.CorporationsType,
+		Version:           2,
+	}
+	tests := []struct {
+		name  string
+		event domain.CompanyEvent
+	}{
+		{
+			name: "insert",
+			event: domain.CompanyEvent{
+				EventID:  testEventUUID,
+				Time:     testTime,
+				Action:   domain.InsertEventActionType,
+				ID:       testUUID,
+				TenantID: "acme",
+				State:    company,
+			},
+		},
+		{
+			name: "update",
+			event: domain.CompanyEvent{
+				EventID:  testEventUUID,
+				Time:     testTime,
+				Action:   domain.UpdateEventActionType,
+				ID:       testUUID,
+				TenantID: "acme",
+				State:    company,
+			},
+		},
+		{
+			name: "delete",
+			event: domain.CompanyEvent{
+				EventID:  testEventUUID,
+				Time:     testTime,
+				Action:   domain.DeleteEventActionType,
+				ID:       testUUID,
+				TenantID: "acme",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := NewEncoder("/project-xm").Encode(tt.event)
+			require.NoError(t, err)
+
+			var got bytes.Buffer
+			require.NoError(t, json.Indent(&got, b, "", "  "))
+			got.WriteByte('\n')
+
+			golden := filepath.Join("testdata", tt.name+".golden")
+			if *update {
+				require.NoError(t, os.WriteFile(golden, got.Bytes(), 0o600))
+			}
+
+			want, err := os.ReadFile(golden)
+			require.NoError(t, err)
+			assert.Equal(t, string(want), got.String())
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+
+	kafkago "github.com/segmentio/kafka-go"
+
+	"github.com/AlisskaPie/project-xm/internal/company/event_sender/cloudevents"
+	"github.com/AlisskaPie/project-xm/internal/config"
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+)
+
+type messageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafkago.Message) error
+	Close() error
+}
+
+// CompanyEventSenderKafka produces company events to a Kafka topic as structured CloudEvents.
+// Events are keyed by company ID, so the events of one company keep their order.
+type CompanyEventSenderKafka struct {
+	writer  messageWriter
+	encoder cloudevents.Encoder
+}
+
+// NewCompanyEventSenderKafka creates a synchronous Kafka producer configured by conf
+func NewCompanyEventSenderKafka(conf config.Kafka, encoder cloudevents.Encoder) (*CompanyEventSenderKafka, error) {
+	if len(conf.Brokers) == 0 {
+		return nil, fmt.Errorf("no kafka brokers configured")
+	}
+
+	if conf.Topic == "" {
+		return nil, fmt.Errorf("no kafka topic configured")
+	}
+
+	var acks kafkago.RequiredAcks
+	if err := acks.UnmarshalText([]byte(conf.Acks)); err != nil {
+		return nil, fmt.Errorf("invalid kafka acks: %w", err)
+	}
+
+	var compression kafkago.Compression
+	if err := compression.UnmarshalText([]byte(conf.Compression)); err != nil {
+		return nil, fmt.Errorf("invalid kafka compression: %w", err)
+	}
+
+	return &CompanyEventSenderKafka{
+		writer: &kafkago.Writer{
+			Addr:         kafkago.TCP(conf.Brokers...),
+			Topic:        conf.Topic,
+			Balancer:     &kafkago.Hash{},
+			RequiredAcks: acks,
+			Compression:  compression,
+			BatchTimeout: conf.BatchTimeout,
+		},
+		encoder: encoder,
+	}, nil
+}
+
+// Send implements domain.CompanyEventSender
+func (s *CompanyEventSenderKafka) Send(ctx context.Context, event domain.CompanyEvent) error {
+	value, err := s.encoder.Encode(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	if err := s.writer.WriteMessages(ctx, kafkago.Message{
+		Key:   []byte(event.ID.String()),
+		Value: value,
+		Headers: []kafkago.Header{
+			{Key: "content-type", Value: []byte(cloudevents.ContentType)},
+		},
+	}); err != nil {
+		return fmt.Errorf("WriteMessages: %w", err)
+	}
+
+	return nil
+}
+
+// Close flushes pending messages and closes the producer
+func (s *CompanyEventSenderKafka) Close() error {
+	return s.writer.Close()
+}
@@ -0,0 +1,143 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	kafkago "github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/AlisskaPie/project-xm/internal/company/event_sender/cloudevents"
+	"github.com/AlisskaPie/project-xm/internal/config"
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+)
+
+var (
+	testUUID    = uuid.MustParse("10000000-0000-0000-0000-000000000000")
+	testEncoder = cloudevents.NewEncoder("/project-xm")
+)
+
+type fakeWriter struct {
+	messages []kafkago.Message
+	err      error
+}
+
+func (w *fakeWriter) WriteMessages(_ context.Context, msgs ...kafkago.Message) error {
+	if w.err != nil {
+		return w.err
+	}
+	w.messages = append(w.messages, msgs...)
+	return nil
+}
+
+func (w *fakeWriter) Close() error {
+	return nil
+}
+
+func TestKafkaSend_Success(t *testing.T) {
+	w := &fakeWriter{}
+	s := &CompanyEventSenderKafka{writer: w, encoder: testEncoder}
+
+	event := domain.NewCompanyEvent(context.TODO(), domain.UpdateEventActionType, testUUID, domain.Company{ID: testUUID, Name: "1"})
+	err := s.Send(context.TODO(), event)
+	require.NoError(t, err)
+
+	require.Len(t, w.messages, 1)
+	assert.Equal(t, testUUID.String(), string(w.messages[0].Key))
+	assert.Equal(t, []kafkago.Header{{Key: "content-type", Value: []byte(cloudevents.ContentType)}}, w.messages[0].Headers)
+
+	var got cloudevents.Event
+	require.NoError(t, json.Unmarshal(w.messages[0].Value, &got))
+	assert.Equal(t, testEncoder.Event(event), got)
+}
+
+func TestKafkaSend_Failed(t *testing.T) {
+	testErr := errors.New("test error")
+	s := &CompanyEventSenderKafka{writer: &fakeWriter{err: testErr}, encoder: testEncoder}
+
+	err := s.Send(context.TODO(), domain.CompanyEvent{ID: testUUID})
+	assert.ErrorIs(t, err, testErr)
+}
+
+func TestNewCompanyEventSenderKafka_InvalidConfig(t *testing.T) {
+	valid := config.Kafka{
+		Brokers:     []string{"localhost:9092"},
+		Topic:       "company-events",
+		Acks:        "all",
+		Compression: "snappy",
+	}
+
+	_, err := NewCompanyEventSenderKafka(valid, testEncoder)
+	assert.NoError(t, err)
+
+	noBrokers := valid
+	noBrokers.Brokers = nil
+	_, err = NewCompanyEventSenderKafka(noBrokers, testEncoder)
+	assert.Error(t, err)
+
+	noTopic := valid
+	noTopic.Topic = ""
+	_, err = NewCompanyEventSenderKafka(noTopic, testEncoder)
+	assert.Error(t, err)
+
+	badAcks := valid
+	badAcks.Acks = "some"
+	_, err = NewCompanyEventSenderKafka(badAcks, testEncoder)
+	assert.Error(t, err)
+
+	badCompression := valid
+	badCompression.Compression = "rar"
+	_, err = NewCompanyEventSenderKafka(badCompression, testEncoder)
+	assert.Error(t, err)
+}
+
+// Runs against a local Kafka, e.g. started with
+// docker run -p 9092:9092 -e KAFKA_AUTO_CREATE_TOPICS_ENABLE=true apache/kafka
+func TestIntegration_KafkaSend(t *testing.T) {
+	brokers := os.Getenv("KAFKA_BROKERS")
+	if testing.Short() || brokers == "" {
+		t.Skip("skipping integration test")
+	}
+
+	topic := "company-events-" + uuid.NewString()
+	s, err := NewCompanyEventSenderKafka(config.Kafka{
+		Brokers:     strings.Split(brokers, ","),
+		Topic:       topic,
+		Acks:        "all",
+		Compression: "gzip",
+	}, testEncoder)
+	require.NoError(t, err)
+	s.writer.(*kafkago.Writer).AllowAutoTopicCreation = true
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	id := uuid.New()
+	for _, action := range []domain.EventActionType{domain.InsertEventActionType, domain.DeleteEventActionType} {
+		require.NoError(t, s.Send(ctx, domain.NewCompanyEvent(ctx, action, id, domain.Company{})))
+	}
+	require.NoError(t, s.Close())
+
+	r := kafkago.NewReader(kafkago.ReaderConfig{
+		Brokers: strings.Split(brokers, ","),
+		Topic:   topic,
+	})
+	defer r.Close()
+
+	for _, action := range []domain.EventActionType{domain.InsertEventActionType, domain.DeleteEventActionType} {
+		m, err := r.ReadMessage(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, id.String(), string(m.Key))
+
+		var got cloudevents.Event
+		require.NoError(t, json.Unmarshal(m.Value, &got))
+		assert.Equal(t, cloudevents.Type(action), got.Type)
+	}
+}
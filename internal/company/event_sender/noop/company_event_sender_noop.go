@@ -2,25 +2,34 @@ package noop
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/rs/zerolog"
 
+	"github.com/AlisskaPie/project-xm/internal/company/event_sender/cloudevents"
 	"github.com/AlisskaPie/project-xm/pkg/domain"
 )
 
 // No operation (just logging) implementation of company event sender
 type companyEventSenderNoop struct {
-	log zerolog.Logger
+	log     zerolog.Logger
+	encoder cloudevents.Encoder
 }
 
-func NewCompanyEventSenderNoop(log zerolog.Logger) domain.CompanyEventSender {
+func NewCompanyEventSenderNoop(log zerolog.Logger, encoder cloudevents.Encoder) domain.CompanyEventSender {
 	return &companyEventSenderNoop{
-		log: log,
+		log:     log,
+		encoder: encoder,
 	}
 }
 
 func (n *companyEventSenderNoop) Send(_ context.Context, event domain.CompanyEvent) error {
-	n.log.Info().Interface("event", event).Msg("noop event has been sent")
+	payload, err := n.encoder.Encode(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	n.log.Info().RawJSON("event", payload).Msg("noop event has been sent")
 
 	return nil
 }
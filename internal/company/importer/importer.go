@@ -0,0 +1,175 @@
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/rs/zerolog"
+
+	delivery "github.com/AlisskaPie/project-xm/internal/company/delivery/http"
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+)
+
+// Options configure the import
+type Options struct {
+	Mapping Mapping
+	// BatchSize is the number of companies created in a transaction, at most domain.MaxBatchSize
+	BatchSize int
+	// DryRun validates the rows without creating the companies
+	DryRun bool
+	// Checkpoint is called with the number of the processed rows after each committed batch,
+	// the import is resumed by skipping them
+	Checkpoint func(rows int) error
+}
+
+// Rejection is the rejected row written to the error file as a JSON line
+type Rejection struct {
+	Row     int      `json:"row"`
+	Record  Record   `json:"record,omitempty"`
+	Reasons []string `json:"reasons"`
+}
+
+// Result sums up the import
+type Result struct {
+	// Rows is the number of the processed rows including the skipped ones
+	Rows     int
+	Imported int
+	Rejected int
+}
+
+// Importer creates the companies of the imported file in batches.
+// A batch is created in the best effort mode, so a company that already exists is rejected alone.
+type Importer struct {
+	usecase domain.CompanyUsecase
+	opts    Options
+	log     zerolog.Logger
+}
+
+// New creates an Importer of the companies through the use case
+func New(usecase domain.CompanyUsecase, opts Options, log zerolog.Logger) (*Importer, error) {
+	if opts.BatchSize <= 0 || opts.BatchSize > domain.MaxBatchSize {
+		return nil, fmt.Errorf("batch size must be between 1 and %d", domain.MaxBatchSize)
+	}
+	if opts.Mapping == nil {
+		opts.Mapping, _ = ParseMapping("")
+	}
+
+	return &Importer{
+		usecase: usecase,
+		opts:    opts,
+		log:     log,
+	}, nil
+}
+
+// batch is the rows read since the last commit
+type batch struct {
+	rows      []int
+	records   []Record
+	companies []domain.CreateCompany
+	rejected  []Rejection
+}
+
+// Import reads the rows after the first skip ones and writes the rejected ones to rejects.
+// The rejections of a batch are written before its checkpoint.
+func (im *Importer) Import(ctx context.Context, r Reader, skip int, rejects io.Writer) (Result, error) {
+	res := Result{Rows: skip}
+	enc := json.NewEncoder(rejects)
+	b := &batch{}
+
+	row := 0
+	for {
+		rec, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		row++
+		if row <= skip {
+			continue
+		}
+		if err != nil {
+			if !errors.Is(err, ErrMalformedRow) {
+				return res, fmt.Errorf("failed to read row %d: %w", row, err)
+			}
+
+			b.rejected = append(b.rejected, Rejection{Row: row, Reasons: []string{err.Error()}})
+			continue
+		}
+
+		company, reasons := im.opts.Mapping.Company(row, rec)
+		if len(reasons) > 0 {
+			b.rejected = append(b.rejected, Rejection{Row: row, Record: rec, Reasons: reasons})
+			continue
+		}
+
+		b.rows = append(b.rows, row)
+		b.records = append(b.records, rec)
+		b.companies = append(b.companies, company)
+
+		if len(b.companies) >= im.opts.BatchSize {
+			if err := im.commit(ctx, b, row, enc, &res); err != nil {
+				return res, err
+			}
+			b = &batch{}
+		}
+	}
+
+	if len(b.companies) > 0 || len(b.rejected) > 0 {
+		if err := im.commit(ctx, b, row, enc, &res); err != nil {
+			return res, err
+		}
+	}
+
+	return res, nil
+}
+
+// commit creates the companies of the batch, rows is the number of the processed rows after it
+func (im *Importer) commit(ctx context.Context, b *batch, rows int, enc *json.Encoder, res *Result) error {
+	imported := len(b.companies)
+
+	if !im.opts.DryRun && len(b.companies) > 0 {
+		results, err := im.usecase.CreateBatch(ctx, domain.CreateCompanies{
+			Companies: b.companies,
+			Mode:      domain.BestEffortBatchMode,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create companies of rows %d-%d: %w", b.rows[0], b.rows[len(b.rows)-1], err)
+		}
+
+		for i, r := range results {
+			if r.Err == nil {
+				continue
+			}
+
+			imported--
+			b.rejected = append(b.rejected, Rejection{
+				Row:     b.rows[i],
+				Record:  b.records[i],
+				Reasons: []string{delivery.NewProblemDetails(r.Err).Error()},
+			})
+		}
+	}
+
+	for _, rej := range b.rejected {
+		if err := enc.Encode(rej); err != nil {
+			return fmt.Errorf("failed to write rejected row %d: %w", rej.Row, err)
+		}
+	}
+
+	res.Rows = rows
+	res.Imported += imported
+	res.Rejected += len(b.rejected)
+	im.log.Info().Int("rows", res.Rows).Int("imported", res.Imported).Int("rejected", res.Rejected).
+		Bool("dry_run", im.opts.DryRun).Msg("import batch committed")
+
+	if im.opts.DryRun || im.opts.Checkpoint == nil {
+		return nil
+	}
+	if err := im.opts.Checkpoint(rows); err != nil {
+		return fmt.Errorf("failed to checkpoint row %d: %w", rows, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,190 @@
+package importer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+	"github.com/AlisskaPie/project-xm/pkg/domain/mocks"
+)
+
+var testUUID = uuid.MustParse("10000000-0000-0000-0000-000000000000")
+
+const testCSV = "Company,kind,amount_of_employees,id\n" +
+	"a,NonProfit,10,10000000-0000-0000-0000-000000000000\n" +
+	",NonProfit,x,\n" +
+	"b,Other,10,\n" +
+	"c,Cooperative,20,\n" +
+	"d,Cooperative,30,\n"
+
+func TestMappingCompany(t *testing.T) {
+	m, err := ParseMapping("")
+	require.NoError(t, err)
+
+	company, reasons := m.Company(1, Record{"id": testUUID.String(), "name": "a", "amount_of_employees": "10",
+		"registered": "true", "type": "NonProfit"})
+	assert.Empty(t, reasons)
+	assert.Equal(t, domain.CreateCompany{
+		ID:                testUUID,
+		Name:              "a",
+		AmountOfEmployees: 10,
+		Registered:        true,
+		CompanyType:       domain.NonProfitType,
+	}, company)
+
+	company, reasons = m.Company(2, Record{"name": "b", "amount_of_employees": "1", "type": "NonProfit"})
+	assert.Empty(t, reasons)
+	other, _ := m.Company(2, Record{"name": "b", "amount_of_employees": "1", "type": "NonProfit"})
+	assert.NotEqual(t, uuid.Nil, company.ID)
+	assert.Equal(t, company.ID, other.ID)
+
+	_, reasons = m.Company(3, Record{"id": "1", "name": "abcdefghijklmnop", "amount_of_employees": "-1",
+		"registered": "maybe", "type": "Other"})
+	assert.Equal(t, []string{
+		"id: uuid",
+		"amount_of_employees: uint32",
+		"registered: bool",
+		"name: max",
+		"amount_of_employees: required",
+		"type: company_type",
+	}, reasons)
+}
+
+func TestImport(t *testing.T) {
+	m, err := ParseMapping("name=Company,type=kind")
+	require.NoError(t, err)
+
+	mockUseCase := &mocks.CompanyUsecase{}
+	mockUseCase.On("CreateBatch", mock.Anything, mock.MatchedBy(func(b domain.CreateCompanies) bool {
+		return len(b.Companies) == 2 && b.Companies[0].Name == "a" && b.Mode == domain.BestEffortBatchMode
+	})).Return([]domain.CreateCompanyResult{
+		{Err: domain.NewError(domain.ErrConflict, "company already exists", nil)},
+		{Company: domain.Company{Name: "c"}},
+	}, nil).Once()
+	mockUseCase.On("CreateBatch", mock.Anything, mock.MatchedBy(func(b domain.CreateCompanies) bool {
+		return len(b.Companies) == 1 && b.Companies[0].Name == "d"
+	})).Return([]domain.CreateCompanyResult{{Company: domain.Company{Name: "d"}}}, nil).Once()
+
+	var checkpoints []int
+	im, err := New(mockUseCase, Options{
+		Mapping:   m,
+		BatchSize: 2,
+		Checkpoint: func(rows int) error {
+			checkpoints = append(checkpoints, rows)
+			return nil
+		},
+	}, zerolog.New(io.Discard))
+	require.NoError(t, err)
+
+	r, err := NewCSVReader(strings.NewReader(testCSV))
+	require.NoError(t, err)
+
+	var rejects bytes.Buffer
+	res, err := im.Import(context.TODO(), r, 0, &rejects)
+	require.NoError(t, err)
+	assert.Equal(t, Result{Rows: 5, Imported: 2, Rejected: 3}, res)
+	assert.Equal(t, []int{4, 5}, checkpoints)
+	assert.Equal(t,
+		`{"row":2,"record":{"Company":"","amount_of_employees":"x","id":"","kind":"NonProfit"},`+
+			`"reasons":["amount_of_employees: uint32","name: required","amount_of_employees: required"]}`+"\n"+
+			`{"row":3,"record":{"Company":"b","amount_of_employees":"10","id":"","kind":"Other"},"reasons":["type: company_type"]}`+"\n"+
+			`{"row":1,"record":{"Company":"a","amount_of_employees":"10","id":"10000000-0000-0000-0000-000000000000","kind":"NonProfit"},`+
+			`"reasons":["Conflicting resource state: company already exists"]}`+"\n",
+		rejects.String(),
+	)
+	mockUseCase.AssertExpectations(t)
+}
+
+func TestImport_Resume(t *testing.T) {
+	m, err := ParseMapping("name=Company,type=kind")
+	require.NoError(t, err)
+
+	mockUseCase := &mocks.CompanyUsecase{}
+	mockUseCase.On("CreateBatch", mock.Anything, mock.MatchedBy(func(b domain.CreateCompanies) bool {
+		return len(b.Companies) == 1 && b.Companies[0].Name == "d"
+	})).Return([]domain.CreateCompanyResult{{Company: domain.Company{Name: "d"}}}, nil).Once()
+
+	im, err := New(mockUseCase, Options{Mapping: m, BatchSize: 2}, zerolog.New(io.Discard))
+	require.NoError(t, err)
+
+	r, err := NewCSVReader(strings.NewReader(testCSV))
+	require.NoError(t, err)
+
+	var rejects bytes.Buffer
+	res, err := im.Import(context.TODO(), r, 4, &rejects)
+	require.NoError(t, err)
+	assert.Equal(t, Result{Rows: 5, Imported: 1}, res)
+	assert.Empty(t, rejects.String())
+	mockUseCase.AssertExpectations(t)
+}
+
+func TestImport_DryRun(t *testing.T) {
+	m, err := ParseMapping("name=Company,type=kind")
+	require.NoError(t, err)
+
+	mockUseCase := &mocks.CompanyUsecase{}
+
+	im, err := New(mockUseCase, Options{
+		Mapping:   m,
+		BatchSize: 2,
+		DryRun:    true,
+		Checkpoint: func(rows int) error {
+			return errors.New("unexpected checkpoint")
+		},
+	}, zerolog.New(io.Discard))
+	require.NoError(t, err)
+
+	r, err := NewCSVReader(strings.NewReader(testCSV))
+	require.NoError(t, err)
+
+	var rejects bytes.Buffer
+	res, err := im.Import(context.TODO(), r, 0, &rejects)
+	require.NoError(t, err)
+	assert.Equal(t, Result{Rows: 5, Imported: 3, Rejected: 2}, res)
+	mockUseCase.AssertExpectations(t)
+}
+
+func TestImport_Failed(t *testing.T) {
+	m, err := ParseMapping("name=Company,type=kind")
+	require.NoError(t, err)
+
+	mockUseCase := &mocks.CompanyUsecase{}
+	mockUseCase.On("CreateBatch", mock.Anything, mock.Anything).Return(nil, errors.New("test error")).Once()
+
+	var checkpoints []int
+	im, err := New(mockUseCase, Options{
+		Mapping:   m,
+		BatchSize: 2,
+		Checkpoint: func(rows int) error {
+			checkpoints = append(checkpoints, rows)
+			return nil
+		},
+	}, zerolog.New(io.Discard))
+	require.NoError(t, err)
+
+	r, err := NewCSVReader(strings.NewReader(testCSV))
+	require.NoError(t, err)
+
+	var rejects bytes.Buffer
+	res, err := im.Import(context.TODO(), r, 0, &rejects)
+	assert.EqualError(t, err, "failed to create companies of rows 1-4: test error")
+	assert.Equal(t, Result{}, res)
+	assert.Empty(t, checkpoints)
+	assert.Empty(t, rejects.String())
+	mockUseCase.AssertExpectations(t)
+}
+
+func TestNew_BatchSize(t *testing.T) {
+	_, err := New(&mocks.CompanyUsecase{}, Options{BatchSize: domain.MaxBatchSize + 1}, zerolog.New(io.Discard))
+	assert.EqualError(t, err, "batch size must be between 1 and 1000")
+}
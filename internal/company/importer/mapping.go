@@ -0,0 +1,118 @@
+package importer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+
+	delivery "github.com/AlisskaPie/project-xm/internal/company/delivery/http"
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+)
+
+// Company fields of the imported rows, named as in delivery.CompanyPostRequest
+const (
+	IDField                = "id"
+	NameField              = "name"
+	DescriptionField       = "description"
+	AmountOfEmployeesField = "amount_of_employees"
+	RegisteredField        = "registered"
+	TypeField              = "type"
+)
+
+var fields = []string{
+	IDField,
+	NameField,
+	DescriptionField,
+	AmountOfEmployeesField,
+	RegisteredField,
+	TypeField,
+}
+
+// rowNamespace derives the IDs of the rows without one
+var rowNamespace = uuid.MustParse("6f1c1f7e-5d0b-4a7e-9f4e-2b8f0c3d9a10")
+
+// Mapping maps the company fields to the columns of the imported file
+type Mapping map[string]string
+
+// ParseMapping parses the comma separated field=column pairs,
+// the fields missing in s are mapped to the columns of the same name
+func ParseMapping(s string) (Mapping, error) {
+	m := make(Mapping, len(fields))
+	for _, f := range fields {
+		m[f] = f
+	}
+
+	if strings.TrimSpace(s) == "" {
+		return m, nil
+	}
+
+	for _, pair := range strings.Split(s, ",") {
+		field, column, ok := strings.Cut(pair, "=")
+		field = strings.TrimSpace(field)
+		column = strings.TrimSpace(column)
+		if !ok || column == "" {
+			return nil, fmt.Errorf("invalid mapping %q, want field=column", pair)
+		}
+		if _, known := m[field]; !known {
+			return nil, fmt.Errorf("unknown field %q, want one of %s", field, strings.Join(fields, ", "))
+		}
+
+		m[field] = column
+	}
+
+	return m, nil
+}
+
+// Company converts the row to the company, validated with the rules of delivery.CompanyPostRequest.
+// The reasons are the failed rules of the invalid row, as field: rule.
+// The row without an ID gets the one derived from its number and name,
+// so that rerunning an interrupted import doesn't duplicate it.
+func (m Mapping) Company(row int, rec Record) (domain.CreateCompany, []string) {
+	var reasons []string
+	req := delivery.CompanyPostRequest{
+		Name:        rec[m[NameField]],
+		Description: rec[m[DescriptionField]],
+		CompanyType: domain.CompanyType(rec[m[TypeField]]),
+	}
+
+	if v := rec[m[IDField]]; v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			reasons = append(reasons, IDField+": uuid")
+		}
+		req.ID = id
+	} else {
+		req.ID = uuid.NewSHA1(rowNamespace, []byte(fmt.Sprintf("%d/%s", row, req.Name)))
+	}
+
+	if v := rec[m[AmountOfEmployeesField]]; v != "" {
+		amount, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			reasons = append(reasons, AmountOfEmployeesField+": uint32")
+		}
+		req.AmountOfEmployees = uint32(amount)
+	}
+
+	if v := rec[m[RegisteredField]]; v != "" {
+		registered, err := strconv.ParseBool(v)
+		if err != nil {
+			reasons = append(reasons, RegisteredField+": bool")
+		}
+		req.Registered = registered
+	}
+
+	if err := req.Validate(); err != nil {
+		p := delivery.NewProblemDetails(domain.NewError(domain.ErrBadRequest, "", err))
+		for _, f := range p.Errors {
+			reasons = append(reasons, f.Field+": "+f.Rule)
+		}
+	}
+
+	if len(reasons) > 0 {
+		return domain.CreateCompany{}, reasons
+	}
+
+	return req.ToCreateCompany(), nil
+}
@@ -0,0 +1,123 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// ErrMalformedRow is the error of a row that cannot be parsed, the row is rejected and the import goes on
+var ErrMalformedRow = errors.New("malformed row")
+
+// Record is a row of the imported file keyed by its column names
+type Record map[string]string
+
+// Reader reads the rows of the imported file one by one, it returns io.EOF after the last row
+type Reader interface {
+	Read() (Record, error)
+}
+
+type csvReader struct {
+	r      *csv.Reader
+	header []string
+}
+
+// NewCSVReader creates a Reader of the CSV with the column names in the first line
+func NewCSVReader(r io.Reader) (Reader, error) {
+	cr := csv.NewReader(r)
+	// the missing trailing columns are read as empty
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	return &csvReader{
+		r:      cr,
+		header: header,
+	}, nil
+}
+
+// Read implements Reader
+func (r *csvReader) Read() (Record, error) {
+	fields, err := r.r.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, fmt.Errorf("%w: %v", ErrMalformedRow, err)
+		}
+		return nil, err
+	}
+
+	rec := make(Record, len(r.header))
+	for i, column := range r.header {
+		if i < len(fields) {
+			rec[column] = fields[i]
+		}
+	}
+
+	return rec, nil
+}
+
+type ndjsonReader struct {
+	s *bufio.Scanner
+}
+
+// NewNDJSONReader creates a Reader of the newline delimited JSON objects,
+// their values are read as strings and the empty lines are skipped
+func NewNDJSONReader(r io.Reader) Reader {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	return &ndjsonReader{
+		s: s,
+	}
+}
+
+// Read implements Reader
+func (r *ndjsonReader) Read() (Record, error) {
+	for r.s.Scan() {
+		line := bytes.TrimSpace(r.s.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		d := json.NewDecoder(bytes.NewReader(line))
+		d.UseNumber()
+
+		var values map[string]any
+		if err := d.Decode(&values); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrMalformedRow, err)
+		}
+
+		rec := make(Record, len(values))
+		for k, v := range values {
+			switch v := v.(type) {
+			case nil:
+				rec[k] = ""
+			case string:
+				rec[k] = v
+			case json.Number:
+				rec[k] = v.String()
+			case bool:
+				rec[k] = strconv.FormatBool(v)
+			default:
+				return nil, fmt.Errorf("%w: %q is not a scalar", ErrMalformedRow, k)
+			}
+		}
+
+		return rec, nil
+	}
+
+	if err := r.s.Err(); err != nil {
+		return nil, err
+	}
+
+	return nil, io.EOF
+}
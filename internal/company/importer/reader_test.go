@@ -0,0 +1,73 @@
+package importer
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readAll(t *testing.T, r Reader) ([]Record, []error) {
+	var recs []Record
+	var errs []error
+	for {
+		rec, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return recs, errs
+		}
+		recs = append(recs, rec)
+		errs = append(errs, err)
+	}
+}
+
+func TestCSVReader(t *testing.T) {
+	r, err := NewCSVReader(strings.NewReader("name,type,registered\n" +
+		"a,NonProfit,true\n" +
+		"b\n" +
+		"\"c,Cooperative\n"))
+	require.NoError(t, err)
+
+	recs, errs := readAll(t, r)
+	require.Len(t, recs, 3)
+	assert.Equal(t, Record{"name": "a", "type": "NonProfit", "registered": "true"}, recs[0])
+	assert.NoError(t, errs[0])
+	assert.Equal(t, Record{"name": "b"}, recs[1])
+	assert.NoError(t, errs[1])
+	assert.ErrorIs(t, errs[2], ErrMalformedRow)
+}
+
+func TestNDJSONReader(t *testing.T) {
+	r := NewNDJSONReader(strings.NewReader(`{"name":"a","amount_of_employees":10,"registered":true,"description":null}` +
+		"\n\n" +
+		`{"name":` + "\n" +
+		`{"name":["b"]}` + "\n"))
+
+	recs, errs := readAll(t, r)
+	require.Len(t, recs, 3)
+	assert.Equal(t, Record{"name": "a", "amount_of_employees": "10", "registered": "true", "description": ""}, recs[0])
+	assert.NoError(t, errs[0])
+	assert.ErrorIs(t, errs[1], ErrMalformedRow)
+	assert.ErrorIs(t, errs[2], ErrMalformedRow)
+}
+
+func TestParseMapping(t *testing.T) {
+	m, err := ParseMapping("name = Company Name,type=Kind")
+	require.NoError(t, err)
+	assert.Equal(t, Mapping{
+		IDField:                IDField,
+		NameField:              "Company Name",
+		DescriptionField:       DescriptionField,
+		AmountOfEmployeesField: AmountOfEmployeesField,
+		RegisteredField:        RegisteredField,
+		TypeField:              "Kind",
+	}, m)
+
+	_, err = ParseMapping("name")
+	assert.EqualError(t, err, `invalid mapping "name", want field=column`)
+
+	_, err = ParseMapping("title=Name")
+	assert.EqualError(t, err, `unknown field "title", want one of id, name, description, amount_of_employees, registered, type`)
+}
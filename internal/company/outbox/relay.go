@@ -0,0 +1,107 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog"
+
+	"github.com/AlisskaPie/project-xm/internal/config"
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+)
+
+// Relay drains the company events outbox to the event sender.
+// Events are removed from the outbox only after they were sent,
+// so each of them is delivered at least once.
+type Relay struct {
+	outbox  domain.CompanyOutboxRepository
+	sender  domain.CompanyEventSender
+	conf    config.Outbox
+	backlog prometheus.Gauge
+	log     zerolog.Logger
+}
+
+// NewRelay creates a relay and registers its metrics in reg
+func NewRelay(
+	outbox domain.CompanyOutboxRepository,
+	sender domain.CompanyEventSender,
+	conf config.Outbox,
+	reg prometheus.Registerer,
+	log zerolog.Logger,
+) *Relay {
+	backlog := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "company_outbox_backlog",
+		Help: "Number of company events waiting in the outbox to be sent.",
+	})
+	reg.MustRegister(backlog)
+
+	return &Relay{
+		outbox:  outbox,
+		sender:  sender,
+		conf:    conf,
+		backlog: backlog,
+		log:     log,
+	}
+}
+
+// Run relays the outbox until ctx is done, backing off exponentially while sending fails
+func (r *Relay) Run(ctx context.Context) {
+	failures := 0
+
+	for {
+		wait := r.conf.PollInterval
+		if err := r.RelayPending(ctx); err != nil {
+			failures++
+			wait = r.backoff(failures)
+			r.log.Err(err).Int("failures", failures).Dur("retry_in", wait).Msg("failed to relay outbox")
+		} else {
+			failures = 0
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// RelayPending sends the pending events in batches until the outbox is empty or sending fails
+func (r *Relay) RelayPending(ctx context.Context) error {
+	defer r.updateBacklog(ctx)
+
+	for {
+		sent, err := r.outbox.Dispatch(ctx, r.conf.BatchSize, r.sender.Send)
+		if err != nil {
+			return err
+		}
+
+		if sent == 0 || sent < r.conf.BatchSize {
+			return nil
+		}
+	}
+}
+
+func (r *Relay) updateBacklog(ctx context.Context) {
+	n, err := r.outbox.Pending(ctx)
+	if err != nil {
+		r.log.Err(err).Msg("failed to get outbox backlog")
+		return
+	}
+
+	r.backlog.Set(float64(n))
+}
+
+func (r *Relay) backoff(failures int) time.Duration {
+	wait := r.conf.MinRetryBackoff
+	for i := 1; i < failures && wait < r.conf.MaxRetryBackoff; i++ {
+		wait *= 2
+	}
+
+	if wait > r.conf.MaxRetryBackoff {
+		return r.conf.MaxRetryBackoff
+	}
+
+	return wait
+}
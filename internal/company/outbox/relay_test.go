@@ -0,0 +1,63 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/AlisskaPie/project-xm/internal/config"
+	"github.com/AlisskaPie/project-xm/pkg/domain/mocks"
+)
+
+var testConfig = config.Outbox{
+	PollInterval:    time.Second,
+	BatchSize:       2,
+	MinRetryBackoff: time.Second,
+	MaxRetryBackoff: 5 * time.Second,
+}
+
+func TestRelayPending_Success(t *testing.T) {
+	o := &mocks.CompanyOutboxRepository{}
+	o.On("Dispatch", mock.Anything, 2, mock.Anything).Return(2, nil).Once()
+	o.On("Dispatch", mock.Anything, 2, mock.Anything).Return(1, nil).Once()
+	o.On("Pending", mock.Anything).Return(0, nil)
+
+	r := NewRelay(o, &mocks.CompanyEventSender{}, testConfig, prometheus.NewRegistry(), zerolog.New(io.Discard))
+	err := r.RelayPending(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, float64(0), testutil.ToFloat64(r.backlog))
+
+	o.AssertExpectations(t)
+}
+
+func TestRelayPending_Failed(t *testing.T) {
+	testErr := errors.New("test error")
+	o := &mocks.CompanyOutboxRepository{}
+	o.On("Dispatch", mock.Anything, 2, mock.Anything).Return(0, testErr).Once()
+	o.On("Pending", mock.Anything).Return(7, nil)
+
+	r := NewRelay(o, &mocks.CompanyEventSender{}, testConfig, prometheus.NewRegistry(), zerolog.New(io.Discard))
+	err := r.RelayPending(context.TODO())
+	assert.ErrorIs(t, err, testErr)
+	assert.Equal(t, float64(7), testutil.ToFloat64(r.backlog))
+
+	o.AssertExpectations(t)
+}
+
+func TestRelayBackoff(t *testing.T) {
+	r := &Relay{conf: testConfig}
+
+	assert.Equal(t, time.Second, r.backoff(1))
+	assert.Equal(t, 2*time.Second, r.backoff(2))
+	assert.Equal(t, 4*time.Second, r.backoff(3))
+	assert.Equal(t, 5*time.Second, r.backoff(4))
+	assert.Equal(t, 5*time.Second, r.backoff(100))
+}
@@ -0,0 +1,196 @@
+// Package audit records the audit entries of the company mutations of the SQL repositories
+package audit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+)
+
+// Store keeps the audit entries in the database of the company repository
+type Store interface {
+	// WithTx runs fn in the transaction carried by ctx, or in a new one passed to fn in its context
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+	// Lock reads the company before its update and keeps it from concurrent updates until the transaction ends,
+	// the deleted company is read only if withDeleted is set
+	Lock(ctx context.Context, id uuid.UUID, withDeleted bool) (domain.Company, error)
+	// AddEntry records the audit entry of the company in the transaction carried by ctx
+	AddEntry(ctx context.Context, action domain.EventActionType, id uuid.UUID, changes []domain.FieldChange) error
+}
+
+// wrapper records an audit entry within the same transaction as each mutation
+type wrapper struct {
+	store Store
+	repo  domain.CompanyRepository
+}
+
+// Create implements domain.CompanyRepository
+func (r *wrapper) Create(ctx context.Context, c domain.CreateCompany) (domain.Company, error) {
+	var company domain.Company
+
+	err := r.store.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		company, err = r.repo.Create(ctx, c)
+		if err != nil {
+			return fmt.Errorf("repo.Create: %w", err)
+		}
+
+		changes := domain.CompanyChanges(nil, company)
+		if err := r.store.AddEntry(ctx, domain.InsertEventActionType, company.ID, changes); err != nil {
+			return fmt.Errorf("failed to add insert audit entry: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return domain.Company{}, err
+	}
+
+	return company, nil
+}
+
+// CreateBatch implements domain.CompanyRepository
+func (r *wrapper) CreateBatch(ctx context.Context, b domain.CreateCompanies) ([]domain.CreateCompanyResult, error) {
+	var results []domain.CreateCompanyResult
+
+	err := r.store.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		results, err = r.repo.CreateBatch(ctx, b)
+		if err != nil {
+			return fmt.Errorf("repo.CreateBatch: %w", err)
+		}
+
+		for _, res := range results {
+			if res.Err != nil {
+				continue
+			}
+
+			changes := domain.CompanyChanges(nil, res.Company)
+			if err := r.store.AddEntry(ctx, domain.InsertEventActionType, res.Company.ID, changes); err != nil {
+				return fmt.Errorf("failed to add insert audit entry: %w", err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// Delete implements domain.CompanyRepository
+func (r *wrapper) Delete(ctx context.Context, id uuid.UUID, d domain.DeleteCompany) error {
+	return r.store.WithTx(ctx, func(ctx context.Context) error {
+		// the deleted company may be purged
+		before, err := r.store.Lock(ctx, id, d.Purge)
+		if err != nil {
+			return err
+		}
+
+		if err := r.repo.Delete(ctx, id, d); err != nil {
+			return fmt.Errorf("repo.Delete: %w", err)
+		}
+
+		action := d.Action()
+		changes := domain.CompanyChanges(&before, domain.Company{})
+		if err := r.store.AddEntry(ctx, action, id, changes); err != nil {
+			return fmt.Errorf("failed to add %s audit entry: %w", action, err)
+		}
+
+		return nil
+	})
+}
+
+// Export implements domain.CompanyRepository
+func (r *wrapper) Export(ctx context.Context, q domain.ExportCompanies, fn func(domain.Company) error) error {
+	return r.repo.Export(ctx, q, fn)
+}
+
+// GetByID implements domain.CompanyRepository
+func (r *wrapper) GetByID(ctx context.Context, id uuid.UUID) (domain.Company, error) {
+	return r.repo.GetByID(ctx, id)
+}
+
+// GetByIDAsOf implements domain.CompanyRepository
+func (r *wrapper) GetByIDAsOf(ctx context.Context, id uuid.UUID, at time.Time) (domain.Company, error) {
+	return r.repo.GetByIDAsOf(ctx, id, at)
+}
+
+// List implements domain.CompanyRepository
+func (r *wrapper) List(ctx context.Context, q domain.ListCompanies) (domain.CompanyPage, error) {
+	return r.repo.List(ctx, q)
+}
+
+// Search implements domain.CompanyRepository
+func (r *wrapper) Search(ctx context.Context, q domain.SearchCompanies) ([]domain.CompanySearchResult, error) {
+	return r.repo.Search(ctx, q)
+}
+
+// Patch implements domain.CompanyRepository
+func (r *wrapper) Patch(ctx context.Context, id uuid.UUID, c domain.PatchCompany) (domain.Company, error) {
+	var company domain.Company
+
+	err := r.store.WithTx(ctx, func(ctx context.Context) error {
+		before, err := r.store.Lock(ctx, id, false)
+		if err != nil {
+			return err
+		}
+
+		company, err = r.repo.Patch(ctx, id, c)
+		if err != nil {
+			return fmt.Errorf("repo.Patch: %w", err)
+		}
+
+		changes := domain.CompanyChanges(&before, company)
+		if err := r.store.AddEntry(ctx, domain.UpdateEventActionType, id, changes); err != nil {
+			return fmt.Errorf("failed to add update audit entry: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return domain.Company{}, err
+	}
+
+	return company, nil
+}
+
+// Restore implements domain.CompanyRepository
+func (r *wrapper) Restore(ctx context.Context, id uuid.UUID) (domain.Company, error) {
+	var company domain.Company
+
+	err := r.store.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		company, err = r.repo.Restore(ctx, id)
+		if err != nil {
+			return fmt.Errorf("repo.Restore: %w", err)
+		}
+
+		if err := r.store.AddEntry(ctx, domain.RestoreEventActionType, id, nil); err != nil {
+			return fmt.Errorf("failed to add restore audit entry: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return domain.Company{}, err
+	}
+
+	return company, nil
+}
+
+// NewWrapper wraps repo so that every mutation records its domain.CompanyAuditEntry in store atomically,
+// the actor is taken from the context of the mutation.
+// repo must run its queries in the transaction carried by the context, as the SQL company repositories do.
+func NewWrapper(store Store, repo domain.CompanyRepository) domain.CompanyRepository {
+	return &wrapper{
+		store: store,
+		repo:  repo,
+	}
+}
@@ -0,0 +1,264 @@
+// Package cache serves the companies read by ID from an in-process LRU
+package cache
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/AlisskaPie/project-xm/internal/config"
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+)
+
+// cacheKey identifies the cached company, the same ID may be missing in the other tenants
+type cacheKey struct {
+	tenant string
+	id     uuid.UUID
+}
+
+// newCacheKey returns the key of the company id of the tenant of ctx
+func newCacheKey(ctx context.Context, id uuid.UUID) cacheKey {
+	return cacheKey{tenant: domain.TenantFromContext(ctx), id: id}
+}
+
+// cacheEntry is the cached result of GetByID, err is the not found error of the missing company
+type cacheEntry struct {
+	key     cacheKey
+	company domain.Company
+	err     error
+	expires time.Time
+}
+
+// wrapper caches the companies read by ID in a bounded LRU.
+// The mutations made in this process invalidate the cached companies, those of the other processes
+// are seen once the entries expire.
+type wrapper struct {
+	repo domain.CompanyRepository
+	conf config.Cache
+	now  func() time.Time
+
+	mu      sync.Mutex
+	entries map[cacheKey]*list.Element
+	// lru holds the *cacheEntry, the most recently used at the front
+	lru *list.List
+	// generation is incremented by every invalidation, so that GetByID doesn't cache
+	// the company read before a concurrent mutation
+	generation uint64
+
+	hits   prometheus.Counter
+	misses prometheus.Counter
+}
+
+// Create implements domain.CompanyRepository
+func (r *wrapper) Create(ctx context.Context, c domain.CreateCompany) (domain.Company, error) {
+	company, err := r.repo.Create(ctx, c)
+	if err != nil {
+		return company, fmt.Errorf("repo.Create: %w", err)
+	}
+
+	// the company may be cached as missing
+	r.invalidateTx(ctx, company.ID)
+
+	return company, nil
+}
+
+// CreateBatch implements domain.CompanyRepository
+func (r *wrapper) CreateBatch(ctx context.Context, b domain.CreateCompanies) ([]domain.CreateCompanyResult, error) {
+	results, err := r.repo.CreateBatch(ctx, b)
+	if err != nil {
+		return results, fmt.Errorf("repo.CreateBatch: %w", err)
+	}
+
+	for _, res := range results {
+		if res.Err == nil {
+			r.invalidateTx(ctx, res.Company.ID)
+		}
+	}
+
+	return results, nil
+}
+
+// Delete implements domain.CompanyRepository
+func (r *wrapper) Delete(ctx context.Context, id uuid.UUID, d domain.DeleteCompany) error {
+	// the company is invalidated even if the delete fails, as it might have been deleted by another process
+	defer r.invalidateTx(ctx, id)
+
+	if err := r.repo.Delete(ctx, id, d); err != nil {
+		return fmt.Errorf("repo.Delete: %w", err)
+	}
+
+	return nil
+}
+
+// Export implements domain.CompanyRepository
+func (r *wrapper) Export(ctx context.Context, q domain.ExportCompanies, fn func(domain.Company) error) error {
+	return r.repo.Export(ctx, q, fn)
+}
+
+// GetByID implements domain.CompanyRepository
+func (r *wrapper) GetByID(ctx context.Context, id uuid.UUID) (domain.Company, error) {
+	// the transaction reads its own changes, they must not be cached before the commit
+	if domain.InTx(ctx) {
+		return r.repo.GetByID(ctx, id)
+	}
+
+	key := newCacheKey(ctx, id)
+
+	r.mu.Lock()
+	if e, ok := r.entries[key]; ok {
+		entry := e.Value.(*cacheEntry)
+		if r.now().Before(entry.expires) {
+			r.lru.MoveToFront(e)
+			r.mu.Unlock()
+			r.hits.Inc()
+
+			return entry.company, entry.err
+		}
+
+		r.remove(e)
+	}
+	generation := r.generation
+	r.mu.Unlock()
+	r.misses.Inc()
+
+	company, err := r.repo.GetByID(ctx, id)
+
+	ttl := r.conf.TTL
+	if errors.Is(err, domain.ErrNotFound) {
+		ttl = r.conf.NegativeTTL
+	} else if err != nil {
+		return company, err
+	}
+
+	if ttl > 0 {
+		r.add(generation, &cacheEntry{
+			key:     key,
+			company: company,
+			err:     err,
+			expires: r.now().Add(ttl),
+		})
+	}
+
+	return company, err
+}
+
+// GetByIDAsOf implements domain.CompanyRepository
+func (r *wrapper) GetByIDAsOf(ctx context.Context, id uuid.UUID, at time.Time) (domain.Company, error) {
+	return r.repo.GetByIDAsOf(ctx, id, at)
+}
+
+// List implements domain.CompanyRepository
+func (r *wrapper) List(ctx context.Context, q domain.ListCompanies) (domain.CompanyPage, error) {
+	return r.repo.List(ctx, q)
+}
+
+// Search implements domain.CompanyRepository
+func (r *wrapper) Search(ctx context.Context, q domain.SearchCompanies) ([]domain.CompanySearchResult, error) {
+	return r.repo.Search(ctx, q)
+}
+
+// Patch implements domain.CompanyRepository
+func (r *wrapper) Patch(ctx context.Context, id uuid.UUID, c domain.PatchCompany) (domain.Company, error) {
+	// the company is invalidated even if the patch fails, e.g. its version shows the cached one is stale
+	defer r.invalidateTx(ctx, id)
+
+	company, err := r.repo.Patch(ctx, id, c)
+	if err != nil {
+		return company, fmt.Errorf("repo.Patch: %w", err)
+	}
+
+	return company, nil
+}
+
+// Restore implements domain.CompanyRepository
+func (r *wrapper) Restore(ctx context.Context, id uuid.UUID) (domain.Company, error) {
+	defer r.invalidateTx(ctx, id)
+
+	company, err := r.repo.Restore(ctx, id)
+	if err != nil {
+		return company, fmt.Errorf("repo.Restore: %w", err)
+	}
+
+	return company, nil
+}
+
+// add caches the entry unless the cache was invalidated since the generation, evicting the least recently used entry
+// if the cache is full
+func (r *wrapper) add(generation uint64, entry *cacheEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if generation != r.generation {
+		return
+	}
+
+	if e, ok := r.entries[entry.key]; ok {
+		r.remove(e)
+	}
+	for r.lru.Len() >= r.conf.Size && r.lru.Len() > 0 {
+		r.remove(r.lru.Back())
+	}
+
+	r.entries[entry.key] = r.lru.PushFront(entry)
+}
+
+// invalidate removes the company from the cache
+func (r *wrapper) invalidate(key cacheKey) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.generation++
+	if e, ok := r.entries[key]; ok {
+		r.remove(e)
+	}
+}
+
+// invalidateTx invalidates the company at once and again once the transaction carried by ctx is committed,
+// as the company may be cached by the reads made before the commit
+func (r *wrapper) invalidateTx(ctx context.Context, id uuid.UUID) {
+	key := newCacheKey(ctx, id)
+	r.invalidate(key)
+
+	if domain.InTx(ctx) {
+		_ = domain.AfterCommit(ctx, func(context.Context) error {
+			r.invalidate(key)
+			return nil
+		})
+	}
+}
+
+// remove removes the element of the entry, it must be called with the lock held
+func (r *wrapper) remove(e *list.Element) {
+	r.lru.Remove(e)
+	delete(r.entries, e.Value.(*cacheEntry).key)
+}
+
+// NewWrapper wraps repo so that GetByID is served from the cache configured by conf,
+// its hit and miss counters are registered in reg
+func NewWrapper(repo domain.CompanyRepository, conf config.Cache, reg prometheus.Registerer) domain.CompanyRepository {
+	hits := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "company_cache_hits_total",
+		Help: "Number of the companies read by ID from the cache.",
+	})
+	misses := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "company_cache_misses_total",
+		Help: "Number of the companies read by ID from the repository as they were not cached.",
+	})
+	reg.MustRegister(hits, misses)
+
+	return &wrapper{
+		repo:    repo,
+		conf:    conf,
+		now:     time.Now,
+		entries: map[cacheKey]*list.Element{},
+		lru:     list.New(),
+		hits:    hits,
+		misses:  misses,
+	}
+}
@@ -0,0 +1,265 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/AlisskaPie/project-xm/internal/config"
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+	"github.com/AlisskaPie/project-xm/pkg/domain/mocks"
+)
+
+var testUUID = uuid.MustParse("10000000-0000-0000-0000-000000000000")
+
+var testCacheConfig = config.Cache{
+	Enabled:     true,
+	Size:        2,
+	TTL:         time.Minute,
+	NegativeTTL: time.Second,
+}
+
+// newTestWrapper returns the cache wrapper of m whose clock is advanced by the returned func
+func newTestWrapper(m *mocks.CompanyRepository, conf config.Cache) (*wrapper, func(time.Duration)) {
+	w := NewWrapper(m, conf, prometheus.NewRegistry()).(*wrapper)
+
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	w.now = func() time.Time { return now }
+
+	return w, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestWrapper_GetByIDHit(t *testing.T) {
+	company := domain.Company{ID: testUUID, Name: "1", Version: 1}
+
+	m := &mocks.CompanyRepository{}
+	m.On("GetByID", mock.Anything, testUUID).Return(company, nil).Once()
+	w, _ := newTestWrapper(m, testCacheConfig)
+
+	for i := 0; i < 2; i++ {
+		got, err := w.GetByID(context.TODO(), testUUID)
+		assert.NoError(t, err)
+		assert.Equal(t, company, got)
+	}
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(w.hits))
+	assert.Equal(t, float64(1), testutil.ToFloat64(w.misses))
+	m.AssertExpectations(t)
+}
+
+func TestWrapper_GetByIDExpired(t *testing.T) {
+	company := domain.Company{ID: testUUID, Name: "1", Version: 1}
+
+	m := &mocks.CompanyRepository{}
+	m.On("GetByID", mock.Anything, testUUID).Return(company, nil).Twice()
+	w, advance := newTestWrapper(m, testCacheConfig)
+
+	_, err := w.GetByID(context.TODO(), testUUID)
+	assert.NoError(t, err)
+
+	advance(testCacheConfig.TTL)
+	_, err = w.GetByID(context.TODO(), testUUID)
+	assert.NoError(t, err)
+
+	assert.Equal(t, float64(0), testutil.ToFloat64(w.hits))
+	assert.Equal(t, float64(2), testutil.ToFloat64(w.misses))
+	m.AssertExpectations(t)
+}
+
+func TestWrapper_GetByIDNotFound(t *testing.T) {
+	notFound := domain.NewError(domain.ErrNotFound, "company is not found", nil)
+
+	m := &mocks.CompanyRepository{}
+	m.On("GetByID", mock.Anything, testUUID).Return(domain.Company{}, notFound).Twice()
+	w, advance := newTestWrapper(m, testCacheConfig)
+
+	for i := 0; i < 2; i++ {
+		_, err := w.GetByID(context.TODO(), testUUID)
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	}
+	assert.Equal(t, float64(1), testutil.ToFloat64(w.hits))
+
+	advance(testCacheConfig.NegativeTTL)
+	_, err := w.GetByID(context.TODO(), testUUID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(w.misses))
+	m.AssertExpectations(t)
+}
+
+func TestWrapper_GetByIDNotFoundDisabled(t *testing.T) {
+	conf := testCacheConfig
+	conf.NegativeTTL = 0
+
+	m := &mocks.CompanyRepository{}
+	m.On("GetByID", mock.Anything, testUUID).Return(domain.Company{}, domain.ErrNotFound).Twice()
+	w, _ := newTestWrapper(m, conf)
+
+	for i := 0; i < 2; i++ {
+		_, err := w.GetByID(context.TODO(), testUUID)
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	}
+
+	m.AssertExpectations(t)
+}
+
+func TestWrapper_GetByIDError(t *testing.T) {
+	m := &mocks.CompanyRepository{}
+	m.On("GetByID", mock.Anything, testUUID).Return(domain.Company{}, errors.New("error")).Twice()
+	w, _ := newTestWrapper(m, testCacheConfig)
+
+	for i := 0; i < 2; i++ {
+		_, err := w.GetByID(context.TODO(), testUUID)
+		assert.Error(t, err)
+	}
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(w.misses))
+	m.AssertExpectations(t)
+}
+
+func TestWrapper_GetByIDEvicted(t *testing.T) {
+	ids := []uuid.UUID{uuid.New(), uuid.New(), uuid.New()}
+
+	m := &mocks.CompanyRepository{}
+	for _, id := range ids {
+		m.On("GetByID", mock.Anything, id).Return(domain.Company{ID: id}, nil)
+	}
+	w, _ := newTestWrapper(m, testCacheConfig)
+
+	// ids[1] is the least recently used once ids[0] is read again
+	for _, id := range []uuid.UUID{ids[0], ids[1], ids[0], ids[2]} {
+		_, err := w.GetByID(context.TODO(), id)
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, testCacheConfig.Size, w.lru.Len())
+	assert.Contains(t, w.entries, cacheKey{id: ids[0]})
+	assert.NotContains(t, w.entries, cacheKey{id: ids[1]})
+	assert.Contains(t, w.entries, cacheKey{id: ids[2]})
+}
+
+func TestWrapper_PatchInvalidates(t *testing.T) {
+	company := domain.Company{ID: testUUID, Name: "1", Version: 1}
+	patched := domain.Company{ID: testUUID, Name: "2", Version: 2}
+	name := "2"
+
+	m := &mocks.CompanyRepository{}
+	m.On("GetByID", mock.Anything, testUUID).Return(company, nil).Once()
+	m.On("Patch", mock.Anything, testUUID, domain.PatchCompany{Name: &name}).Return(patched, nil)
+	m.On("GetByID", mock.Anything, testUUID).Return(patched, nil).Once()
+	w, _ := newTestWrapper(m, testCacheConfig)
+
+	_, err := w.GetByID(context.TODO(), testUUID)
+	assert.NoError(t, err)
+
+	_, err = w.Patch(context.TODO(), testUUID, domain.PatchCompany{Name: &name})
+	assert.NoError(t, err)
+
+	got, err := w.GetByID(context.TODO(), testUUID)
+	assert.NoError(t, err)
+	assert.Equal(t, patched, got)
+
+	m.AssertExpectations(t)
+}
+
+func TestWrapper_DeleteError(t *testing.T) {
+	m := &mocks.CompanyRepository{}
+	m.On("GetByID", mock.Anything, testUUID).Return(domain.Company{ID: testUUID}, nil).Once()
+	m.On("Delete", mock.Anything, testUUID, domain.DeleteCompany{}).Return(errors.New("error"))
+	w, _ := newTestWrapper(m, testCacheConfig)
+
+	_, err := w.GetByID(context.TODO(), testUUID)
+	assert.NoError(t, err)
+
+	err = w.Delete(context.TODO(), testUUID, domain.DeleteCompany{})
+	assert.Error(t, err)
+	assert.NotContains(t, w.entries, cacheKey{id: testUUID})
+
+	m.AssertExpectations(t)
+}
+
+func TestWrapper_CreateInvalidatesNotFound(t *testing.T) {
+	m := &mocks.CompanyRepository{}
+	m.On("GetByID", mock.Anything, testUUID).Return(domain.Company{}, domain.ErrNotFound).Once()
+	m.On("Create", mock.Anything, domain.CreateCompany{Name: "1"}).Return(domain.Company{ID: testUUID, Name: "1"}, nil)
+	w, _ := newTestWrapper(m, testCacheConfig)
+
+	_, err := w.GetByID(context.TODO(), testUUID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	_, err = w.Create(context.TODO(), domain.CreateCompany{Name: "1"})
+	assert.NoError(t, err)
+	assert.NotContains(t, w.entries, cacheKey{id: testUUID})
+
+	m.AssertExpectations(t)
+}
+
+func TestWrapper_GetByIDRacingPatch(t *testing.T) {
+	m := &mocks.CompanyRepository{}
+	w, _ := newTestWrapper(m, testCacheConfig)
+
+	// the company is patched while it's read, so the company read may be stale
+	m.On("GetByID", mock.Anything, testUUID).Return(domain.Company{ID: testUUID}, nil).Run(func(mock.Arguments) {
+		w.invalidate(cacheKey{id: testUUID})
+	})
+
+	_, err := w.GetByID(context.TODO(), testUUID)
+	assert.NoError(t, err)
+	assert.NotContains(t, w.entries, cacheKey{id: testUUID})
+}
+
+func TestWrapper_InTx(t *testing.T) {
+	company := domain.Company{ID: testUUID, Name: "1", Version: 1}
+
+	m := &mocks.CompanyRepository{}
+	m.On("GetByID", mock.Anything, testUUID).Return(company, nil).Twice()
+	m.On("Delete", mock.Anything, testUUID, domain.DeleteCompany{}).Return(nil)
+	w, _ := newTestWrapper(m, testCacheConfig)
+
+	// the uncommitted reads are not cached
+	txCtx, afterCommit := domain.ContextWithTx(context.TODO())
+	_, err := w.GetByID(txCtx, testUUID)
+	assert.NoError(t, err)
+	assert.NotContains(t, w.entries, cacheKey{id: testUUID})
+
+	// the company read before the commit is invalidated once the delete is committed
+	assert.NoError(t, w.Delete(txCtx, testUUID, domain.DeleteCompany{}))
+	_, err = w.GetByID(context.TODO(), testUUID)
+	assert.NoError(t, err)
+	assert.Contains(t, w.entries, cacheKey{id: testUUID})
+
+	assert.NoError(t, afterCommit(context.TODO()))
+	assert.NotContains(t, w.entries, cacheKey{id: testUUID})
+
+	m.AssertExpectations(t)
+}
+
+func TestWrapper_Tenants(t *testing.T) {
+	company := domain.Company{ID: testUUID, Name: "1", Version: 1}
+	acme := domain.ContextWithTenant(context.TODO(), "acme")
+	other := domain.ContextWithTenant(context.TODO(), "other")
+
+	m := &mocks.CompanyRepository{}
+	m.On("GetByID", acme, testUUID).Return(company, nil).Once()
+	m.On("GetByID", other, testUUID).Return(domain.Company{}, domain.ErrNotFound).Once()
+	w, _ := newTestWrapper(m, testCacheConfig)
+
+	// the company cached for its tenant is not served to the other one
+	for i := 0; i < 2; i++ {
+		got, err := w.GetByID(acme, testUUID)
+		assert.NoError(t, err)
+		assert.Equal(t, company, got)
+
+		_, err = w.GetByID(other, testUUID)
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	}
+
+	m.AssertExpectations(t)
+}
@@ -0,0 +1,144 @@
+// Package eventsender sends the events of the company mutations of the repositories without an outbox
+package eventsender
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+
+	"github.com/google/uuid"
+)
+
+type wrapper struct {
+	repo        domain.CompanyRepository
+	eventSender domain.CompanyEventSender
+}
+
+// Create implements domain.CompanyRepository
+func (r *wrapper) Create(ctx context.Context, c domain.CreateCompany) (domain.Company, error) {
+	company, err := r.repo.Create(ctx, c)
+	if err != nil {
+		return company, fmt.Errorf("repo.Create: %w", err)
+	}
+
+	event := domain.NewCompanyEvent(ctx, domain.InsertEventActionType, company.ID, company)
+	if err := r.send(ctx, event, "insert"); err != nil {
+		return domain.Company{}, err
+	}
+
+	return company, nil
+}
+
+// CreateBatch implements domain.CompanyRepository
+func (r *wrapper) CreateBatch(
+	ctx context.Context,
+	b domain.CreateCompanies,
+) ([]domain.CreateCompanyResult, error) {
+	results, err := r.repo.CreateBatch(ctx, b)
+	if err != nil {
+		return results, fmt.Errorf("repo.CreateBatch: %w", err)
+	}
+
+	for _, res := range results {
+		if res.Err != nil {
+			continue
+		}
+
+		event := domain.NewCompanyEvent(ctx, domain.InsertEventActionType, res.Company.ID, res.Company)
+		if err := r.send(ctx, event, "insert"); err != nil {
+			return nil, err
+		}
+	}
+
+	return results, nil
+}
+
+// Delete implements domain.CompanyRepository
+func (r *wrapper) Delete(ctx context.Context, id uuid.UUID, d domain.DeleteCompany) error {
+	if err := r.repo.Delete(ctx, id, d); err != nil {
+		return fmt.Errorf("repo.Delete: %w", err)
+	}
+
+	event := domain.NewCompanyEvent(ctx, d.Action(), id, domain.Company{})
+	if err := r.send(ctx, event, string(event.Action)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Export implements domain.CompanyRepository
+func (r *wrapper) Export(ctx context.Context, q domain.ExportCompanies, fn func(domain.Company) error) error {
+	return r.repo.Export(ctx, q, fn)
+}
+
+// GetByID implements domain.CompanyRepository
+func (r *wrapper) GetByID(ctx context.Context, id uuid.UUID) (domain.Company, error) {
+	return r.repo.GetByID(ctx, id)
+}
+
+// GetByIDAsOf implements domain.CompanyRepository
+func (r *wrapper) GetByIDAsOf(ctx context.Context, id uuid.UUID, at time.Time) (domain.Company, error) {
+	return r.repo.GetByIDAsOf(ctx, id, at)
+}
+
+// List implements domain.CompanyRepository
+func (r *wrapper) List(ctx context.Context, q domain.ListCompanies) (domain.CompanyPage, error) {
+	return r.repo.List(ctx, q)
+}
+
+// Search implements domain.CompanyRepository
+func (r *wrapper) Search(ctx context.Context, q domain.SearchCompanies) ([]domain.CompanySearchResult, error) {
+	return r.repo.Search(ctx, q)
+}
+
+// Patch implements domain.CompanyRepository
+func (r *wrapper) Patch(ctx context.Context, id uuid.UUID, c domain.PatchCompany) (domain.Company, error) {
+	company, err := r.repo.Patch(ctx, id, c)
+	if err != nil {
+		return company, fmt.Errorf("repo.Patch: %w", err)
+	}
+
+	event := domain.NewCompanyEvent(ctx, domain.UpdateEventActionType, id, company)
+	if err := r.send(ctx, event, "patch"); err != nil {
+		return domain.Company{}, err
+	}
+
+	return company, nil
+}
+
+// Restore implements domain.CompanyRepository
+func (r *wrapper) Restore(ctx context.Context, id uuid.UUID) (domain.Company, error) {
+	company, err := r.repo.Restore(ctx, id)
+	if err != nil {
+		return company, fmt.Errorf("repo.Restore: %w", err)
+	}
+
+	event := domain.NewCompanyEvent(ctx, domain.RestoreEventActionType, id, company)
+	if err := r.send(ctx, event, "restore"); err != nil {
+		return domain.Company{}, err
+	}
+
+	return company, nil
+}
+
+// send sends the event once the transaction carried by ctx is committed, or at once if there is none,
+// so that the events of the rolled back changes are not sent
+func (r *wrapper) send(ctx context.Context, event domain.CompanyEvent, action string) error {
+	return domain.AfterCommit(ctx, func(ctx context.Context) error {
+		if err := r.eventSender.Send(ctx, event); err != nil {
+			return fmt.Errorf("failed to send %s event: %w", action, err)
+		}
+		return nil
+	})
+}
+
+// NewWrapper wraps repo so that the events of its mutations are sent by eventSender right after them
+func NewWrapper(repo domain.CompanyRepository, eventSender domain.CompanyEventSender) domain.CompanyRepository {
+	return &wrapper{
+		eventSender: eventSender,
+		repo:        repo,
+	}
+}
@@ -0,0 +1,189 @@
+package eventsender
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+	"github.com/AlisskaPie/project-xm/pkg/domain/mocks"
+)
+
+var testUUID = uuid.MustParse("10000000-0000-0000-0000-000000000000")
+
+// matchEvent matches a new event equal to want except for its generated ID and time
+func matchEvent(want domain.CompanyEvent) any {
+	return mock.MatchedBy(func(e domain.CompanyEvent) bool {
+		return e.EventID != uuid.Nil && !e.Time.IsZero() &&
+			e.Action == want.Action && e.ID == want.ID && e.State == want.State
+	})
+}
+
+func TestWrapper_CreateSuccess(t *testing.T) {
+	createCompany := domain.CreateCompany{
+		Name:              "1",
+		Description:       "2",
+		AmountOfEmployees: 3,
+		Registered:        true,
+		CompanyType:       domain.CooperativeType,
+	}
+	createdCompany := domain.Company{
+		ID:                testUUID,
+		Name:              "1",
+		Description:       "2",
+		AmountOfEmployees: 3,
+		Registered:        true,
+		CompanyType:       domain.CooperativeType,
+		Version:           1,
+	}
+
+	m := &mocks.CompanyRepository{}
+	m.On("Create", mock.Anything, createCompany).Return(createdCompany, nil)
+
+	e := &mocks.CompanyEventSender{}
+	e.On("Send", mock.Anything, matchEvent(domain.CompanyEvent{
+		Action: domain.InsertEventActionType,
+		ID:     testUUID,
+		State:  createdCompany,
+	})).Return(nil)
+	w := NewWrapper(m, e)
+
+	company, err := w.Create(context.TODO(), createCompany)
+	assert.NoError(t, err)
+	assert.Equal(t, createdCompany, company)
+
+	m.AssertExpectations(t)
+	e.AssertExpectations(t)
+}
+
+func TestWrapper_DeleteSuccess(t *testing.T) {
+	m := &mocks.CompanyRepository{}
+	m.On("Delete", mock.Anything, testUUID, domain.DeleteCompany{}).Return(nil)
+
+	e := &mocks.CompanyEventSender{}
+	e.On("Send", mock.Anything, matchEvent(domain.CompanyEvent{
+		Action: domain.DeleteEventActionType,
+		ID:     testUUID,
+	})).Return(nil)
+	w := NewWrapper(m, e)
+
+	err := w.Delete(context.TODO(), testUUID, domain.DeleteCompany{})
+	assert.NoError(t, err)
+
+	m.AssertExpectations(t)
+	e.AssertExpectations(t)
+}
+
+func TestWrapper_PurgeSuccess(t *testing.T) {
+	m := &mocks.CompanyRepository{}
+	m.On("Delete", mock.Anything, testUUID, domain.DeleteCompany{Purge: true}).Return(nil)
+
+	e := &mocks.CompanyEventSender{}
+	e.On("Send", mock.Anything, matchEvent(domain.CompanyEvent{
+		Action: domain.PurgeEventActionType,
+		ID:     testUUID,
+	})).Return(nil)
+	w := NewWrapper(m, e)
+
+	err := w.Delete(context.TODO(), testUUID, domain.DeleteCompany{Purge: true})
+	assert.NoError(t, err)
+
+	m.AssertExpectations(t)
+	e.AssertExpectations(t)
+}
+
+func TestWrapper_RestoreSuccess(t *testing.T) {
+	restoredCompany := domain.Company{
+		ID:          testUUID,
+		Name:        "1",
+		CompanyType: domain.CooperativeType,
+		Version:     3,
+	}
+
+	m := &mocks.CompanyRepository{}
+	m.On("Restore", mock.Anything, testUUID).Return(restoredCompany, nil)
+
+	e := &mocks.CompanyEventSender{}
+	e.On("Send", mock.Anything, matchEvent(domain.CompanyEvent{
+		Action: domain.RestoreEventActionType,
+		ID:     testUUID,
+		State:  restoredCompany,
+	})).Return(nil)
+	w := NewWrapper(m, e)
+
+	company, err := w.Restore(context.TODO(), testUUID)
+	assert.NoError(t, err)
+	assert.Equal(t, restoredCompany, company)
+
+	m.AssertExpectations(t)
+	e.AssertExpectations(t)
+}
+
+func TestWrapper_PatchSuccess(t *testing.T) {
+	testPatchCompany := domain.PatchCompany{
+		Name:              getPointer("1"),
+		Description:       getPointer("2"),
+		AmountOfEmployees: getPointer(uint32(3)),
+		Registered:        getPointer(true),
+		CompanyType:       getPointer(domain.CooperativeType),
+	}
+	testExpCompany := domain.Company{
+		Name:              "1",
+		Description:       "2",
+		AmountOfEmployees: 3,
+		Registered:        true,
+		CompanyType:       domain.CooperativeType,
+	}
+	m := &mocks.CompanyRepository{}
+	m.On("Patch", mock.Anything, testUUID, testPatchCompany).
+		Return(domain.Company{
+			Name:              "1",
+			Description:       "2",
+			AmountOfEmployees: 3,
+			Registered:        true,
+			CompanyType:       domain.CooperativeType,
+		}, nil)
+
+	e := &mocks.CompanyEventSender{}
+	e.On("Send", mock.Anything, matchEvent(domain.CompanyEvent{
+		Action: domain.UpdateEventActionType,
+		ID:     testUUID,
+		State:  testExpCompany,
+	})).Return(nil)
+	w := NewWrapper(m, e)
+
+	_, err := w.Patch(context.TODO(), testUUID, testPatchCompany)
+	assert.NoError(t, err)
+
+	m.AssertExpectations(t)
+	e.AssertExpectations(t)
+}
+
+func TestWrapper_SendAfterCommit(t *testing.T) {
+	m := &mocks.CompanyRepository{}
+	m.On("Delete", mock.Anything, testUUID, domain.DeleteCompany{}).Return(nil)
+
+	e := &mocks.CompanyEventSender{}
+	w := NewWrapper(m, e)
+
+	txCtx, afterCommit := domain.ContextWithTx(context.TODO())
+	assert.NoError(t, w.Delete(txCtx, testUUID, domain.DeleteCompany{}))
+	e.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+
+	e.On("Send", mock.Anything, matchEvent(domain.CompanyEvent{
+		Action: domain.DeleteEventActionType,
+		ID:     testUUID,
+	})).Return(nil)
+	assert.NoError(t, afterCommit(context.TODO()))
+
+	m.AssertExpectations(t)
+	e.AssertExpectations(t)
+}
+
+// Generic to get pointer
+func getPointer[T any](value T) *T {
+	return &value
+}
@@ -0,0 +1,96 @@
+// Package keyset encodes the keyset pagination cursors of the company lists of the SQL repositories
+package keyset
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+)
+
+// SortColumns whitelists the columns to sort the companies by
+var SortColumns = map[domain.CompanySortField]string{
+	domain.SortByName:              "name",
+	domain.SortByAmountOfEmployees: "amount_of_employees",
+	domain.SortByID:                "id",
+}
+
+// cursor is the keyset position after the last company of the page
+type cursor struct {
+	SortBy domain.CompanySortField `json:"s"`
+	Desc   bool                    `json:"d,omitempty"`
+	Value  json.RawMessage         `json:"v,omitempty"`
+	ID     uuid.UUID               `json:"i"`
+}
+
+// NewCursor encodes the position after the last company of the page sorted as q
+func NewCursor(q domain.ListCompanies, last domain.Company) (string, error) {
+	c := cursor{
+		SortBy: q.SortBy,
+		Desc:   q.Desc,
+		ID:     last.ID,
+	}
+
+	var value any
+	switch q.SortBy {
+	case domain.SortByName:
+		value = last.Name
+	case domain.SortByAmountOfEmployees:
+		value = last.AmountOfEmployees
+	}
+
+	if value != nil {
+		b, err := json.Marshal(value)
+		if err != nil {
+			return "", fmt.Errorf("json.Marshal: %w", err)
+		}
+		c.Value = b
+	}
+
+	b, err := json.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("json.Marshal: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// ParseCursor decodes the cursor of q and returns the sort column value it points at,
+// the cursor must be created for the same sorting as q
+func ParseCursor(q domain.ListCompanies) (any, uuid.UUID, error) {
+	invalid := domain.NewError(domain.ErrBadRequest, "invalid cursor", nil)
+
+	b, err := base64.RawURLEncoding.DecodeString(q.Cursor)
+	if err != nil {
+		return nil, uuid.Nil, invalid
+	}
+
+	var c cursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, uuid.Nil, invalid
+	}
+
+	if c.SortBy != q.SortBy || c.Desc != q.Desc {
+		return nil, uuid.Nil, invalid
+	}
+
+	var value any
+	switch c.SortBy {
+	case domain.SortByName:
+		var name string
+		err = json.Unmarshal(c.Value, &name)
+		value = name
+	case domain.SortByAmountOfEmployees:
+		var amount uint32
+		err = json.Unmarshal(c.Value, &amount)
+		value = amount
+	}
+	if err != nil {
+		return nil, uuid.Nil, invalid
+	}
+
+	return value, c.ID, nil
+}
@@ -0,0 +1,64 @@
+package keyset
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+)
+
+var testUUID = uuid.MustParse("10000000-0000-0000-0000-000000000000")
+
+func TestCursor(t *testing.T) {
+	last := domain.Company{ID: testUUID, Name: "a", AmountOfEmployees: 10}
+	tests := []struct {
+		name      string
+		q         domain.ListCompanies
+		wantValue any
+	}{
+		{
+			name:      "Name",
+			q:         domain.ListCompanies{SortBy: domain.SortByName},
+			wantValue: "a",
+		},
+		{
+			name:      "AmountOfEmployees",
+			q:         domain.ListCompanies{SortBy: domain.SortByAmountOfEmployees, Desc: true},
+			wantValue: uint32(10),
+		},
+		{
+			name: "ID",
+			q:    domain.ListCompanies{SortBy: domain.SortByID},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewCursor(tt.q, last)
+			require.NoError(t, err)
+
+			tt.q.Cursor = c
+			value, id, err := ParseCursor(tt.q)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantValue, value)
+			assert.Equal(t, testUUID, id)
+		})
+	}
+}
+
+func TestParseCursor_Invalid(t *testing.T) {
+	c, err := NewCursor(domain.ListCompanies{SortBy: domain.SortByName}, domain.Company{ID: testUUID, Name: "a"})
+	require.NoError(t, err)
+
+	for _, q := range []domain.ListCompanies{
+		{SortBy: domain.SortByName, Cursor: "!"},
+		{SortBy: domain.SortByName, Cursor: "e30"},
+		{SortBy: domain.SortByName, Desc: true, Cursor: c},
+		{SortBy: domain.SortByID, Cursor: c},
+	} {
+		_, _, err := ParseCursor(q)
+		assert.Equal(t, domain.NewError(domain.ErrBadRequest, "invalid cursor", nil), err)
+	}
+}
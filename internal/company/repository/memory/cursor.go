@@ -0,0 +1,102 @@
+package memory
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+)
+
+// sortFields whitelists the fields to sort the companies by
+var sortFields = map[domain.CompanySortField]struct{}{
+	domain.SortByName:              {},
+	domain.SortByAmountOfEmployees: {},
+	domain.SortByID:                {},
+}
+
+// cursor is the keyset position after the last company of the page
+type cursor struct {
+	SortBy domain.CompanySortField `json:"s"`
+	Desc   bool                    `json:"d,omitempty"`
+	Name   string                  `json:"n,omitempty"`
+	Amount uint32                  `json:"a,omitempty"`
+	ID     uuid.UUID               `json:"i"`
+}
+
+// less tells whether a goes before b sorted by the field and then by ID, in the descending order if desc is set
+func less(a, b domain.Company, by domain.CompanySortField, desc bool) bool {
+	if desc {
+		a, b = b, a
+	}
+
+	switch by {
+	case domain.SortByName:
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+	case domain.SortByAmountOfEmployees:
+		if a.AmountOfEmployees != b.AmountOfEmployees {
+			return a.AmountOfEmployees < b.AmountOfEmployees
+		}
+	}
+
+	return a.ID.String() < b.ID.String()
+}
+
+func sortCompanies(companies []domain.Company, by domain.CompanySortField, desc bool) {
+	sort.Slice(companies, func(i, j int) bool {
+		return less(companies[i], companies[j], by, desc)
+	})
+}
+
+func newCursor(q domain.ListCompanies, last domain.Company) (string, error) {
+	c := cursor{
+		SortBy: q.SortBy,
+		Desc:   q.Desc,
+		ID:     last.ID,
+	}
+
+	switch q.SortBy {
+	case domain.SortByName:
+		c.Name = last.Name
+	case domain.SortByAmountOfEmployees:
+		c.Amount = last.AmountOfEmployees
+	}
+
+	b, err := json.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("json.Marshal: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// parseCursor decodes the cursor into the company holding the sort field value it points at,
+// the cursor must be created for the same sorting as q
+func parseCursor(q domain.ListCompanies) (domain.Company, error) {
+	invalid := domain.NewError(domain.ErrBadRequest, "invalid cursor", nil)
+
+	b, err := base64.RawURLEncoding.DecodeString(q.Cursor)
+	if err != nil {
+		return domain.Company{}, invalid
+	}
+
+	var c cursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return domain.Company{}, invalid
+	}
+
+	if c.SortBy != q.SortBy || c.Desc != q.Desc {
+		return domain.Company{}, invalid
+	}
+
+	return domain.Company{
+		ID:                c.ID,
+		Name:              c.Name,
+		AmountOfEmployees: c.Amount,
+	}, nil
+}
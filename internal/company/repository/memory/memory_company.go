@@ -0,0 +1,535 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+)
+
+// Limits of the company columns in postgres
+const (
+	maxNameLength        = 15
+	maxDescriptionLength = 3000
+)
+
+// company is owned by the tenant, it's seen only by the calls with the tenant in the context
+type company struct {
+	domain.Company
+	tenant  string
+	deleted bool
+}
+
+// version is the state of the company between validFrom and validTo, validTo is zero for the current state
+type version struct {
+	company   domain.Company
+	tenant    string
+	validFrom time.Time
+	validTo   time.Time
+}
+
+// auditEntry is the audit entry of the company of the tenant
+type auditEntry struct {
+	domain.CompanyAuditEntry
+	tenant string
+}
+
+// companyRepository keeps the companies, their history and audit entries in memory
+// as the postgres repositories with the audit wrapper do
+type companyRepository struct {
+	mu                 sync.RWMutex
+	uniqueNamesPerType bool
+	companies          map[uuid.UUID]*company
+	versions           map[uuid.UUID][]version
+	audit              []auditEntry
+	now                func() time.Time
+}
+
+// Create implements domain.CompanyRepository
+func (r *companyRepository) Create(ctx context.Context, c domain.CreateCompany) (domain.Company, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	company, err := r.newCompany(ctx, c, nil)
+	if err != nil {
+		return domain.Company{}, err
+	}
+
+	r.insert(ctx, company)
+
+	return company, nil
+}
+
+// CreateBatch implements domain.CompanyRepository
+func (r *companyRepository) CreateBatch(
+	ctx context.Context,
+	b domain.CreateCompanies,
+) ([]domain.CreateCompanyResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	results := make([]domain.CreateCompanyResult, len(b.Companies))
+	// the companies of the batch conflict with each other as with the stored ones
+	var batch []domain.Company
+	for i, c := range b.Companies {
+		company, err := r.newCompany(ctx, c, batch)
+		if err != nil && !errors.Is(err, domain.ErrConflict) {
+			return nil, err
+		}
+		if err != nil {
+			if b.Mode == domain.AllOrNothingBatchMode {
+				return nil, domain.NewError(domain.ErrConflict, fmt.Sprintf("company %d or its name already exists", i), nil)
+			}
+
+			results[i].Err = domain.NewError(domain.ErrConflict, "company or its name already exists", nil)
+			continue
+		}
+
+		batch = append(batch, company)
+		results[i].Company = company
+	}
+
+	for _, company := range batch {
+		r.insert(ctx, company)
+	}
+
+	return results, nil
+}
+
+// Delete implements domain.CompanyRepository
+func (r *companyRepository) Delete(ctx context.Context, id uuid.UUID, d domain.DeleteCompany) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.lookup(ctx, id)
+	if !ok || (c.deleted && !d.Purge) {
+		return domain.NewError(domain.ErrNotFound, "company does not exist", nil)
+	}
+	if d.Version != nil && *d.Version != c.Version {
+		return domain.NewError(domain.ErrPreconditionFailed, fmt.Sprintf("company version is %d", c.Version), nil)
+	}
+
+	before := c.Company
+	action := domain.DeleteEventActionType
+	if d.Purge {
+		action = domain.PurgeEventActionType
+		delete(r.companies, id)
+	} else {
+		c.deleted = true
+		c.Version++
+	}
+
+	r.addVersion(ctx, id, nil)
+	r.addAuditEntry(ctx, action, id, domain.CompanyChanges(&before, domain.Company{}))
+
+	return nil
+}
+
+// Export implements domain.CompanyRepository.
+// The companies are copied before fn is called, so fn may use the repository.
+func (r *companyRepository) Export(ctx context.Context, q domain.ExportCompanies, fn func(domain.Company) error) error {
+	companies := r.find(ctx, func(c domain.Company) bool {
+		return (q.CompanyType == nil || c.CompanyType == *q.CompanyType) &&
+			(q.Registered == nil || c.Registered == *q.Registered)
+	})
+	sortCompanies(companies, domain.SortByID, false)
+
+	for _, c := range companies {
+		if err := fn(c); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetByID implements domain.CompanyRepository
+func (r *companyRepository) GetByID(ctx context.Context, id uuid.UUID) (domain.Company, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	c, ok := r.lookup(ctx, id)
+	if !ok || c.deleted {
+		return domain.Company{}, domain.NewError(domain.ErrNotFound, "company does not exist", nil)
+	}
+
+	return c.Company, nil
+}
+
+// GetByIDAsOf implements domain.CompanyRepository
+func (r *companyRepository) GetByIDAsOf(ctx context.Context, id uuid.UUID, at time.Time) (domain.Company, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tenant := domain.TenantFromContext(ctx)
+	for _, v := range r.versions[id] {
+		if v.tenant == tenant && !v.validFrom.After(at) && (v.validTo.IsZero() || v.validTo.After(at)) {
+			return v.company, nil
+		}
+	}
+
+	return domain.Company{}, domain.NewError(domain.ErrNotFound, "company did not exist at that time", nil)
+}
+
+// History implements domain.CompanyAuditRepository
+func (r *companyRepository) History(ctx context.Context, q domain.CompanyHistory) (domain.CompanyHistoryPage, error) {
+	if q.Limit <= 0 {
+		q.Limit = domain.DefaultListLimit
+	}
+
+	var last int64
+	if q.Cursor != "" {
+		// the cursor is the ID of the last entry of the previous page
+		var err error
+		last, err = strconv.ParseInt(q.Cursor, 10, 64)
+		if err != nil {
+			return domain.CompanyHistoryPage{}, domain.NewError(domain.ErrBadRequest, "invalid cursor", nil)
+		}
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	page := domain.CompanyHistoryPage{
+		Entries: []domain.CompanyAuditEntry{},
+	}
+	tenant := domain.TenantFromContext(ctx)
+	for i := len(r.audit) - 1; i >= 0; i-- {
+		e := r.audit[i]
+		if e.tenant != tenant || e.CompanyID != q.CompanyID || (q.Cursor != "" && e.ID >= last) {
+			continue
+		}
+
+		if len(page.Entries) == q.Limit {
+			page.NextCursor = strconv.FormatInt(page.Entries[len(page.Entries)-1].ID, 10)
+			break
+		}
+		page.Entries = append(page.Entries, e.CompanyAuditEntry)
+	}
+
+	return page, nil
+}
+
+// List implements domain.CompanyRepository
+func (r *companyRepository) List(ctx context.Context, q domain.ListCompanies) (domain.CompanyPage, error) {
+	if _, ok := sortFields[q.SortBy]; !ok {
+		return domain.CompanyPage{}, domain.NewError(domain.ErrBadRequest, "unknown sort field", nil)
+	}
+	if q.Limit <= 0 {
+		q.Limit = domain.DefaultListLimit
+	}
+
+	var after *domain.Company
+	if q.Cursor != "" {
+		c, err := parseCursor(q)
+		if err != nil {
+			return domain.CompanyPage{}, err
+		}
+		after = &c
+	}
+
+	prefix := strings.ToLower(q.NamePrefix)
+	companies := r.find(ctx, func(c domain.Company) bool {
+		return (q.CompanyType == nil || c.CompanyType == *q.CompanyType) &&
+			(q.Registered == nil || c.Registered == *q.Registered) &&
+			(q.MinEmployees == nil || c.AmountOfEmployees >= *q.MinEmployees) &&
+			(q.MaxEmployees == nil || c.AmountOfEmployees <= *q.MaxEmployees) &&
+			strings.HasPrefix(strings.ToLower(c.Name), prefix) &&
+			(after == nil || less(*after, c, q.SortBy, q.Desc))
+	})
+	sortCompanies(companies, q.SortBy, q.Desc)
+
+	page := domain.CompanyPage{
+		Companies: companies,
+	}
+	if len(companies) > q.Limit {
+		page.Companies = companies[:q.Limit]
+
+		var err error
+		page.NextCursor, err = newCursor(q, page.Companies[q.Limit-1])
+		if err != nil {
+			return domain.CompanyPage{}, fmt.Errorf("newCursor: %w", err)
+		}
+	}
+
+	return page, nil
+}
+
+// Search implements domain.CompanyRepository
+func (r *companyRepository) Search(ctx context.Context, q domain.SearchCompanies) ([]domain.CompanySearchResult, error) {
+	terms := parseSearch(q.Query)
+	if len(terms) == 0 {
+		return nil, domain.NewError(domain.ErrBadRequest, "search query has no words", nil)
+	}
+	if q.Limit <= 0 {
+		q.Limit = domain.DefaultListLimit
+	}
+
+	results := []domain.CompanySearchResult{}
+	for _, c := range r.find(ctx, func(domain.Company) bool { return true }) {
+		rank, ok := match(terms, c)
+		if !ok {
+			continue
+		}
+
+		results = append(results, domain.CompanySearchResult{
+			Company: c,
+			Rank:    rank,
+			Snippet: snippet(terms, c.Description),
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Rank != results[j].Rank {
+			return results[i].Rank > results[j].Rank
+		}
+		return results[i].Company.ID.String() < results[j].Company.ID.String()
+	})
+	if len(results) > q.Limit {
+		results = results[:q.Limit]
+	}
+
+	return results, nil
+}
+
+// Patch implements domain.CompanyRepository
+func (r *companyRepository) Patch(ctx context.Context, id uuid.UUID, c domain.PatchCompany) (domain.Company, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.lookup(ctx, id)
+	if !ok || stored.deleted {
+		return domain.Company{}, domain.NewError(domain.ErrNotFound, "company does not exist", nil)
+	}
+	if c.Version != nil && *c.Version != stored.Version {
+		return domain.Company{}, domain.NewError(
+			domain.ErrPreconditionFailed, fmt.Sprintf("company version is %d", stored.Version), nil)
+	}
+
+	before := stored.Company
+	after := stored.Company
+	if c.Name != nil {
+		after.Name = *c.Name
+	}
+	if c.Description != nil {
+		after.Description = *c.Description
+	}
+	if c.AmountOfEmployees != nil {
+		after.AmountOfEmployees = *c.AmountOfEmployees
+	}
+	if c.Registered != nil {
+		after.Registered = *c.Registered
+	}
+	if c.CompanyType != nil {
+		after.CompanyType = *c.CompanyType
+	}
+	if c.Name != nil || c.Description != nil || c.AmountOfEmployees != nil || c.Registered != nil || c.CompanyType != nil {
+		after.Version++
+	}
+
+	if err := validate(after); err != nil {
+		return domain.Company{}, err
+	}
+	if err := r.checkName(stored.tenant, after, nil); err != nil {
+		return domain.Company{}, err
+	}
+
+	stored.Company = after
+	r.addVersion(ctx, id, &after)
+	r.addAuditEntry(ctx, domain.UpdateEventActionType, id, domain.CompanyChanges(&before, after))
+
+	return after, nil
+}
+
+// Restore implements domain.CompanyRepository
+func (r *companyRepository) Restore(ctx context.Context, id uuid.UUID) (domain.Company, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.lookup(ctx, id)
+	if !ok || !stored.deleted {
+		return domain.Company{}, domain.NewError(domain.ErrNotFound, "deleted company does not exist", nil)
+	}
+	if err := r.checkName(stored.tenant, stored.Company, nil); err != nil {
+		return domain.Company{}, err
+	}
+
+	stored.deleted = false
+	stored.Version++
+	r.addVersion(ctx, id, &stored.Company)
+	r.addAuditEntry(ctx, domain.RestoreEventActionType, id, nil)
+
+	return stored.Company, nil
+}
+
+// newCompany checks the company can be created by the tenant of ctx along with the batch ones,
+// its ID is generated if it's missing. It must be called with the lock held.
+func (r *companyRepository) newCompany(
+	ctx context.Context,
+	c domain.CreateCompany,
+	batch []domain.Company,
+) (domain.Company, error) {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+
+	company := domain.Company{
+		ID:                c.ID,
+		Name:              c.Name,
+		Description:       c.Description,
+		AmountOfEmployees: c.AmountOfEmployees,
+		Registered:        c.Registered,
+		CompanyType:       c.CompanyType,
+		Version:           1,
+	}
+	if err := validate(company); err != nil {
+		return domain.Company{}, err
+	}
+
+	// the IDs are unique across the tenants as the postgres primary key
+	if _, ok := r.companies[c.ID]; ok {
+		return domain.Company{}, domain.NewError(domain.ErrConflict, "company already exists", nil)
+	}
+	for _, b := range batch {
+		if b.ID == c.ID {
+			return domain.Company{}, domain.NewError(domain.ErrConflict, "company already exists", nil)
+		}
+	}
+
+	if err := r.checkName(domain.TenantFromContext(ctx), company, batch); err != nil {
+		return domain.Company{}, err
+	}
+
+	return company, nil
+}
+
+// insert stores the checked new company of the tenant of ctx, it must be called with the lock held
+func (r *companyRepository) insert(ctx context.Context, c domain.Company) {
+	r.companies[c.ID] = &company{Company: c, tenant: domain.TenantFromContext(ctx)}
+	r.addVersion(ctx, c.ID, &c)
+	r.addAuditEntry(ctx, domain.InsertEventActionType, c.ID, domain.CompanyChanges(nil, c))
+}
+
+// checkName fails if another company of the tenant, stored and not deleted or of the batch, has the name of c
+// ignoring case. It must be called with the lock held.
+func (r *companyRepository) checkName(tenant string, c domain.Company, batch []domain.Company) error {
+	taken := func(other domain.Company) bool {
+		return other.ID != c.ID && strings.EqualFold(other.Name, c.Name) &&
+			(!r.uniqueNamesPerType || other.CompanyType == c.CompanyType)
+	}
+
+	for _, other := range r.companies {
+		if other.tenant == tenant && !other.deleted && taken(other.Company) {
+			return domain.NewError(domain.ErrConflict, fmt.Sprintf("company name is taken by %s", other.ID), nil)
+		}
+	}
+	for _, other := range batch {
+		if taken(other) {
+			return domain.NewError(domain.ErrConflict, "company name is already taken", nil)
+		}
+	}
+
+	return nil
+}
+
+// addVersion ends the current version of the company of the tenant of ctx and starts the new one unless c is nil.
+// It must be called with the lock held.
+func (r *companyRepository) addVersion(ctx context.Context, id uuid.UUID, c *domain.Company) {
+	now := r.now().UTC()
+
+	versions := r.versions[id]
+	if n := len(versions); n > 0 && versions[n-1].validTo.IsZero() {
+		versions[n-1].validTo = now
+	}
+	if c != nil {
+		versions = append(versions, version{company: *c, tenant: domain.TenantFromContext(ctx), validFrom: now})
+	}
+
+	r.versions[id] = versions
+}
+
+// addAuditEntry records the action on the company by the actor carried by ctx, it must be called with the lock held
+func (r *companyRepository) addAuditEntry(
+	ctx context.Context,
+	action domain.EventActionType,
+	id uuid.UUID,
+	changes []domain.FieldChange,
+) {
+	if changes == nil {
+		changes = []domain.FieldChange{}
+	}
+
+	r.audit = append(r.audit, auditEntry{
+		CompanyAuditEntry: domain.CompanyAuditEntry{
+			ID:        int64(len(r.audit) + 1),
+			CompanyID: id,
+			Action:    action,
+			Actor:     domain.ActorFromContext(ctx),
+			Time:      r.now().UTC(),
+			Changes:   changes,
+		},
+		tenant: domain.TenantFromContext(ctx),
+	})
+}
+
+// lookup returns the company of the tenant of ctx, deleted or not, it must be called with the lock held
+func (r *companyRepository) lookup(ctx context.Context, id uuid.UUID) (*company, bool) {
+	c, ok := r.companies[id]
+	if !ok || c.tenant != domain.TenantFromContext(ctx) {
+		return nil, false
+	}
+
+	return c, true
+}
+
+// find returns the copies of the not deleted companies of the tenant of ctx matching the filter
+func (r *companyRepository) find(ctx context.Context, filter func(domain.Company) bool) []domain.Company {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tenant := domain.TenantFromContext(ctx)
+	res := []domain.Company{}
+	for _, c := range r.companies {
+		if c.tenant == tenant && !c.deleted && filter(c.Company) {
+			res = append(res, c.Company)
+		}
+	}
+
+	return res
+}
+
+// validate checks the constraints of the company table
+func validate(c domain.Company) error {
+	if utf8.RuneCountInString(c.Name) > maxNameLength || utf8.RuneCountInString(c.Description) > maxDescriptionLength {
+		return domain.NewError(domain.ErrValidation, "value is too long", nil)
+	}
+	// the values of the companyType enum in postgres
+	if !c.CompanyType.Valid() {
+		return domain.NewError(domain.ErrValidation, "value is not allowed", nil)
+	}
+
+	return nil
+}
+
+// NewCompanyRepository creates the in-memory domain.CompanyRepository and the domain.CompanyAuditRepository
+// of its companies, for the development without a database. Its data is lost with the process.
+// The company names are unique in the tenant ignoring case, per type if uniqueNamesPerType is set.
+func NewCompanyRepository(uniqueNamesPerType bool) (domain.CompanyRepository, domain.CompanyAuditRepository) {
+	r := &companyRepository{
+		uniqueNamesPerType: uniqueNamesPerType,
+		companies:          map[uuid.UUID]*company{},
+		versions:           map[uuid.UUID][]version{},
+		now:                time.Now,
+	}
+
+	return r, r
+}
@@ -0,0 +1,329 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/AlisskaPie/project-xm/internal/company/repository/repotest"
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+)
+
+var testUUID = uuid.MustParse("10000000-0000-0000-0000-000000000000")
+
+func getPointer[T any](v T) *T {
+	return &v
+}
+
+func newTestRepository(t *testing.T) *companyRepository {
+	repo, _ := NewCompanyRepository(false)
+	r, ok := repo.(*companyRepository)
+	require.True(t, ok)
+
+	now := time.Date(2022, 10, 1, 0, 0, 0, 0, time.UTC)
+	r.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	return r
+}
+
+func testCreateCompany(id uuid.UUID, name string) domain.CreateCompany {
+	return domain.CreateCompany{
+		ID:                id,
+		Name:              name,
+		Description:       "open source data",
+		AmountOfEmployees: 10,
+		Registered:        true,
+		CompanyType:       domain.CorporationsType,
+	}
+}
+
+func TestMemoryCompanyCreate(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.TODO()
+
+	created, err := r.Create(ctx, testCreateCompany(testUUID, "Acme"))
+	require.NoError(t, err)
+	assert.Equal(t, domain.Company{
+		ID:                testUUID,
+		Name:              "Acme",
+		Description:       "open source data",
+		AmountOfEmployees: 10,
+		Registered:        true,
+		CompanyType:       domain.CorporationsType,
+		Version:           1,
+	}, created)
+
+	got, err := r.GetByID(ctx, testUUID)
+	require.NoError(t, err)
+	assert.Equal(t, created, got)
+
+	generated, err := r.Create(ctx, testCreateCompany(uuid.Nil, "Globex"))
+	require.NoError(t, err)
+	assert.NotEqual(t, uuid.Nil, generated.ID)
+
+	_, err = r.Create(ctx, testCreateCompany(testUUID, "Initech"))
+	assert.Equal(t, domain.NewError(domain.ErrConflict, "company already exists", nil), err)
+
+	_, err = r.Create(ctx, testCreateCompany(uuid.Nil, "ACME"))
+	assert.Equal(t, domain.NewError(domain.ErrConflict, "company name is taken by "+testUUID.String(), nil), err)
+
+	_, err = r.Create(ctx, testCreateCompany(uuid.Nil, "Name is too long"))
+	assert.Equal(t, domain.NewError(domain.ErrValidation, "value is too long", nil), err)
+
+	_, err = r.GetByID(ctx, uuid.New())
+	assert.Equal(t, domain.NewError(domain.ErrNotFound, "company does not exist", nil), err)
+}
+
+func TestMemoryCompanyCreate_UniqueNamesPerType(t *testing.T) {
+	repo, _ := NewCompanyRepository(true)
+	ctx := context.TODO()
+
+	_, err := repo.Create(ctx, testCreateCompany(uuid.Nil, "Acme"))
+	require.NoError(t, err)
+
+	other := testCreateCompany(uuid.Nil, "acme")
+	other.CompanyType = domain.NonProfitType
+	_, err = repo.Create(ctx, other)
+	assert.NoError(t, err)
+}
+
+func TestMemoryCompanyCreateBatch(t *testing.T) {
+	secondUUID := uuid.MustParse("20000000-0000-0000-0000-000000000000")
+	batch := []domain.CreateCompany{
+		testCreateCompany(testUUID, "Acme"),
+		testCreateCompany(secondUUID, "acme"),
+	}
+
+	t.Run("AllOrNothing", func(t *testing.T) {
+		r := newTestRepository(t)
+
+		_, err := r.CreateBatch(context.TODO(), domain.CreateCompanies{
+			Companies: batch,
+			Mode:      domain.AllOrNothingBatchMode,
+		})
+		assert.Equal(t, domain.NewError(domain.ErrConflict, "company 1 or its name already exists", nil), err)
+
+		_, err = r.GetByID(context.TODO(), testUUID)
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	})
+
+	t.Run("BestEffort", func(t *testing.T) {
+		r := newTestRepository(t)
+
+		results, err := r.CreateBatch(context.TODO(), domain.CreateCompanies{
+			Companies: batch,
+			Mode:      domain.BestEffortBatchMode,
+		})
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+		assert.NoError(t, results[0].Err)
+		assert.Equal(t, testUUID, results[0].Company.ID)
+		assert.Equal(t, domain.NewError(domain.ErrConflict, "company or its name already exists", nil), results[1].Err)
+
+		_, err = r.GetByID(context.TODO(), testUUID)
+		assert.NoError(t, err)
+	})
+}
+
+func TestMemoryCompanyPatch(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.TODO()
+
+	_, err := r.Create(ctx, testCreateCompany(testUUID, "Acme"))
+	require.NoError(t, err)
+
+	patched, err := r.Patch(ctx, testUUID, domain.PatchCompany{
+		Description: getPointer("closed source"),
+		Version:     getPointer(int64(1)),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "closed source", patched.Description)
+	assert.Equal(t, int64(2), patched.Version)
+
+	got, err := r.GetByID(ctx, testUUID)
+	require.NoError(t, err)
+	assert.Equal(t, patched, got)
+
+	_, err = r.Patch(ctx, testUUID, domain.PatchCompany{Registered: getPointer(false), Version: getPointer(int64(1))})
+	assert.Equal(t, domain.NewError(domain.ErrPreconditionFailed, "company version is 2", nil), err)
+
+	_, err = r.Patch(ctx, testUUID, domain.PatchCompany{CompanyType: getPointer(domain.CompanyType("Partnership"))})
+	assert.Equal(t, domain.NewError(domain.ErrValidation, "value is not allowed", nil), err)
+
+	_, err = r.Patch(ctx, uuid.New(), domain.PatchCompany{Registered: getPointer(false)})
+	assert.Equal(t, domain.NewError(domain.ErrNotFound, "company does not exist", nil), err)
+}
+
+func TestMemoryCompanyDeleteRestore(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.TODO()
+
+	_, err := r.Create(ctx, testCreateCompany(testUUID, "Acme"))
+	require.NoError(t, err)
+
+	require.NoError(t, r.Delete(ctx, testUUID, domain.DeleteCompany{}))
+	_, err = r.GetByID(ctx, testUUID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	// the deleted company doesn't hold its name
+	taken, err := r.Create(ctx, testCreateCompany(uuid.Nil, "ACME"))
+	require.NoError(t, err)
+
+	_, err = r.Restore(ctx, testUUID)
+	assert.Equal(t, domain.NewError(domain.ErrConflict, "company name is taken by "+taken.ID.String(), nil), err)
+
+	require.NoError(t, r.Delete(ctx, taken.ID, domain.DeleteCompany{}))
+	restored, err := r.Restore(ctx, testUUID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), restored.Version)
+
+	_, err = r.Restore(ctx, testUUID)
+	assert.Equal(t, domain.NewError(domain.ErrNotFound, "deleted company does not exist", nil), err)
+
+	require.NoError(t, r.Delete(ctx, testUUID, domain.DeleteCompany{Purge: true}))
+	err = r.Delete(ctx, testUUID, domain.DeleteCompany{Purge: true})
+	assert.Equal(t, domain.NewError(domain.ErrNotFound, "company does not exist", nil), err)
+}
+
+func TestMemoryCompanyList(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.TODO()
+
+	for i, name := range []string{"Delta", "alpha", "Charlie", "bravo", "Echo"} {
+		c := testCreateCompany(uuid.Nil, name)
+		c.AmountOfEmployees = uint32(i)
+		_, err := r.Create(ctx, c)
+		require.NoError(t, err)
+	}
+
+	q := domain.ListCompanies{SortBy: domain.SortByAmountOfEmployees, Desc: true, Limit: 2}
+	var names []string
+	for {
+		page, err := r.List(ctx, q)
+		require.NoError(t, err)
+		for _, c := range page.Companies {
+			names = append(names, c.Name)
+		}
+
+		if page.NextCursor == "" {
+			break
+		}
+		q.Cursor = page.NextCursor
+	}
+	assert.Equal(t, []string{"Echo", "bravo", "Charlie", "alpha", "Delta"}, names)
+
+	page, err := r.List(ctx, domain.ListCompanies{SortBy: domain.SortByName, NamePrefix: "C", MaxEmployees: getPointer(uint32(3))})
+	require.NoError(t, err)
+	require.Len(t, page.Companies, 1)
+	assert.Equal(t, "Charlie", page.Companies[0].Name)
+
+	_, err = r.List(ctx, domain.ListCompanies{SortBy: domain.SortByName, Cursor: q.Cursor})
+	assert.Equal(t, domain.NewError(domain.ErrBadRequest, "invalid cursor", nil), err)
+
+	_, err = r.List(ctx, domain.ListCompanies{SortBy: "description"})
+	assert.Equal(t, domain.NewError(domain.ErrBadRequest, "unknown sort field", nil), err)
+}
+
+func TestMemoryCompanySearch(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.TODO()
+
+	_, err := r.Create(ctx, testCreateCompany(testUUID, "Acme"))
+	require.NoError(t, err)
+	other := testCreateCompany(uuid.Nil, "Globex")
+	other.Description = "data source"
+	_, err = r.Create(ctx, other)
+	require.NoError(t, err)
+
+	results, err := r.Search(ctx, domain.SearchCompanies{Query: `"open source" dat*`})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, testUUID, results[0].Company.ID)
+	assert.Equal(t, "<mark>open</mark> <mark>source</mark> <mark>data</mark>", results[0].Snippet)
+
+	results, err = r.Search(ctx, domain.SearchCompanies{Query: "source"})
+	require.NoError(t, err)
+	assert.Len(t, results, 2)
+
+	_, err = r.Search(ctx, domain.SearchCompanies{Query: `" "`})
+	assert.ErrorIs(t, err, domain.ErrBadRequest)
+}
+
+func TestMemoryCompanyHistory(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := domain.ContextWithActor(context.TODO(), domain.Actor{Subject: "admin"})
+
+	_, err := r.Create(ctx, testCreateCompany(testUUID, "Acme"))
+	require.NoError(t, err)
+	// the fake clock ticks on each version and audit entry
+	before := time.Date(2022, 10, 1, 0, 0, 2, 0, time.UTC)
+	_, err = r.Patch(ctx, testUUID, domain.PatchCompany{AmountOfEmployees: getPointer(uint32(20))})
+	require.NoError(t, err)
+	require.NoError(t, r.Delete(ctx, testUUID, domain.DeleteCompany{}))
+
+	old, err := r.GetByIDAsOf(ctx, testUUID, before)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(10), old.AmountOfEmployees)
+
+	_, err = r.GetByIDAsOf(ctx, testUUID, time.Now())
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	page, err := r.History(ctx, domain.CompanyHistory{CompanyID: testUUID, Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, page.Entries, 2)
+	assert.Equal(t, domain.DeleteEventActionType, page.Entries[0].Action)
+	assert.Contains(t, page.Entries[0].Changes, domain.FieldChange{Field: "name", Old: "Acme", New: ""})
+	assert.Equal(t, domain.UpdateEventActionType, page.Entries[1].Action)
+	assert.Equal(t, "admin", page.Entries[1].Actor.Subject)
+	assert.Equal(t, []domain.FieldChange{{Field: "amount_of_employees", Old: uint32(10), New: uint32(20)}},
+		page.Entries[1].Changes)
+
+	page, err = r.History(ctx, domain.CompanyHistory{CompanyID: testUUID, Limit: 2, Cursor: page.NextCursor})
+	require.NoError(t, err)
+	require.Len(t, page.Entries, 1)
+	assert.Equal(t, domain.InsertEventActionType, page.Entries[0].Action)
+	assert.Empty(t, page.NextCursor)
+}
+
+func TestMemoryCompanyConcurrent(t *testing.T) {
+	repo, _ := NewCompanyRepository(false)
+	ctx := context.TODO()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			c, err := repo.Create(ctx, testCreateCompany(uuid.Nil, fmt.Sprintf("Company %d", i)))
+			if !assert.NoError(t, err) {
+				return
+			}
+			_, err = repo.Patch(ctx, c.ID, domain.PatchCompany{Registered: getPointer(false)})
+			assert.NoError(t, err)
+			_, err = repo.List(ctx, domain.ListCompanies{SortBy: domain.SortByName})
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	page, err := repo.List(ctx, domain.ListCompanies{SortBy: domain.SortByName, Limit: domain.MaxListLimit})
+	require.NoError(t, err)
+	assert.Len(t, page.Companies, 20)
+}
+
+func TestMemoryCompanyRepository(t *testing.T) {
+	repotest.TestCompanyRepository(t, func(t *testing.T) domain.CompanyRepository {
+		repo, _ := NewCompanyRepository(false)
+		return repo
+	})
+}
@@ -0,0 +1,142 @@
+package memory
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+)
+
+// nameWeight is the rank of a term found in the name relative to the one found in the description
+const nameWeight = 2.5
+
+// term is the word of the search query, or the phrase of adjacent words if quoted,
+// the last word matches as a prefix if prefix is set
+type term struct {
+	words  []string
+	prefix bool
+}
+
+// parseSearch splits the query into the terms the companies must all match,
+// it approximates the web search syntax of the postgres repository
+func parseSearch(query string) []term {
+	var terms []term
+	for i, part := range strings.Split(query, `"`) {
+		// the odd parts are inside the quotes
+		if i%2 == 1 {
+			if words := splitWords(part); len(words) > 0 {
+				terms = append(terms, term{words: words})
+			}
+			continue
+		}
+
+		for _, field := range strings.Fields(part) {
+			words := splitWords(field)
+			if len(words) == 0 {
+				continue
+			}
+			terms = append(terms, term{words: words, prefix: strings.HasSuffix(field, "*")})
+		}
+	}
+
+	return terms
+}
+
+// match tells whether the name or the description of the company has all the terms, and ranks it
+func match(terms []term, c domain.Company) (float64, bool) {
+	name := splitWords(c.Name)
+	description := splitWords(c.Description)
+
+	var rank float64
+	for _, t := range terms {
+		inName, inDescription := t.count(name), t.count(description)
+		if inName == 0 && inDescription == 0 {
+			return 0, false
+		}
+		rank += nameWeight*float64(inName) + float64(inDescription)
+	}
+
+	return rank / float64(1+len(name)+len(description)), true
+}
+
+// snippet highlights the words of the description matched by the terms
+func snippet(terms []term, description string) string {
+	var b strings.Builder
+	start := -1
+	flush := func(end int) {
+		word := description[start:end]
+		if matchesAny(terms, strings.ToLower(word)) {
+			b.WriteString("<mark>" + word + "</mark>")
+		} else {
+			b.WriteString(word)
+		}
+		start = -1
+	}
+
+	for i, r := range description {
+		if isWordRune(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			flush(i)
+		}
+		b.WriteRune(r)
+	}
+	if start >= 0 {
+		flush(len(description))
+	}
+
+	return b.String()
+}
+
+// count returns the number of the term occurrences in the words
+func (t term) count(words []string) int {
+	n := 0
+	for i := 0; i+len(t.words) <= len(words); i++ {
+		if t.matchAt(words[i:]) {
+			n++
+		}
+	}
+
+	return n
+}
+
+func (t term) matchAt(words []string) bool {
+	for i, w := range t.words {
+		if t.prefix && i == len(t.words)-1 {
+			if !strings.HasPrefix(words[i], w) {
+				return false
+			}
+			continue
+		}
+		if words[i] != w {
+			return false
+		}
+	}
+
+	return true
+}
+
+func matchesAny(terms []term, word string) bool {
+	for _, t := range terms {
+		for i, w := range t.words {
+			if word == w || t.prefix && i == len(t.words)-1 && strings.HasPrefix(word, w) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// splitWords returns the lowercase words of s
+func splitWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool { return !isWordRune(r) })
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,37 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+)
+
+// txManager implements domain.TxManager for the in-memory repository. Every call of the repository is atomic
+// on its own but the units of work are not: nothing is rolled back if fn fails, only the AfterCommit funcs
+// are dropped. It's good enough for the development it's meant for.
+type txManager struct {
+	repo domain.CompanyRepository
+}
+
+// WithTx implements domain.TxManager
+func (m *txManager) WithTx(
+	ctx context.Context,
+	_ domain.TxOptions,
+	fn func(ctx context.Context, repo domain.CompanyRepository) error,
+) error {
+	if domain.InTx(ctx) {
+		return fn(ctx, m.repo)
+	}
+
+	txCtx, afterCommit := domain.ContextWithTx(ctx)
+	if err := fn(txCtx, m.repo); err != nil {
+		return err
+	}
+
+	return afterCommit(ctx)
+}
+
+// NewTxManager creates the domain.TxManager passing repo, the one of NewCompanyRepository, to the units of work
+func NewTxManager(repo domain.CompanyRepository) domain.TxManager {
+	return &txManager{repo: repo}
+}
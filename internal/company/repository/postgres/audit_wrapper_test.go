@@ -0,0 +1,162 @@
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+	"github.com/AlisskaPie/project-xm/pkg/domain/mocks"
+)
+
+func TestAuditWrapper_CreateSuccess(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	require.NoError(t, err)
+	dbMock.ExpectBegin()
+	dbMock.ExpectPrepare(`^INSERT INTO "company_audit" \("action", "actor", "changes", "company_id", "request_id", `+
+		`"tenant_id"\) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6\)$`).
+		ExpectExec().
+		WithArgs("insert", "1234567890", sqlmock.AnyArg(), testUUID.String(), "req", "acme").
+		WillReturnResult(driver.RowsAffected(1))
+	dbMock.ExpectCommit()
+
+	createCompany := domain.CreateCompany{
+		Name:        "1",
+		CompanyType: domain.CooperativeType,
+	}
+	createdCompany := domain.Company{
+		ID:          testUUID,
+		Name:        "1",
+		CompanyType: domain.CooperativeType,
+		Version:     1,
+	}
+
+	m := &mocks.CompanyRepository{}
+	m.On("Create", mock.Anything, createCompany).Return(createdCompany, nil)
+
+	ctx := domain.ContextWithActor(context.TODO(), domain.Actor{Subject: "1234567890", RequestID: "req"})
+	ctx = domain.ContextWithTenant(ctx, "acme")
+	w := NewAuditWrapper(sqlx.NewDb(db, "sqlmock"), m)
+	company, err := w.Create(ctx, createCompany)
+	assert.NoError(t, err)
+	assert.Equal(t, createdCompany, company)
+
+	m.AssertExpectations(t)
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
+
+func TestAuditWrapper_PatchSuccess(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	require.NoError(t, err)
+	dbMock.ExpectBegin()
+	dbMock.ExpectPrepare(`^SELECT (.+) FROM "company" `+
+		`WHERE \(\("deleted_at" IS NULL\) AND \("id" = \$1\) AND \("tenant_id" = \$2\)\) FOR UPDATE$`).
+		ExpectQuery().
+		WithArgs(testUUID.String(), "").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "type", "version"}).
+			AddRow(testUUID.String(), "1", domain.NonProfitType, 1))
+	dbMock.ExpectPrepare(`^INSERT INTO "company_audit" `).
+		ExpectExec().
+		WithArgs("update", "", `[{"field":"type","old":"NonProfit","new":"Cooperative"}]`, testUUID.String(), "", "").
+		WillReturnResult(driver.RowsAffected(1))
+	dbMock.ExpectCommit()
+
+	patch := domain.PatchCompany{CompanyType: getPointer(domain.CooperativeType)}
+	patchedCompany := domain.Company{
+		ID:          testUUID,
+		Name:        "1",
+		CompanyType: domain.CooperativeType,
+		Version:     2,
+	}
+
+	m := &mocks.CompanyRepository{}
+	m.On("Patch", mock.Anything, testUUID, patch).Return(patchedCompany, nil)
+
+	w := NewAuditWrapper(sqlx.NewDb(db, "sqlmock"), m)
+	company, err := w.Patch(context.TODO(), testUUID, patch)
+	assert.NoError(t, err)
+	assert.Equal(t, patchedCompany, company)
+
+	m.AssertExpectations(t)
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
+
+func TestAuditWrapper_PatchNotFound(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	require.NoError(t, err)
+	dbMock.ExpectBegin()
+	dbMock.ExpectPrepare(`^SELECT (.+) FOR UPDATE$`).
+		ExpectQuery().
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	dbMock.ExpectRollback()
+
+	m := &mocks.CompanyRepository{}
+
+	w := NewAuditWrapper(sqlx.NewDb(db, "sqlmock"), m)
+	_, err = w.Patch(context.TODO(), testUUID, domain.PatchCompany{})
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	m.AssertExpectations(t)
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
+
+func TestAuditWrapper_DeleteSuccess(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	require.NoError(t, err)
+	dbMock.ExpectBegin()
+	dbMock.ExpectPrepare(`^SELECT (.+) FROM "company" `+
+		`WHERE \(\("deleted_at" IS NULL\) AND \("id" = \$1\) AND \("tenant_id" = \$2\)\) FOR UPDATE$`).
+		ExpectQuery().
+		WithArgs(testUUID.String(), "").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "type", "version"}).
+			AddRow(testUUID.String(), "1", domain.NonProfitType, 1))
+	dbMock.ExpectPrepare(`^INSERT INTO "company_audit" `).
+		ExpectExec().
+		WithArgs("delete", "", `[{"field":"name","old":"1","new":""},{"field":"type","old":"NonProfit","new":""}]`,
+			testUUID.String(), "", "").
+		WillReturnResult(driver.RowsAffected(1))
+	dbMock.ExpectCommit()
+
+	m := &mocks.CompanyRepository{}
+	m.On("Delete", mock.Anything, testUUID, domain.DeleteCompany{}).Return(nil)
+
+	w := NewAuditWrapper(sqlx.NewDb(db, "sqlmock"), m)
+	err = w.Delete(context.TODO(), testUUID, domain.DeleteCompany{})
+	assert.NoError(t, err)
+
+	m.AssertExpectations(t)
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
+
+func TestAuditWrapper_DeleteFailed(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	require.NoError(t, err)
+	dbMock.ExpectBegin()
+	// the deleted company may be purged
+	dbMock.ExpectPrepare(`^SELECT (.+) FROM "company" WHERE \(\("id" = \$1\) AND \("tenant_id" = \$2\)\) FOR UPDATE$`).
+		ExpectQuery().
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "type", "version"}).
+			AddRow(testUUID.String(), "1", domain.NonProfitType, 2))
+	dbMock.ExpectPrepare(`^INSERT INTO "company_audit" `).
+		ExpectExec().
+		WithArgs("purge", "", sqlmock.AnyArg(), testUUID.String(), "", "").
+		WillReturnError(errors.New("test error"))
+	dbMock.ExpectRollback()
+
+	m := &mocks.CompanyRepository{}
+	m.On("Delete", mock.Anything, testUUID, domain.DeleteCompany{Purge: true}).Return(nil)
+
+	w := NewAuditWrapper(sqlx.NewDb(db, "sqlmock"), m)
+	err = w.Delete(context.TODO(), testUUID, domain.DeleteCompany{Purge: true})
+	assert.EqualError(t, err, "failed to add purge audit entry: ExecContext: test error")
+
+	m.AssertExpectations(t)
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
@@ -0,0 +1,113 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+	"github.com/rs/zerolog"
+
+	"github.com/AlisskaPie/project-xm/internal/config"
+)
+
+// Open opens the postgres database of the DSN with the pool limits and connect timeout of conf,
+// no connection is made until the first query
+func Open(dsn string, conf config.DB) (*sqlx.DB, error) {
+	dsn, err := withConnectTimeout(dsn, conf.ConnectTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sqlx.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("sqlx.Open: %w", err)
+	}
+
+	// zero keeps the database/sql defaults
+	if conf.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(conf.MaxOpenConns)
+	}
+	if conf.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(conf.MaxIdleConns)
+	}
+	db.SetConnMaxLifetime(conf.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(conf.ConnMaxIdleTime)
+
+	return db, nil
+}
+
+// Connect opens the postgres database of the DSN like Open and waits for it to accept connections.
+// The failed pings are retried with the backoff of conf.Retry for conf.StartupTimeout, so that the database
+// may start after the service, zero fails on the first error.
+func Connect(ctx context.Context, dsn string, conf config.DB, log zerolog.Logger) (*sqlx.DB, error) {
+	db, err := Open(dsn, conf)
+	if err != nil {
+		return nil, err
+	}
+
+	if conf.StartupTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, conf.StartupTimeout)
+		defer cancel()
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := db.PingContext(ctx)
+		if err == nil {
+			return db, nil
+		}
+
+		if conf.StartupTimeout > 0 {
+			wait := backoff(conf.Retry, attempt)
+			log.Warn().Err(err).Int("attempt", attempt).Dur("retry_in", wait).Msg("failed to connect to db")
+
+			if sleep(ctx, wait) == nil {
+				continue
+			}
+		}
+
+		// the error of the ping is more telling than the expired startup timeout
+		_ = db.Close()
+		return nil, fmt.Errorf("PingContext: %w", err)
+	}
+}
+
+// withConnectTimeout sets the connect_timeout of the DSN, in either the URL or the key=value form,
+// unless the DSN sets it itself. The timeout bounds both the dial and the startup of the connection.
+func withConnectTimeout(dsn string, timeout time.Duration) (string, error) {
+	if timeout <= 0 {
+		return dsn, nil
+	}
+
+	// connect_timeout is in whole seconds
+	seconds := strconv.Itoa(int(math.Ceil(timeout.Seconds())))
+
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			// the error would quote the DSN with its password
+			return "", errors.New("DSN is not a valid URL")
+		}
+
+		q := u.Query()
+		if q.Get("connect_timeout") == "" {
+			q.Set("connect_timeout", seconds)
+			u.RawQuery = q.Encode()
+		}
+
+		return u.String(), nil
+	}
+
+	if strings.Contains(dsn, "connect_timeout=") {
+		return dsn, nil
+	}
+
+	return strings.TrimSpace(dsn + " connect_timeout=" + seconds), nil
+}
@@ -0,0 +1,47 @@
+package postgres
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+
+	"github.com/lib/pq"
+
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+)
+
+// translateError converts the driver errors into the domain ones, unknown errors are returned as is
+func translateError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.NewError(domain.ErrNotFound, "company does not exist", err)
+	}
+
+	if errors.Is(err, driver.ErrBadConn) {
+		return domain.NewError(domain.ErrUnavailable, "", err)
+	}
+
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+
+	switch pqErr.Code.Name() {
+	case "unique_violation":
+		if _, ok := nameViolation(err); ok {
+			return domain.NewError(domain.ErrConflict, "company name is already taken", err)
+		}
+		return domain.NewError(domain.ErrConflict, "company already exists", err)
+	case "string_data_right_truncation":
+		return domain.NewError(domain.ErrValidation, "value is too long", err)
+	case "not_null_violation", "check_violation", "invalid_text_representation":
+		return domain.NewError(domain.ErrValidation, "value is not allowed", err)
+	case "admin_shutdown", "cannot_connect_now", "too_many_connections":
+		return domain.NewError(domain.ErrUnavailable, "", err)
+	}
+
+	if pqErr.Code.Class().Name() == "connection_exception" {
+		return domain.NewError(domain.ErrUnavailable, "", err)
+	}
+
+	return err
+}
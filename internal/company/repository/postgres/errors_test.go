@@ -0,0 +1,45 @@
+package postgres
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+)
+
+func TestTranslateError(t *testing.T) {
+	testErr := errors.New("test error")
+	tests := []struct {
+		name     string
+		err      error
+		wantKind error
+	}{
+		{name: "NoRows", err: fmt.Errorf("scan: %w", sql.ErrNoRows), wantKind: domain.ErrNotFound},
+		{name: "BadConn", err: driver.ErrBadConn, wantKind: domain.ErrUnavailable},
+		{name: "UniqueViolation", err: testPqErr, wantKind: domain.ErrConflict},
+		{name: "UniqueNameViolation", err: testNamePqErr, wantKind: domain.ErrConflict},
+		{name: "TooLong", err: &pq.Error{Code: "22001"}, wantKind: domain.ErrValidation},
+		{name: "NotNull", err: &pq.Error{Code: "23502"}, wantKind: domain.ErrValidation},
+		{name: "InvalidEnum", err: &pq.Error{Code: "22P02"}, wantKind: domain.ErrValidation},
+		{name: "TooManyConnections", err: &pq.Error{Code: "53300"}, wantKind: domain.ErrUnavailable},
+		{name: "ConnectionException", err: &pq.Error{Code: "08006"}, wantKind: domain.ErrUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := translateError(tt.err)
+			assert.ErrorIs(t, err, tt.wantKind)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+
+	t.Run("Unknown", func(t *testing.T) {
+		assert.Equal(t, testErr, translateError(testErr))
+		assert.Equal(t, error(&pq.Error{Code: "42P01"}), translateError(&pq.Error{Code: "42P01"}))
+	})
+}
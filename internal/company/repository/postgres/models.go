@@ -1,11 +1,17 @@
 package postgres
 
 import (
+	"context"
+	"time"
+
 	"github.com/AlisskaPie/project-xm/pkg/domain"
 
 	"github.com/google/uuid"
 )
 
+// companyColumns lists the columns of Company
+var companyColumns = []any{"id", "name", "description", "amount_of_employees", "registered", "type", "version"}
+
 type Company struct {
 	ID                uuid.UUID          `db:"id"`
 	Name              string             `db:"name"`
@@ -13,6 +19,34 @@ type Company struct {
 	AmountOfEmployees uint32             `db:"amount_of_employees"`
 	Registered        bool               `db:"registered"`
 	CompanyType       domain.CompanyType `db:"type"`
+	Version           int64              `db:"version" goqu:"skipinsert"`
+}
+
+// companyRow is the inserted row of the company owned by the tenant
+type companyRow struct {
+	Company
+	TenantID string `db:"tenant_id"`
+}
+
+// newCompany returns the row of the company created by the tenant of ctx
+func newCompany(ctx context.Context, c domain.CreateCompany) companyRow {
+	return companyRow{
+		Company: Company{
+			ID:                c.ID,
+			Name:              c.Name,
+			Description:       c.Description,
+			AmountOfEmployees: c.AmountOfEmployees,
+			Registered:        c.Registered,
+			CompanyType:       c.CompanyType,
+		},
+		TenantID: domain.TenantFromContext(ctx),
+	}
+}
+
+type CompanySearchResult struct {
+	Company
+	Rank    float64 `db:"rank"`
+	Snippet string  `db:"snippet"`
 }
 
 type PatchCompany struct {
@@ -22,3 +56,25 @@ type PatchCompany struct {
 	Registered        *bool               `db:"registered"`
 	CompanyType       *domain.CompanyType `db:"type"`
 }
+
+type OutboxMessage struct {
+	ID      int64  `db:"id"`
+	Payload []byte `db:"payload"`
+}
+
+type AuditEntry struct {
+	ID        int64     `db:"id"`
+	CompanyID uuid.UUID `db:"company_id"`
+	Action    string    `db:"action"`
+	Actor     string    `db:"actor"`
+	RequestID string    `db:"request_id"`
+	Changes   []byte    `db:"changes"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// AuditChange is the JSON of domain.FieldChange stored in AuditEntry.Changes
+type AuditChange struct {
+	Field string `json:"field"`
+	Old   any    `json:"old"`
+	New   any    `json:"new"`
+}
@@ -0,0 +1,210 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+)
+
+// The unique indexes of the company names of the tenant ignoring case, only one of them exists,
+// see EnsureUniqueNames
+const (
+	uniqueNameIndex        = "company_tenant_lower_name_key"
+	uniqueNamePerTypeIndex = "company_tenant_lower_name_type_key"
+)
+
+// legacyNameIndexes are the unique indexes of the names across the tenants, they are dropped by EnsureUniqueNames
+var legacyNameIndexes = []string{"company_lower_name_key", "company_lower_name_type_key"}
+
+// NameDuplicate is the group of companies of the tenant whose names differ only in case,
+// CompanyType is set if the names are unique per type
+type NameDuplicate struct {
+	TenantID    string
+	Name        string
+	CompanyType domain.CompanyType
+	IDs         []uuid.UUID
+}
+
+func (d NameDuplicate) String() string {
+	if d.CompanyType != "" {
+		return fmt.Sprintf("%q of type %s in tenant %q: %v", d.Name, d.CompanyType, d.TenantID, d.IDs)
+	}
+
+	return fmt.Sprintf("%q in tenant %q: %v", d.Name, d.TenantID, d.IDs)
+}
+
+// FindNameDuplicates lists the companies that would violate the unique names index,
+// the deleted companies don't hold their names
+func FindNameDuplicates(ctx context.Context, db *sqlx.DB, perType bool) ([]NameDuplicate, error) {
+	group := []any{goqu.C("tenant_id"), goqu.L("lower(?)", goqu.C("name"))}
+	if perType {
+		group = append(group, goqu.C("type"))
+	}
+
+	columns := []any{
+		goqu.C("tenant_id"),
+		goqu.L("lower(?)", goqu.C("name")).As("name"),
+		goqu.L("array_agg(? ORDER BY ?)", goqu.C("id"), goqu.C("id")).As("ids"),
+	}
+	if perType {
+		columns = append(columns, goqu.C("type"))
+	} else {
+		columns = append(columns, goqu.L("''").As("type"))
+	}
+
+	q, args, err := dialect.From("company").
+		Prepared(true).
+		Select(columns...).
+		Where(goqu.C("deleted_at").IsNull()).
+		GroupBy(group...).
+		Having(goqu.L("count(*) > 1")).
+		Order(goqu.C("tenant_id").Asc(), goqu.I("name").Asc()).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("cannot build query: %w", err)
+	}
+
+	rows, err := db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, fmt.Errorf("QueryContext: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	var res []NameDuplicate
+	for rows.Next() {
+		var d NameDuplicate
+		var ids []string
+		if err := rows.Scan(&d.TenantID, &d.Name, pq.Array(&ids), &d.CompanyType); err != nil {
+			return nil, fmt.Errorf("Scan: %w", err)
+		}
+
+		for _, id := range ids {
+			parsed, err := uuid.Parse(id)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse company ID: %w", err)
+			}
+			d.IDs = append(d.IDs, parsed)
+		}
+
+		res = append(res, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+
+	return res, nil
+}
+
+// EnsureUniqueNames creates the unique index of the company names of the tenant ignoring case, per type
+// if perType is set, and drops the indexes of the other scopes. It fails if the existing names are not unique,
+// see FindNameDuplicates.
+func EnsureUniqueNames(ctx context.Context, db *sqlx.DB, perType bool) error {
+	create := fmt.Sprintf(
+		"CREATE UNIQUE INDEX IF NOT EXISTS %s ON company (tenant_id, lower(name)) WHERE deleted_at IS NULL",
+		uniqueNameIndex)
+	drop := []string{uniqueNamePerTypeIndex}
+	if perType {
+		create = fmt.Sprintf(
+			"CREATE UNIQUE INDEX IF NOT EXISTS %s ON company (tenant_id, lower(name), type) WHERE deleted_at IS NULL",
+			uniqueNamePerTypeIndex)
+		drop = []string{uniqueNameIndex}
+	}
+
+	queries := []string{create}
+	for _, index := range append(drop, legacyNameIndexes...) {
+		queries = append(queries, "DROP INDEX IF EXISTS "+index)
+	}
+
+	// the index of the new scope is created before the old one is dropped, so the names are always unique
+	return withTx(ctx, db, func(ctx context.Context) error {
+		for _, q := range queries {
+			if _, err := conn(ctx, db).ExecContext(ctx, q); err != nil {
+				return fmt.Errorf("ExecContext: %w", translateError(err))
+			}
+		}
+
+		return nil
+	})
+}
+
+// nameViolation returns the unique names index violated by err
+func nameViolation(err error) (string, bool) {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code.Name() != "unique_violation" {
+		return "", false
+	}
+
+	if pqErr.Constraint != uniqueNameIndex && pqErr.Constraint != uniqueNamePerTypeIndex {
+		return "", false
+	}
+
+	return pqErr.Constraint, true
+}
+
+// nameConflict converts the violation of the unique names index into the conflict naming the company
+// that already has the name. The nil name and type are those of the company id.
+// The companies are read outside of the transaction of ctx, as the violation aborts it.
+func (r *companyRepository) nameConflict(
+	ctx context.Context,
+	err error,
+	index string,
+	id uuid.UUID,
+	name *string,
+	companyType *domain.CompanyType,
+) error {
+	conflict := translateError(err)
+
+	if name == nil || companyType == nil {
+		q, args, qErr := dialect.From("company").
+			Prepared(true).
+			Select(companyColumns...).
+			Where(goqu.C("id").Eq(id.String()), tenantScope(ctx)).
+			ToSQL()
+		if qErr != nil {
+			return conflict
+		}
+
+		var current Company
+		if err := r.db.GetContext(ctx, &current, q, args...); err != nil {
+			return conflict
+		}
+		if name == nil {
+			name = &current.Name
+		}
+		if companyType == nil {
+			companyType = &current.CompanyType
+		}
+	}
+
+	ds := dialect.From("company").
+		Prepared(true).
+		Select("id").
+		Where(
+			goqu.L("lower(?) = lower(?)", goqu.C("name"), *name),
+			goqu.C("deleted_at").IsNull(),
+			goqu.C("id").Neq(id.String()),
+			tenantScope(ctx),
+		)
+	if index == uniqueNamePerTypeIndex {
+		ds = ds.Where(goqu.C("type").Eq(string(*companyType)))
+	}
+
+	q, args, qErr := ds.Limit(1).ToSQL()
+	if qErr != nil {
+		return conflict
+	}
+
+	var existing uuid.UUID
+	if err := r.db.GetContext(ctx, &existing, q, args...); err != nil {
+		return conflict
+	}
+
+	return domain.NewError(domain.ErrConflict, fmt.Sprintf("company name is taken by %s", existing), err)
+}
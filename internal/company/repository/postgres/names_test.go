@@ -0,0 +1,134 @@
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+)
+
+func TestFindNameDuplicates(t *testing.T) {
+	testErr := errors.New("test error")
+	secondUUID := uuid.MustParse("20000000-0000-0000-0000-000000000000")
+
+	tests := []struct {
+		name    string
+		rf      registerFunc
+		perType bool
+		want    []NameDuplicate
+		wantErr error
+	}{
+		{
+			name: "Success",
+			rf: func(s sqlmock.Sqlmock) {
+				s.ExpectQuery(`^SELECT "tenant_id", lower\("name"\) AS "name", array_agg\("id" ORDER BY "id"\) AS "ids", ` +
+					`'' AS "type" FROM "company" WHERE \("deleted_at" IS NULL\) GROUP BY "tenant_id", lower\("name"\) ` +
+					`HAVING count\(\*\) > 1 ORDER BY "tenant_id" ASC, "name" ASC$`).
+					WillReturnRows(sqlmock.NewRows([]string{"tenant_id", "name", "ids", "type"}).
+						AddRow("tenant", "acme", "{"+testUUID.String()+","+secondUUID.String()+"}", ""))
+			},
+			want: []NameDuplicate{{TenantID: "tenant", Name: "acme", IDs: []uuid.UUID{testUUID, secondUUID}}},
+		},
+		{
+			name:    "PerType",
+			perType: true,
+			rf: func(s sqlmock.Sqlmock) {
+				s.ExpectQuery(`^SELECT "tenant_id", lower\("name"\) AS "name", array_agg\("id" ORDER BY "id"\) AS "ids", ` +
+					`"type" FROM "company" WHERE \("deleted_at" IS NULL\) GROUP BY "tenant_id", lower\("name"\), "type" ` +
+					`HAVING count\(\*\) > 1 ORDER BY "tenant_id" ASC, "name" ASC$`).
+					WillReturnRows(sqlmock.NewRows([]string{"tenant_id", "name", "ids", "type"}))
+			},
+		},
+		{
+			name: "Failed",
+			rf: func(s sqlmock.Sqlmock) {
+				s.ExpectQuery(`^SELECT (.+)`).
+					WillReturnError(testErr)
+			},
+			wantErr: fmt.Errorf("QueryContext: %w", testErr),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, dbMock, err := sqlmock.New()
+			require.NoError(t, err)
+			tt.rf(dbMock)
+
+			duplicates, err := FindNameDuplicates(context.TODO(), sqlx.NewDb(db, "sqlmock"), tt.perType)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, duplicates)
+			assert.NoError(t, dbMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestNameDuplicateString(t *testing.T) {
+	assert.Equal(t, `"acme" in tenant "tenant": [10000000-0000-0000-0000-000000000000]`,
+		NameDuplicate{TenantID: "tenant", Name: "acme", IDs: []uuid.UUID{testUUID}}.String())
+	assert.Equal(t, `"acme" of type NonProfit in tenant "": [10000000-0000-0000-0000-000000000000]`,
+		NameDuplicate{Name: "acme", CompanyType: domain.NonProfitType, IDs: []uuid.UUID{testUUID}}.String())
+}
+
+func TestEnsureUniqueNames(t *testing.T) {
+	for _, perType := range []bool{false, true} {
+		t.Run(fmt.Sprintf("PerType=%t", perType), func(t *testing.T) {
+			db, dbMock, err := sqlmock.New()
+			require.NoError(t, err)
+
+			create := `^CREATE UNIQUE INDEX IF NOT EXISTS company_tenant_lower_name_key ` +
+				`ON company \(tenant_id, lower\(name\)\) WHERE deleted_at IS NULL$`
+			drop := `^DROP INDEX IF EXISTS company_tenant_lower_name_type_key$`
+			if perType {
+				create = `^CREATE UNIQUE INDEX IF NOT EXISTS company_tenant_lower_name_type_key ` +
+					`ON company \(tenant_id, lower\(name\), type\) WHERE deleted_at IS NULL$`
+				drop = `^DROP INDEX IF EXISTS company_tenant_lower_name_key$`
+			}
+
+			dbMock.ExpectBegin()
+			dbMock.ExpectExec(create).WillReturnResult(driver.ResultNoRows)
+			dbMock.ExpectExec(drop).WillReturnResult(driver.ResultNoRows)
+			// the indexes of the names across the tenants
+			dbMock.ExpectExec(`^DROP INDEX IF EXISTS company_lower_name_key$`).WillReturnResult(driver.ResultNoRows)
+			dbMock.ExpectExec(`^DROP INDEX IF EXISTS company_lower_name_type_key$`).WillReturnResult(driver.ResultNoRows)
+			dbMock.ExpectCommit()
+
+			err = EnsureUniqueNames(context.TODO(), sqlx.NewDb(db, "sqlmock"), perType)
+			assert.NoError(t, err)
+			assert.NoError(t, dbMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPostgresCompanyPatch_NameConflict(t *testing.T) {
+	perTypeErr := &pq.Error{Code: "23505", Constraint: uniqueNamePerTypeIndex}
+	secondUUID := uuid.MustParse("20000000-0000-0000-0000-000000000000")
+
+	db, dbMock, err := sqlmock.New()
+	require.NoError(t, err)
+	dbMock.ExpectPrepare(`^UPDATE "company" SET (.+) RETURNING (.+)$`).
+		ExpectQuery().
+		WillReturnError(perTypeErr)
+	dbMock.ExpectQuery(`^SELECT (.+) FROM "company" WHERE \(\("id" = \$1\) AND \("tenant_id" = \$2\)\)$`).
+		WithArgs(testUUID.String(), "").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "type", "version"}).
+			AddRow(testUUID.String(), "Acme", domain.NonProfitType, 1))
+	dbMock.ExpectQuery(`^SELECT "id" FROM "company" WHERE \(lower\("name"\) = lower\(\$1\) AND \("deleted_at" IS NULL\) `+
+		`AND \("id" != \$2\) AND \("tenant_id" = \$3\) AND \("type" = \$4\)\) LIMIT \$5$`).
+		WithArgs("Acme", testUUID.String(), "", "Cooperative", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(secondUUID.String()))
+
+	r := NewCompanyRepository(context.TODO(), sqlx.NewDb(db, "sqlmock"))
+	_, err = r.Patch(context.TODO(), testUUID, domain.PatchCompany{CompanyType: getPointer(domain.CooperativeType)})
+	assert.Equal(t, domain.NewError(domain.ErrConflict, "company name is taken by "+secondUUID.String(), perTypeErr), err)
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
@@ -0,0 +1,176 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// outboxWrapper stores an event in the outbox within the same transaction as each mutation
+type outboxWrapper struct {
+	db    *sqlx.DB
+	stmts *statements
+	repo  domain.CompanyRepository
+}
+
+// Create implements domain.CompanyRepository
+func (r *outboxWrapper) Create(ctx context.Context, c domain.CreateCompany) (domain.Company, error) {
+	var company domain.Company
+
+	err := withTx(ctx, r.db, func(ctx context.Context) error {
+		var err error
+		company, err = r.repo.Create(ctx, c)
+		if err != nil {
+			return fmt.Errorf("repo.Create: %w", err)
+		}
+
+		event := domain.NewCompanyEvent(ctx, domain.InsertEventActionType, company.ID, company)
+		if err := addOutboxEvent(ctx, r.db, r.stmts, event); err != nil {
+			return fmt.Errorf("failed to add insert event: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return domain.Company{}, err
+	}
+
+	return company, nil
+}
+
+// CreateBatch implements domain.CompanyRepository
+func (r *outboxWrapper) CreateBatch(ctx context.Context, b domain.CreateCompanies) ([]domain.CreateCompanyResult, error) {
+	var results []domain.CreateCompanyResult
+
+	err := withTx(ctx, r.db, func(ctx context.Context) error {
+		var err error
+		results, err = r.repo.CreateBatch(ctx, b)
+		if err != nil {
+			return fmt.Errorf("repo.CreateBatch: %w", err)
+		}
+
+		for _, res := range results {
+			if res.Err != nil {
+				continue
+			}
+
+			event := domain.NewCompanyEvent(ctx, domain.InsertEventActionType, res.Company.ID, res.Company)
+			if err := addOutboxEvent(ctx, r.db, r.stmts, event); err != nil {
+				return fmt.Errorf("failed to add insert event: %w", err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// Delete implements domain.CompanyRepository
+func (r *outboxWrapper) Delete(ctx context.Context, id uuid.UUID, d domain.DeleteCompany) error {
+	return withTx(ctx, r.db, func(ctx context.Context) error {
+		if err := r.repo.Delete(ctx, id, d); err != nil {
+			return fmt.Errorf("repo.Delete: %w", err)
+		}
+
+		event := domain.NewCompanyEvent(ctx, d.Action(), id, domain.Company{})
+		if err := addOutboxEvent(ctx, r.db, r.stmts, event); err != nil {
+			return fmt.Errorf("failed to add %s event: %w", event.Action, err)
+		}
+
+		return nil
+	})
+}
+
+// Export implements domain.CompanyRepository
+func (r *outboxWrapper) Export(ctx context.Context, q domain.ExportCompanies, fn func(domain.Company) error) error {
+	return r.repo.Export(ctx, q, fn)
+}
+
+// GetByID implements domain.CompanyRepository
+func (r *outboxWrapper) GetByID(ctx context.Context, id uuid.UUID) (domain.Company, error) {
+	return r.repo.GetByID(ctx, id)
+}
+
+// GetByIDAsOf implements domain.CompanyRepository
+func (r *outboxWrapper) GetByIDAsOf(ctx context.Context, id uuid.UUID, at time.Time) (domain.Company, error) {
+	return r.repo.GetByIDAsOf(ctx, id, at)
+}
+
+// List implements domain.CompanyRepository
+func (r *outboxWrapper) List(ctx context.Context, q domain.ListCompanies) (domain.CompanyPage, error) {
+	return r.repo.List(ctx, q)
+}
+
+// Search implements domain.CompanyRepository
+func (r *outboxWrapper) Search(ctx context.Context, q domain.SearchCompanies) ([]domain.CompanySearchResult, error) {
+	return r.repo.Search(ctx, q)
+}
+
+// Patch implements domain.CompanyRepository
+func (r *outboxWrapper) Patch(ctx context.Context, id uuid.UUID, c domain.PatchCompany) (domain.Company, error) {
+	var company domain.Company
+
+	err := withTx(ctx, r.db, func(ctx context.Context) error {
+		var err error
+		company, err = r.repo.Patch(ctx, id, c)
+		if err != nil {
+			return fmt.Errorf("repo.Patch: %w", err)
+		}
+
+		event := domain.NewCompanyEvent(ctx, domain.UpdateEventActionType, id, company)
+		if err := addOutboxEvent(ctx, r.db, r.stmts, event); err != nil {
+			return fmt.Errorf("failed to add patch event: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return domain.Company{}, err
+	}
+
+	return company, nil
+}
+
+// Restore implements domain.CompanyRepository
+func (r *outboxWrapper) Restore(ctx context.Context, id uuid.UUID) (domain.Company, error) {
+	var company domain.Company
+
+	err := withTx(ctx, r.db, func(ctx context.Context) error {
+		var err error
+		company, err = r.repo.Restore(ctx, id)
+		if err != nil {
+			return fmt.Errorf("repo.Restore: %w", err)
+		}
+
+		event := domain.NewCompanyEvent(ctx, domain.RestoreEventActionType, id, company)
+		if err := addOutboxEvent(ctx, r.db, r.stmts, event); err != nil {
+			return fmt.Errorf("failed to add restore event: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return domain.Company{}, err
+	}
+
+	return company, nil
+}
+
+// NewOutboxWrapper wraps repo so that every mutation stores its domain.CompanyEvent in the outbox atomically.
+// repo must run its queries in the transaction carried by the context, as the postgres company repository does.
+func NewOutboxWrapper(db *sqlx.DB, repo domain.CompanyRepository) domain.CompanyRepository {
+	return &outboxWrapper{
+		db:    db,
+		stmts: newStatements(),
+		repo:  repo,
+	}
+}
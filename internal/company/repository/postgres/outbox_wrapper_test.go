@@ -0,0 +1,170 @@
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+	"github.com/AlisskaPie/project-xm/pkg/domain/mocks"
+)
+
+func TestOutboxWrapper_CreateSuccess(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	require.NoError(t, err)
+	dbMock.ExpectBegin()
+	dbMock.ExpectPrepare(`^INSERT INTO "company_outbox" \("company_id", "payload"\) VALUES \(\$1, \$2\)$`).
+		ExpectExec().
+		WithArgs(testUUID.String(), matchArg(`^{"EventID":.*,"Action":"insert","ID":"10000000-0000-0000-0000-000000000000",.*}$`)).
+		WillReturnResult(driver.RowsAffected(1))
+	dbMock.ExpectCommit()
+
+	createCompany := domain.CreateCompany{
+		Name:        "1",
+		CompanyType: domain.CooperativeType,
+	}
+	createdCompany := domain.Company{
+		ID:          testUUID,
+		Name:        "1",
+		CompanyType: domain.CooperativeType,
+		Version:     1,
+	}
+
+	m := &mocks.CompanyRepository{}
+	m.On("Create", mock.Anything, createCompany).Return(createdCompany, nil)
+
+	w := NewOutboxWrapper(sqlx.NewDb(db, "sqlmock"), m)
+	company, err := w.Create(context.TODO(), createCompany)
+	assert.NoError(t, err)
+	assert.Equal(t, createdCompany, company)
+
+	m.AssertExpectations(t)
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
+
+func TestOutboxWrapper_CreateBatchSuccess(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	require.NoError(t, err)
+	dbMock.ExpectBegin()
+	dbMock.ExpectPrepare(`^INSERT INTO "company_outbox" `).
+		ExpectExec().
+		WithArgs(testUUID.String(), matchArg(`^{"EventID":.*,"Action":"insert",.*}$`)).
+		WillReturnResult(driver.RowsAffected(1))
+	dbMock.ExpectCommit()
+
+	batch := domain.CreateCompanies{
+		Companies: []domain.CreateCompany{{ID: testUUID}, {ID: testUUID}},
+		Mode:      domain.BestEffortBatchMode,
+	}
+	results := []domain.CreateCompanyResult{
+		{Company: domain.Company{ID: testUUID, Version: 1}},
+		{Err: domain.NewError(domain.ErrConflict, "company already exists", nil)},
+	}
+
+	m := &mocks.CompanyRepository{}
+	m.On("CreateBatch", mock.Anything, batch).Return(results, nil)
+
+	w := NewOutboxWrapper(sqlx.NewDb(db, "sqlmock"), m)
+	res, err := w.CreateBatch(context.TODO(), batch)
+	assert.NoError(t, err)
+	assert.Equal(t, results, res)
+
+	m.AssertExpectations(t)
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
+
+func TestOutboxWrapper_DeleteSuccess(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	require.NoError(t, err)
+	dbMock.ExpectBegin()
+	dbMock.ExpectPrepare(`^INSERT INTO "company_outbox" `).
+		ExpectExec().
+		WithArgs(testUUID.String(), matchArg(`"Action":"delete","ID":"10000000-0000-0000-0000-000000000000",`)).
+		WillReturnResult(driver.RowsAffected(1))
+	dbMock.ExpectCommit()
+
+	m := &mocks.CompanyRepository{}
+	m.On("Delete", mock.Anything, testUUID, domain.DeleteCompany{}).Return(nil)
+
+	w := NewOutboxWrapper(sqlx.NewDb(db, "sqlmock"), m)
+	err = w.Delete(context.TODO(), testUUID, domain.DeleteCompany{})
+	assert.NoError(t, err)
+
+	m.AssertExpectations(t)
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
+
+func TestOutboxWrapper_PurgeSuccess(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	require.NoError(t, err)
+	dbMock.ExpectBegin()
+	dbMock.ExpectPrepare(`^INSERT INTO "company_outbox" `).
+		ExpectExec().
+		WithArgs(testUUID.String(), matchArg(`"Action":"purge","ID":"10000000-0000-0000-0000-000000000000",`)).
+		WillReturnResult(driver.RowsAffected(1))
+	dbMock.ExpectCommit()
+
+	m := &mocks.CompanyRepository{}
+	m.On("Delete", mock.Anything, testUUID, domain.DeleteCompany{Purge: true}).Return(nil)
+
+	w := NewOutboxWrapper(sqlx.NewDb(db, "sqlmock"), m)
+	err = w.Delete(context.TODO(), testUUID, domain.DeleteCompany{Purge: true})
+	assert.NoError(t, err)
+
+	m.AssertExpectations(t)
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
+
+func TestOutboxWrapper_PatchFailed(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	require.NoError(t, err)
+	dbMock.ExpectBegin()
+	dbMock.ExpectRollback()
+
+	testErr := errors.New("test error")
+	m := &mocks.CompanyRepository{}
+	m.On("Patch", mock.Anything, testUUID, domain.PatchCompany{}).Return(domain.Company{}, testErr)
+
+	w := NewOutboxWrapper(sqlx.NewDb(db, "sqlmock"), m)
+	_, err = w.Patch(context.TODO(), testUUID, domain.PatchCompany{})
+	assert.ErrorIs(t, err, testErr)
+
+	m.AssertExpectations(t)
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
+
+func TestOutboxWrapper_JoinsRepositoryTransaction(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	require.NoError(t, err)
+	dbMock.ExpectBegin()
+	dbMock.ExpectPrepare(`^INSERT INTO "company" `).
+		ExpectQuery().
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(testUUID.String()))
+	dbMock.ExpectPrepare(`^INSERT INTO "company_outbox" `).
+		ExpectExec().
+		WillReturnError(errors.New("test error"))
+	dbMock.ExpectRollback()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	w := NewOutboxWrapper(sqlxDB, NewCompanyRepository(context.TODO(), sqlxDB))
+	_, err = w.Create(context.TODO(), domain.CreateCompany{ID: testUUID})
+	assert.Error(t, err)
+
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
+
+// matchArg matches the string arg by the regexp
+type matchArg string
+
+func (m matchArg) Match(v driver.Value) bool {
+	s, ok := v.(string)
+	return ok && regexp.MustCompile(string(m)).MatchString(s)
+}
@@ -0,0 +1,178 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/AlisskaPie/project-xm/internal/company/repository/audit"
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type companyAuditRepository struct {
+	db    *sqlx.DB
+	stmts *statements
+}
+
+// History implements domain.CompanyAuditRepository
+func (r *companyAuditRepository) History(ctx context.Context, q domain.CompanyHistory) (domain.CompanyHistoryPage, error) {
+	if q.Limit <= 0 {
+		q.Limit = domain.DefaultListLimit
+	}
+
+	ds := dialect.From("company_audit").
+		Prepared(true).
+		Select("id", "company_id", "action", "actor", "request_id", "changes", "created_at").
+		Where(goqu.C("company_id").Eq(q.CompanyID.String()), tenantScope(ctx))
+
+	if q.Cursor != "" {
+		// the cursor is the ID of the last entry of the previous page
+		last, err := strconv.ParseInt(q.Cursor, 10, 64)
+		if err != nil {
+			return domain.CompanyHistoryPage{}, domain.NewError(domain.ErrBadRequest, "invalid cursor", nil)
+		}
+		ds = ds.Where(goqu.C("id").Lt(last))
+	}
+
+	// one more entry tells whether there is the next page
+	query, args, err := ds.Order(goqu.C("id").Desc()).Limit(uint(q.Limit + 1)).ToSQL()
+	if err != nil {
+		return domain.CompanyHistoryPage{}, fmt.Errorf("cannot build query: %w", err)
+	}
+
+	var res []AuditEntry
+	if err := r.stmts.selectAll(ctx, r.db, &res, query, args); err != nil {
+		return domain.CompanyHistoryPage{}, fmt.Errorf("SelectContext: %w", translateError(err))
+	}
+
+	page := domain.CompanyHistoryPage{
+		Entries: make([]domain.CompanyAuditEntry, 0, len(res)),
+	}
+	if len(res) > q.Limit {
+		res = res[:q.Limit]
+		page.NextCursor = strconv.FormatInt(res[len(res)-1].ID, 10)
+	}
+
+	for _, e := range res {
+		var changes []AuditChange
+		if err := json.Unmarshal(e.Changes, &changes); err != nil {
+			return domain.CompanyHistoryPage{}, fmt.Errorf("failed to decode audit entry %d: %w", e.ID, err)
+		}
+
+		entry := domain.CompanyAuditEntry{
+			ID:        e.ID,
+			CompanyID: e.CompanyID,
+			Action:    domain.EventActionType(e.Action),
+			Actor: domain.Actor{
+				Subject:   e.Actor,
+				RequestID: e.RequestID,
+			},
+			Time:    e.CreatedAt.UTC(),
+			Changes: make([]domain.FieldChange, 0, len(changes)),
+		}
+		for _, c := range changes {
+			entry.Changes = append(entry.Changes, domain.FieldChange(c))
+		}
+
+		page.Entries = append(page.Entries, entry)
+	}
+
+	return page, nil
+}
+
+// auditStore keeps the audit entries in the company_audit table of db
+type auditStore struct {
+	db    *sqlx.DB
+	stmts *statements
+}
+
+// WithTx implements audit.Store
+func (s auditStore) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return withTx(ctx, s.db, fn)
+}
+
+// Lock implements audit.Store
+func (s auditStore) Lock(ctx context.Context, id uuid.UUID, withDeleted bool) (domain.Company, error) {
+	where := goqu.Ex{"id": id.String(), "tenant_id": domain.TenantFromContext(ctx)}
+	if !withDeleted {
+		where["deleted_at"] = nil
+	}
+
+	q, args, err := dialect.From("company").
+		Prepared(true).
+		Select(companyColumns...).
+		Where(where).
+		ForUpdate(exp.Wait).
+		ToSQL()
+	if err != nil {
+		return domain.Company{}, fmt.Errorf("cannot build query: %w", err)
+	}
+
+	var res Company
+	if err := s.stmts.get(ctx, s.db, &res, q, args); err != nil {
+		return domain.Company{}, fmt.Errorf("GetContext: %w", translateError(err))
+	}
+
+	return domain.Company(res), nil
+}
+
+// AddEntry implements audit.Store, the actor is carried by ctx
+func (s auditStore) AddEntry(
+	ctx context.Context,
+	action domain.EventActionType,
+	id uuid.UUID,
+	changes []domain.FieldChange,
+) error {
+	auditChanges := make([]AuditChange, 0, len(changes))
+	for _, c := range changes {
+		auditChanges = append(auditChanges, AuditChange(c))
+	}
+
+	b, err := json.Marshal(auditChanges)
+	if err != nil {
+		return fmt.Errorf("failed to encode changes: %w", err)
+	}
+
+	actor := domain.ActorFromContext(ctx)
+	q, args, err := dialect.Insert("company_audit").
+		Prepared(true).
+		Rows(goqu.Record{
+			"company_id": id.String(),
+			"tenant_id":  domain.TenantFromContext(ctx),
+			"action":     string(action),
+			"actor":      actor.Subject,
+			"request_id": actor.RequestID,
+			"changes":    string(b),
+		}).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("cannot build query: %w", err)
+	}
+
+	if _, err := s.stmts.exec(ctx, s.db, q, args); err != nil {
+		return fmt.Errorf("ExecContext: %w", err)
+	}
+
+	return nil
+}
+
+// NewCompanyAuditRepository creates an object that represent the domain.CompanyAuditRepository interface
+func NewCompanyAuditRepository(db *sqlx.DB) domain.CompanyAuditRepository {
+	return &companyAuditRepository{
+		db:    db,
+		stmts: newStatements(),
+	}
+}
+
+// NewAuditWrapper wraps repo so that every mutation records its domain.CompanyAuditEntry atomically,
+// the actor is taken from the context of the mutation.
+// repo must run its queries in the transaction carried by the context, as the postgres company repository does.
+func NewAuditWrapper(db *sqlx.DB, repo domain.CompanyRepository) domain.CompanyRepository {
+	return audit.NewWrapper(auditStore{db: db, stmts: newStatements()}, repo)
+}
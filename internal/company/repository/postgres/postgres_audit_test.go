@@ -0,0 +1,114 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+)
+
+func TestPostgresCompanyAuditHistory(t *testing.T) {
+	testErr := errors.New("test error")
+	testTime := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	auditColumns := []string{"id", "company_id", "action", "actor", "request_id", "changes", "created_at"}
+
+	tests := []struct {
+		name     string
+		rf       registerFunc
+		query    domain.CompanyHistory
+		wantPage domain.CompanyHistoryPage
+		wantErr  error
+	}{
+		{
+			name:  "Success",
+			query: domain.CompanyHistory{CompanyID: testUUID, Limit: 1},
+			rf: func(s sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows(auditColumns).
+					AddRow(5, testUUID.String(), "update", "1234567890", "req",
+						[]byte(`[{"field":"type","old":"NonProfit","new":"Cooperative"}]`), testTime).
+					AddRow(4, testUUID.String(), "insert", "1234567890", "req", []byte(`[]`), testTime)
+				s.ExpectPrepare(`^SELECT "id", "company_id", "action", "actor", "request_id", "changes", "created_at" `+
+					`FROM "company_audit" WHERE \(\("company_id" = \$1\) AND \("tenant_id" = \$2\)\) `+
+					`ORDER BY "id" DESC LIMIT \$3$`).
+					ExpectQuery().
+					WithArgs(testUUID.String(), "", 2).
+					WillReturnRows(rows)
+			},
+			wantPage: domain.CompanyHistoryPage{
+				Entries: []domain.CompanyAuditEntry{{
+					ID:        5,
+					CompanyID: testUUID,
+					Action:    domain.UpdateEventActionType,
+					Actor:     domain.Actor{Subject: "1234567890", RequestID: "req"},
+					Time:      testTime,
+					Changes: []domain.FieldChange{
+						{Field: "type", Old: "NonProfit", New: "Cooperative"},
+					},
+				}},
+				NextCursor: "5",
+			},
+		},
+		{
+			name:  "Cursor",
+			query: domain.CompanyHistory{CompanyID: testUUID, Limit: 1, Cursor: "5"},
+			rf: func(s sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows(auditColumns).
+					AddRow(4, testUUID.String(), "insert", "1234567890", "req", []byte(`[]`), testTime)
+				s.ExpectPrepare(`^SELECT (.+) FROM "company_audit" `+
+					`WHERE \(\("company_id" = \$1\) AND \("tenant_id" = \$2\) AND \("id" < \$3\)\) `+
+					`ORDER BY "id" DESC LIMIT \$4$`).
+					ExpectQuery().
+					WithArgs(testUUID.String(), "", 5, 2).
+					WillReturnRows(rows)
+			},
+			wantPage: domain.CompanyHistoryPage{
+				Entries: []domain.CompanyAuditEntry{{
+					ID:        4,
+					CompanyID: testUUID,
+					Action:    domain.InsertEventActionType,
+					Actor:     domain.Actor{Subject: "1234567890", RequestID: "req"},
+					Time:      testTime,
+					Changes:   []domain.FieldChange{},
+				}},
+			},
+		},
+		{
+			name:    "InvalidCursor",
+			query:   domain.CompanyHistory{CompanyID: testUUID, Cursor: "abc"},
+			rf:      func(s sqlmock.Sqlmock) {},
+			wantErr: domain.NewError(domain.ErrBadRequest, "invalid cursor", nil),
+		},
+		{
+			name:  "Failed",
+			query: domain.CompanyHistory{CompanyID: testUUID},
+			rf: func(s sqlmock.Sqlmock) {
+				s.ExpectPrepare(`^SELECT (.+) LIMIT \$3$`).
+					ExpectQuery().
+					WithArgs(testUUID.String(), "", 21).
+					WillReturnError(testErr)
+			},
+			wantErr: fmt.Errorf("SelectContext: %w", testErr),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, dbMock, err := sqlmock.New()
+			require.NoError(t, err)
+			tt.rf(dbMock)
+
+			r := NewCompanyAuditRepository(sqlx.NewDb(db, "sqlmock"))
+			page, err := r.History(context.TODO(), tt.query)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantPage, page)
+			assert.NoError(t, dbMock.ExpectationsWereMet())
+		})
+	}
+}
@@ -2,11 +2,17 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/AlisskaPie/project-xm/internal/company/repository/keyset"
 	"github.com/AlisskaPie/project-xm/pkg/domain"
 
 	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 )
@@ -14,59 +20,455 @@ import (
 type companyRepository struct {
 	ctx context.Context
 	db  *sqlx.DB
+	// replicas route the reads, they all go to db if it's nil
+	replicas *Replicas
+	stmts    *statements
 }
 
 // Create implements domain.CompanyRepository
-func (r *companyRepository) Create(ctx context.Context, c domain.CreateCompany) error {
+func (r *companyRepository) Create(ctx context.Context, c domain.CreateCompany) (domain.Company, error) {
+	r.replicas.wrote(ctx)
+
 	if c.ID == uuid.Nil {
 		c.ID = uuid.New()
 	}
 
-	query, _, err := goqu.Insert("company").Rows(Company(c)).ToSQL()
+	query, args, err := dialect.Insert("company").
+		Prepared(true).
+		Rows(newCompany(ctx, c)).
+		Returning(companyColumns...).
+		ToSQL()
 	if err != nil {
-		return fmt.Errorf("cannot build query: %w", err)
+		return domain.Company{}, fmt.Errorf("cannot build query: %w", err)
 	}
 
-	_, err = r.db.ExecContext(ctx, query)
+	var res Company
+	err = r.stmts.get(ctx, r.db, &res, query, args)
+	if index, ok := nameViolation(err); ok {
+		return domain.Company{}, r.nameConflict(ctx, err, index, c.ID, &c.Name, &c.CompanyType)
+	}
 	if err != nil {
-		return fmt.Errorf("ExecContext: %w", err)
+		return domain.Company{}, fmt.Errorf("GetContext: %w", translateError(err))
 	}
 
-	return nil
+	return domain.Company(res), nil
+}
+
+// CreateBatch implements domain.CompanyRepository
+func (r *companyRepository) CreateBatch(
+	ctx context.Context,
+	b domain.CreateCompanies,
+) ([]domain.CreateCompanyResult, error) {
+	r.replicas.wrote(ctx)
+
+	if len(b.Companies) == 0 {
+		return []domain.CreateCompanyResult{}, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(b.Companies))
+	rows := make([]any, 0, len(b.Companies))
+	for _, c := range b.Companies {
+		if c.ID == uuid.Nil {
+			c.ID = uuid.New()
+		}
+		ids = append(ids, c.ID)
+		rows = append(rows, newCompany(ctx, c))
+	}
+
+	// the companies conflicting by ID or name are skipped instead of failing the whole insert
+	query, args, err := dialect.Insert("company").
+		Prepared(true).
+		Rows(rows...).
+		OnConflict(goqu.DoNothing()).
+		Returning(companyColumns...).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("cannot build query: %w", err)
+	}
+
+	var results []domain.CreateCompanyResult
+	err = withTx(ctx, r.db, func(ctx context.Context) error {
+		var created []Company
+		if err := r.stmts.selectAll(ctx, r.db, &created, query, args); err != nil {
+			return fmt.Errorf("SelectContext: %w", translateError(err))
+		}
+
+		byID := make(map[uuid.UUID]Company, len(created))
+		for _, c := range created {
+			byID[c.ID] = c
+		}
+
+		results = make([]domain.CreateCompanyResult, len(ids))
+		for i, id := range ids {
+			c, ok := byID[id]
+			if !ok {
+				if b.Mode == domain.AllOrNothingBatchMode {
+					return domain.NewError(domain.ErrConflict, fmt.Sprintf("company %d or its name already exists", i), nil)
+				}
+
+				results[i].Err = domain.NewError(domain.ErrConflict, "company or its name already exists", nil)
+				continue
+			}
+
+			// the same ID repeated in the batch conflicts with its first company
+			delete(byID, id)
+			results[i].Company = domain.Company(c)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
 }
 
 // Delete implements domain.CompanyRepository
-func (r *companyRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	q, _, err := goqu.Delete("company").Where(goqu.Ex{"id": id.String()}).ToSQL()
+func (r *companyRepository) Delete(ctx context.Context, id uuid.UUID, d domain.DeleteCompany) error {
+	r.replicas.wrote(ctx)
+
+	where := goqu.Ex{"id": id.String(), "tenant_id": domain.TenantFromContext(ctx)}
+	if d.Version != nil {
+		where["version"] = *d.Version
+	}
+
+	var q string
+	var args []any
+	var err error
+	if d.Purge {
+		q, args, err = dialect.Delete("company").Prepared(true).Where(where).ToSQL()
+	} else {
+		where["deleted_at"] = nil
+		q, args, err = dialect.Update("company").
+			Prepared(true).
+			Set(goqu.Record{
+				"deleted_at": goqu.L("now()"),
+				"version":    goqu.L(`"version" + 1`),
+			}).
+			Where(where).
+			ToSQL()
+	}
 	if err != nil {
 		return fmt.Errorf("cannot build query: %w", err)
 	}
 
-	if _, err := r.db.ExecContext(ctx, q); err != nil {
-		return fmt.Errorf("ExecContext: %w", err)
+	res, err := r.stmts.exec(ctx, r.db, q, args)
+	if err != nil {
+		return fmt.Errorf("ExecContext: %w", translateError(err))
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("RowsAffected: %w", err)
+	}
+
+	if n == 0 {
+		return r.missingVersion(ctx, id, d.Version, d.Purge)
 	}
 
 	return nil
 }
 
+// exportFetchSize is the number of companies fetched at once from the export cursor
+const exportFetchSize = 500
+
+// Export implements domain.CompanyRepository.
+// The companies are read from a server-side cursor, so only exportFetchSize of them are held in memory.
+func (r *companyRepository) Export(ctx context.Context, q domain.ExportCompanies, fn func(domain.Company) error) error {
+	ds := dialect.From("company").
+		Prepared(true).
+		Select(companyColumns...).
+		Where(goqu.C("deleted_at").IsNull(), tenantScope(ctx))
+
+	if q.CompanyType != nil {
+		ds = ds.Where(goqu.C("type").Eq(string(*q.CompanyType)))
+	}
+	if q.Registered != nil {
+		ds = ds.Where(goqu.C("registered").Eq(*q.Registered))
+	}
+
+	query, args, err := ds.Order(goqu.C("id").Asc()).ToSQL()
+	if err != nil {
+		return fmt.Errorf("cannot build query: %w", err)
+	}
+
+	return r.replicas.read(ctx, r.db, func(db *sqlx.DB) error {
+		// once a company is exported the export can't be restarted on the primary
+		exported := false
+		err := r.export(ctx, db, query, args, func(c domain.Company) error {
+			exported = true
+			return fn(c)
+		})
+		if err != nil && exported {
+			return noFallback{err: err}
+		}
+
+		return err
+	})
+}
+
+// export passes the companies of the query read from src to fn
+func (r *companyRepository) export(
+	ctx context.Context,
+	src *sqlx.DB,
+	query string,
+	args []any,
+	fn func(domain.Company) error,
+) error {
+	// cursors live in the transaction, their statements are not cached, as the FETCH prepared where the cursor
+	// doesn't exist would be described as returning no columns
+	return withTx(ctx, src, func(ctx context.Context) error {
+		declare := "DECLARE company_export NO SCROLL CURSOR FOR " + query
+		if _, err := conn(ctx, src).ExecContext(ctx, declare, args...); err != nil {
+			return fmt.Errorf("failed to declare cursor: %w", translateError(err))
+		}
+
+		fetch := fmt.Sprintf("FETCH FORWARD %d FROM company_export", exportFetchSize)
+		for {
+			var res []Company
+			if err := sqlx.SelectContext(ctx, conn(ctx, src), &res, fetch); err != nil {
+				return fmt.Errorf("SelectContext: %w", translateError(err))
+			}
+
+			for _, c := range res {
+				if err := fn(domain.Company(c)); err != nil {
+					return err
+				}
+			}
+
+			if len(res) < exportFetchSize {
+				break
+			}
+		}
+
+		if _, err := conn(ctx, src).ExecContext(ctx, "CLOSE company_export"); err != nil {
+			return fmt.Errorf("failed to close cursor: %w", translateError(err))
+		}
+
+		return nil
+	})
+}
+
 // GetByID implements domain.CompanyRepository
 func (r *companyRepository) GetByID(ctx context.Context, id uuid.UUID) (domain.Company, error) {
-	q, _, err := goqu.From("company").Where(goqu.Ex{"id": id.String()}).ToSQL()
+	q, args, err := dialect.From("company").
+		Prepared(true).
+		Select(companyColumns...).
+		Where(goqu.Ex{"id": id.String(), "deleted_at": nil, "tenant_id": domain.TenantFromContext(ctx)}).
+		ToSQL()
 	if err != nil {
 		return domain.Company{}, fmt.Errorf("cannot build query: %w", err)
 	}
 
 	var res Company
-	err = r.db.QueryRowxContext(ctx, q).StructScan(&res)
+	err = r.replicas.read(ctx, r.db, func(db *sqlx.DB) error {
+		if err := r.stmts.get(ctx, db, &res, q, args); err != nil {
+			return fmt.Errorf("GetContext: %w", translateError(err))
+		}
+
+		return nil
+	})
 	if err != nil {
-		return domain.Company{}, fmt.Errorf("QueryRowxContext: %w", err)
+		return domain.Company{}, err
 	}
 
 	return domain.Company(res), nil
 }
 
+// GetByIDAsOf implements domain.CompanyRepository
+func (r *companyRepository) GetByIDAsOf(ctx context.Context, id uuid.UUID, at time.Time) (domain.Company, error) {
+	q, args, err := dialect.From("company_history").
+		Prepared(true).
+		Select(companyColumns...).
+		Where(
+			goqu.C("id").Eq(id.String()),
+			tenantScope(ctx),
+			goqu.C("valid_from").Lte(at.UTC()),
+			goqu.C("valid_to").Gt(at.UTC()),
+		).
+		ToSQL()
+	if err != nil {
+		return domain.Company{}, fmt.Errorf("cannot build query: %w", err)
+	}
+
+	var res Company
+	err = r.replicas.read(ctx, r.db, func(db *sqlx.DB) error {
+		err := r.stmts.get(ctx, db, &res, q, args)
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.NewError(domain.ErrNotFound, "company did not exist at that time", err)
+		}
+		if err != nil {
+			return fmt.Errorf("GetContext: %w", translateError(err))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return domain.Company{}, err
+	}
+
+	return domain.Company(res), nil
+}
+
+// List implements domain.CompanyRepository
+func (r *companyRepository) List(ctx context.Context, q domain.ListCompanies) (domain.CompanyPage, error) {
+	column, ok := keyset.SortColumns[q.SortBy]
+	if !ok {
+		return domain.CompanyPage{}, domain.NewError(domain.ErrBadRequest, "unknown sort field", nil)
+	}
+	if q.Limit <= 0 {
+		q.Limit = domain.DefaultListLimit
+	}
+
+	ds := dialect.From("company").
+		Prepared(true).
+		Select(companyColumns...).
+		Where(goqu.C("deleted_at").IsNull(), tenantScope(ctx))
+
+	if q.CompanyType != nil {
+		ds = ds.Where(goqu.C("type").Eq(string(*q.CompanyType)))
+	}
+	if q.Registered != nil {
+		ds = ds.Where(goqu.C("registered").Eq(*q.Registered))
+	}
+	if q.MinEmployees != nil {
+		ds = ds.Where(goqu.C("amount_of_employees").Gte(*q.MinEmployees))
+	}
+	if q.MaxEmployees != nil {
+		ds = ds.Where(goqu.C("amount_of_employees").Lte(*q.MaxEmployees))
+	}
+	if q.NamePrefix != "" {
+		ds = ds.Where(goqu.C("name").ILike(likeEscaper.Replace(q.NamePrefix) + "%"))
+	}
+
+	op, order := ">", goqu.C(column).Asc()
+	orderByID := goqu.C("id").Asc()
+	if q.Desc {
+		op, order = "<", goqu.C(column).Desc()
+		orderByID = goqu.C("id").Desc()
+	}
+
+	if q.Cursor != "" {
+		value, id, err := keyset.ParseCursor(q)
+		if err != nil {
+			return domain.CompanyPage{}, err
+		}
+
+		if q.SortBy == domain.SortByID {
+			ds = ds.Where(goqu.L("? "+op+" ?", goqu.C("id"), id.String()))
+		} else {
+			ds = ds.Where(goqu.L("(?, ?) "+op+" (?, ?)", goqu.C(column), goqu.C("id"), value, id.String()))
+		}
+	}
+
+	if q.SortBy == domain.SortByID {
+		ds = ds.Order(orderByID)
+	} else {
+		ds = ds.Order(order, orderByID)
+	}
+
+	// one more company tells whether there is the next page
+	query, args, err := ds.Limit(uint(q.Limit + 1)).ToSQL()
+	if err != nil {
+		return domain.CompanyPage{}, fmt.Errorf("cannot build query: %w", err)
+	}
+
+	var res []Company
+	err = r.replicas.read(ctx, r.db, func(db *sqlx.DB) error {
+		if err := r.stmts.selectAll(ctx, db, &res, query, args); err != nil {
+			return fmt.Errorf("SelectContext: %w", translateError(err))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return domain.CompanyPage{}, err
+	}
+
+	page := domain.CompanyPage{
+		Companies: make([]domain.Company, 0, len(res)),
+	}
+	if len(res) > q.Limit {
+		res = res[:q.Limit]
+
+		page.NextCursor, err = keyset.NewCursor(q, domain.Company(res[len(res)-1]))
+		if err != nil {
+			return domain.CompanyPage{}, fmt.Errorf("keyset.NewCursor: %w", err)
+		}
+	}
+
+	for _, c := range res {
+		page.Companies = append(page.Companies, domain.Company(c))
+	}
+
+	return page, nil
+}
+
+// Search implements domain.CompanyRepository
+func (r *companyRepository) Search(ctx context.Context, q domain.SearchCompanies) ([]domain.CompanySearchResult, error) {
+	tsQuery := toTSQuery(q.Query)
+	if tsQuery == "" {
+		return nil, domain.NewError(domain.ErrBadRequest, "search query has no words", nil)
+	}
+	if q.Limit <= 0 {
+		q.Limit = domain.DefaultListLimit
+	}
+
+	columns := append([]any{}, companyColumns...)
+	columns = append(columns,
+		goqu.L("ts_rank(?, ?)", goqu.C("search"), goqu.I("query")).As("rank"),
+		goqu.L("ts_headline(?, coalesce(?, ''), ?, ?)", searchConfig, goqu.C("description"), goqu.I("query"), headlineOptions).
+			As("snippet"),
+	)
+
+	query, args, err := dialect.From(goqu.T("company"), goqu.L("to_tsquery(?, ?)", searchConfig, tsQuery).As("query")).
+		Prepared(true).
+		Select(columns...).
+		Where(goqu.L("? @@ ?", goqu.C("search"), goqu.I("query")), goqu.C("deleted_at").IsNull(), tenantScope(ctx)).
+		Order(goqu.I("rank").Desc(), goqu.C("id").Asc()).
+		Limit(uint(q.Limit)).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("cannot build query: %w", err)
+	}
+
+	var res []CompanySearchResult
+	err = r.replicas.read(ctx, r.db, func(db *sqlx.DB) error {
+		if err := r.stmts.selectAll(ctx, db, &res, query, args); err != nil {
+			return fmt.Errorf("SelectContext: %w", translateError(err))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]domain.CompanySearchResult, 0, len(res))
+	for _, c := range res {
+		results = append(results, domain.CompanySearchResult{
+			Company: domain.Company(c.Company),
+			Rank:    c.Rank,
+			Snippet: c.Snippet,
+		})
+	}
+
+	return results, nil
+}
+
+// tenantScope restricts the query to the companies of the tenant of ctx
+func tenantScope(ctx context.Context) exp.BooleanExpression {
+	return goqu.C("tenant_id").Eq(domain.TenantFromContext(ctx))
+}
+
+// likeEscaper escapes the LIKE pattern wildcards
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
 // Patch implements domain.CompanyRepository
 func (r *companyRepository) Patch(ctx context.Context, id uuid.UUID, c domain.PatchCompany) (domain.Company, error) {
+	r.replicas.wrote(ctx)
+
 	updates := map[string]any{}
 
 	if c.Description != nil {
@@ -88,28 +490,118 @@ func (r *companyRepository) Patch(ctx context.Context, id uuid.UUID, c domain.Pa
 		updates["type"] = string(*c.CompanyType)
 	}
 
-	q, _, err := goqu.Update("company").
-		Set(updates).
-		Where(goqu.Ex{"id": id.String()}).
-		Returning(goqu.T("company").All()).
+	where := goqu.Ex{"id": id.String(), "deleted_at": nil, "tenant_id": domain.TenantFromContext(ctx)}
+	if c.Version != nil {
+		where["version"] = *c.Version
+	}
+
+	// an empty patch changes nothing, so it only reads the company under the same conditions
+	var q string
+	var args []any
+	var err error
+	if len(updates) == 0 {
+		q, args, err = dialect.From("company").
+			Prepared(true).
+			Select(companyColumns...).
+			Where(where).
+			ToSQL()
+	} else {
+		updates["version"] = goqu.L(`"version" + 1`)
+		q, args, err = dialect.Update("company").
+			Prepared(true).
+			Set(updates).
+			Where(where).
+			Returning(companyColumns...).
+			ToSQL()
+	}
+	if err != nil {
+		return domain.Company{}, fmt.Errorf("cannot build query: %w", err)
+	}
+
+	var res Company
+	err = r.stmts.get(ctx, r.db, &res, q, args)
+	if errors.Is(err, sql.ErrNoRows) && c.Version != nil {
+		return domain.Company{}, r.missingVersion(ctx, id, c.Version, false)
+	}
+	if index, ok := nameViolation(err); ok {
+		return domain.Company{}, r.nameConflict(ctx, err, index, id, c.Name, c.CompanyType)
+	}
+	if err != nil {
+		return domain.Company{}, fmt.Errorf("GetContext: %w", translateError(err))
+	}
+
+	return domain.Company(res), nil
+}
+
+// Restore implements domain.CompanyRepository
+func (r *companyRepository) Restore(ctx context.Context, id uuid.UUID) (domain.Company, error) {
+	r.replicas.wrote(ctx)
+
+	q, args, err := dialect.Update("company").
+		Prepared(true).
+		Set(goqu.Record{
+			"deleted_at": goqu.L("NULL"),
+			"version":    goqu.L(`"version" + 1`),
+		}).
+		Where(goqu.C("id").Eq(id.String()), goqu.C("deleted_at").IsNotNull(), tenantScope(ctx)).
+		Returning(companyColumns...).
 		ToSQL()
 	if err != nil {
 		return domain.Company{}, fmt.Errorf("cannot build query: %w", err)
 	}
 
 	var res Company
-	err = r.db.QueryRowxContext(ctx, q).StructScan(&res)
+	err = r.stmts.get(ctx, r.db, &res, q, args)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Company{}, domain.NewError(domain.ErrNotFound, "deleted company does not exist", err)
+	}
+	if index, ok := nameViolation(err); ok {
+		return domain.Company{}, r.nameConflict(ctx, err, index, id, nil, nil)
+	}
 	if err != nil {
-		return domain.Company{}, fmt.Errorf("QueryRowxContext: %w", err)
+		return domain.Company{}, fmt.Errorf("GetContext: %w", translateError(err))
 	}
 
 	return domain.Company(res), nil
 }
 
+// missingVersion tells why the company of the version was not found: it doesn't exist or has another version.
+// Deleted companies are taken into account only if withDeleted is set.
+func (r *companyRepository) missingVersion(ctx context.Context, id uuid.UUID, version *int64, withDeleted bool) error {
+	if version == nil {
+		return domain.NewError(domain.ErrNotFound, "company does not exist", nil)
+	}
+
+	where := goqu.Ex{"id": id.String(), "tenant_id": domain.TenantFromContext(ctx)}
+	if !withDeleted {
+		where["deleted_at"] = nil
+	}
+
+	q, args, err := dialect.From("company").Prepared(true).Select("version").Where(where).ToSQL()
+	if err != nil {
+		return fmt.Errorf("cannot build query: %w", err)
+	}
+
+	var current int64
+	if err := r.stmts.get(ctx, r.db, &current, q, args); err != nil {
+		return fmt.Errorf("GetContext: %w", translateError(err))
+	}
+
+	return domain.NewError(domain.ErrPreconditionFailed, fmt.Sprintf("company version is %d", current), nil)
+}
+
 // NewCompanyRepository creates an object that represent the company.Repository interface
 func NewCompanyRepository(ctx context.Context, db *sqlx.DB) domain.CompanyRepository {
+	return NewReplicatedCompanyRepository(ctx, db, nil)
+}
+
+// NewReplicatedCompanyRepository creates the company repository that writes to the primary db
+// and reads from the replicas
+func NewReplicatedCompanyRepository(ctx context.Context, db *sqlx.DB, replicas *Replicas) domain.CompanyRepository {
 	return &companyRepository{
-		ctx: ctx,
-		db:  db,
+		ctx:      ctx,
+		db:       db,
+		replicas: replicas,
+		stmts:    newStatements(),
 	}
 }
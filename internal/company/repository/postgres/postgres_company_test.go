@@ -2,17 +2,21 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
 	"database/sql/driver"
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gopkg.in/DATA-DOG/go-sqlmock.v1"
 
+	"github.com/AlisskaPie/project-xm/internal/company/repository/keyset"
 	"github.com/AlisskaPie/project-xm/pkg/domain"
 )
 
@@ -22,6 +26,7 @@ func TestPostgresCompanyCreate(t *testing.T) {
 		name          string
 		rf            registerFunc
 		createCompany domain.CreateCompany
+		company       domain.Company
 		wantErr       error
 	}{
 		{
@@ -34,9 +39,26 @@ func TestPostgresCompanyCreate(t *testing.T) {
 				Registered:        true,
 				CompanyType:       domain.NonProfitType,
 			},
+			company: domain.Company{
+				ID:                testUUID,
+				Name:              "1",
+				Description:       "2",
+				AmountOfEmployees: 3,
+				Registered:        true,
+				CompanyType:       domain.NonProfitType,
+			},
 			rf: func(s sqlmock.Sqlmock) {
-				s.ExpectExec(`^INSERT INTO "company" (.*) VALUES \(3, '2', '10000000-0000-0000-0000-000000000000', '1', TRUE, 'NonProfit'\)$`).
-					WillReturnResult(driver.RowsAffected(1))
+				rows := sqlmock.NewRows([]string{
+					"id", "name", "description", "amount_of_employees", "registered", "type",
+				})
+				rows.AddRow(
+					testUUID.String(),
+					"1", "2", 3, true, domain.NonProfitType,
+				)
+				s.ExpectPrepare(`^INSERT INTO "company" (.*) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7\) RETURNING "id", "name", "description", "amount_of_employees", "registered", "type", "version"$`).
+					ExpectQuery().
+					WithArgs(3, "2", "10000000-0000-0000-0000-000000000000", "1", true, "", "NonProfit").
+					WillReturnRows(rows)
 			},
 			wantErr: nil,
 		},
@@ -44,10 +66,49 @@ func TestPostgresCompanyCreate(t *testing.T) {
 			name:          "Failed",
 			createCompany: domain.CreateCompany{},
 			rf: func(s sqlmock.Sqlmock) {
-				s.ExpectExec("^(.+)").
+				s.ExpectPrepare("^(.+)").
+					ExpectQuery().
 					WillReturnError(testErr)
 			},
-			wantErr: fmt.Errorf("ExecContext: %w", testErr),
+			wantErr: fmt.Errorf("GetContext: %w", testErr),
+		},
+		{
+			name:          "Conflict",
+			createCompany: domain.CreateCompany{ID: testUUID},
+			rf: func(s sqlmock.Sqlmock) {
+				s.ExpectPrepare("^(.+)").
+					ExpectQuery().
+					WillReturnError(testPqErr)
+			},
+			wantErr: fmt.Errorf("GetContext: %w",
+				domain.NewError(domain.ErrConflict, "company already exists", testPqErr)),
+		},
+		{
+			name:          "NameConflict",
+			createCompany: domain.CreateCompany{ID: testUUID, Name: "Acme", CompanyType: domain.NonProfitType},
+			rf: func(s sqlmock.Sqlmock) {
+				s.ExpectPrepare(`^INSERT INTO "company" `).
+					ExpectQuery().
+					WillReturnError(testNamePqErr)
+				s.ExpectQuery(`^SELECT "id" FROM "company" WHERE \(lower\("name"\) = lower\(\$1\) `+
+					`AND \("deleted_at" IS NULL\) AND \("id" != \$2\) AND \("tenant_id" = \$3\)\) LIMIT \$4$`).
+					WithArgs("Acme", "10000000-0000-0000-0000-000000000000", "", 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("20000000-0000-0000-0000-000000000000"))
+			},
+			wantErr: domain.NewError(domain.ErrConflict,
+				"company name is taken by 20000000-0000-0000-0000-000000000000", testNamePqErr),
+		},
+		{
+			name:          "NameConflictNotFound",
+			createCompany: domain.CreateCompany{ID: testUUID, Name: "Acme", CompanyType: domain.NonProfitType},
+			rf: func(s sqlmock.Sqlmock) {
+				s.ExpectPrepare(`^INSERT INTO "company" `).
+					ExpectQuery().
+					WillReturnError(testNamePqErr)
+				s.ExpectQuery(`^SELECT "id" FROM "company" `).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			wantErr: domain.NewError(domain.ErrConflict, "company name is already taken", testNamePqErr),
 		},
 	}
 	for _, tt := range tests {
@@ -59,8 +120,92 @@ func TestPostgresCompanyCreate(t *testing.T) {
 
 			sqlxDB := sqlx.NewDb(db, "sqlmock")
 			r := NewCompanyRepository(context.TODO(), sqlxDB)
-			err = r.Create(context.TODO(), tt.createCompany)
+			company, err := r.Create(context.TODO(), tt.createCompany)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.company, company)
+		})
+	}
+}
+
+func TestPostgresCompanyCreateBatch(t *testing.T) {
+	testUUID2 := uuid.MustParse("20000000-0000-0000-0000-000000000000")
+	companies := []domain.CreateCompany{
+		{ID: testUUID, Name: "1", CompanyType: domain.NonProfitType},
+		{ID: testUUID2, Name: "2", CompanyType: domain.CooperativeType},
+		{ID: testUUID, Name: "3", CompanyType: domain.CooperativeType},
+	}
+
+	tests := []struct {
+		name        string
+		rf          registerFunc
+		mode        domain.BatchMode
+		wantResults []domain.CreateCompanyResult
+		wantErr     error
+	}{
+		{
+			name: "BestEffort",
+			mode: domain.BestEffortBatchMode,
+			rf: func(s sqlmock.Sqlmock) {
+				s.ExpectBegin()
+				s.ExpectPrepare(`^INSERT INTO "company" \("amount_of_employees", "description", "id", "name", "registered", "tenant_id", "type"\) `+
+					`VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7\), \(\$8, \$9, \$10, \$11, \$12, \$13, \$14\), `+
+					`\(\$15, \$16, \$17, \$18, \$19, \$20, \$21\) `+
+					`ON CONFLICT DO NOTHING RETURNING "id", "name", "description", "amount_of_employees", "registered", "type", "version"$`).
+					ExpectQuery().
+					WithArgs(
+						0, "", "10000000-0000-0000-0000-000000000000", "1", false, "", "NonProfit",
+						0, "", "20000000-0000-0000-0000-000000000000", "2", false, "", "Cooperative",
+						0, "", "10000000-0000-0000-0000-000000000000", "3", false, "", "Cooperative",
+					).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "type", "version"}).
+						AddRow(testUUID.String(), "1", domain.NonProfitType, 1))
+				s.ExpectCommit()
+			},
+			wantResults: []domain.CreateCompanyResult{
+				{Company: domain.Company{ID: testUUID, Name: "1", CompanyType: domain.NonProfitType, Version: 1}},
+				{Err: domain.NewError(domain.ErrConflict, "company or its name already exists", nil)},
+				{Err: domain.NewError(domain.ErrConflict, "company or its name already exists", nil)},
+			},
+		},
+		{
+			name: "AllOrNothing",
+			mode: domain.AllOrNothingBatchMode,
+			rf: func(s sqlmock.Sqlmock) {
+				s.ExpectBegin()
+				s.ExpectPrepare(`^INSERT INTO "company" (.+) ON CONFLICT DO NOTHING RETURNING (.+)$`).
+					ExpectQuery().
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "type", "version"}).
+						AddRow(testUUID.String(), "1", domain.NonProfitType, 1).
+						AddRow(testUUID2.String(), "2", domain.CooperativeType, 1))
+				s.ExpectRollback()
+			},
+			wantErr: domain.NewError(domain.ErrConflict, "company 2 or its name already exists", nil),
+		},
+		{
+			name: "Failed",
+			mode: domain.BestEffortBatchMode,
+			rf: func(s sqlmock.Sqlmock) {
+				s.ExpectBegin()
+				s.ExpectPrepare(`^INSERT INTO "company" `).
+					ExpectQuery().
+					WillReturnError(testPqErr)
+				s.ExpectRollback()
+			},
+			wantErr: fmt.Errorf("SelectContext: %w",
+				domain.NewError(domain.ErrConflict, "company already exists", testPqErr)),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, dbMock, err := sqlmock.New()
+			require.NoError(t, err)
+			tt.rf(dbMock)
+
+			r := NewCompanyRepository(context.TODO(), sqlx.NewDb(db, "sqlmock"))
+			results, err := r.CreateBatch(context.TODO(), domain.CreateCompanies{Companies: companies, Mode: tt.mode})
 			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantResults, results)
+			assert.NoError(t, dbMock.ExpectationsWereMet())
 		})
 	}
 }
@@ -71,13 +216,18 @@ func TestPostgresCompanyDelete(t *testing.T) {
 		name    string
 		rf      registerFunc
 		uuid    uuid.UUID
+		version *int64
+		purge   bool
 		wantErr error
 	}{
 		{
 			name: "Success",
 			uuid: uuid.MustParse("10000000-0000-0000-0000-000000000000"),
 			rf: func(s sqlmock.Sqlmock) {
-				s.ExpectExec(`^DELETE FROM "company" WHERE \("id" = '10000000-0000-0000-0000-000000000000'\)$`).
+				s.ExpectPrepare(`^UPDATE "company" SET "deleted_at"=now\(\),"version"="version" \+ 1 `+
+					`WHERE \(\("deleted_at" IS NULL\) AND \("id" = \$1\) AND \("tenant_id" = \$2\)\)$`).
+					ExpectExec().
+					WithArgs("10000000-0000-0000-0000-000000000000", "").
 					WillReturnResult(driver.RowsAffected(1))
 			},
 			wantErr: nil,
@@ -86,11 +236,68 @@ func TestPostgresCompanyDelete(t *testing.T) {
 			name: "Failed",
 			uuid: uuid.Nil,
 			rf: func(s sqlmock.Sqlmock) {
-				s.ExpectExec("^DELETE (.+)").
+				s.ExpectPrepare("^UPDATE (.+)").
+					ExpectExec().
 					WillReturnError(testErr)
 			},
 			wantErr: fmt.Errorf("ExecContext: %w", testErr),
 		},
+		{
+			name: "NotFound",
+			uuid: testUUID,
+			rf: func(s sqlmock.Sqlmock) {
+				s.ExpectPrepare("^UPDATE (.+)").
+					ExpectExec().
+					WillReturnResult(driver.RowsAffected(0))
+			},
+			wantErr: domain.NewError(domain.ErrNotFound, "company does not exist", nil),
+		},
+		{
+			name:    "VersionMismatch",
+			uuid:    testUUID,
+			version: getPointer(int64(2)),
+			rf: func(s sqlmock.Sqlmock) {
+				s.ExpectPrepare(`^UPDATE "company" SET (.+) WHERE \(\("deleted_at" IS NULL\) `+
+					`AND \("id" = \$1\) AND \("tenant_id" = \$2\) AND \("version" = \$3\)\)$`).
+					ExpectExec().
+					WithArgs("10000000-0000-0000-0000-000000000000", "", 2).
+					WillReturnResult(driver.RowsAffected(0))
+				s.ExpectPrepare(`^SELECT "version" FROM "company" WHERE \(\("deleted_at" IS NULL\) AND (.+)\)$`).
+					ExpectQuery().
+					WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(3))
+			},
+			wantErr: domain.NewError(domain.ErrPreconditionFailed, "company version is 3", nil),
+		},
+		{
+			name:  "Purge",
+			uuid:  testUUID,
+			purge: true,
+			rf: func(s sqlmock.Sqlmock) {
+				s.ExpectPrepare(`^DELETE FROM "company" WHERE \(\("id" = \$1\) AND \("tenant_id" = \$2\)\)$`).
+					ExpectExec().
+					WithArgs("10000000-0000-0000-0000-000000000000", "").
+					WillReturnResult(driver.RowsAffected(1))
+			},
+			wantErr: nil,
+		},
+		{
+			name:    "PurgeVersionMismatch",
+			uuid:    testUUID,
+			version: getPointer(int64(2)),
+			purge:   true,
+			rf: func(s sqlmock.Sqlmock) {
+				s.ExpectPrepare(`^DELETE FROM "company" WHERE \(\("id" = \$1\) AND \("tenant_id" = \$2\) `+
+					`AND \("version" = \$3\)\)$`).
+					ExpectExec().
+					WithArgs("10000000-0000-0000-0000-000000000000", "", 2).
+					WillReturnResult(driver.RowsAffected(0))
+				s.ExpectPrepare(`^SELECT "version" FROM "company" WHERE \(\("id" = \$1\) AND \("tenant_id" = \$2\)\)$`).
+					ExpectQuery().
+					WithArgs("10000000-0000-0000-0000-000000000000", "").
+					WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(3))
+			},
+			wantErr: domain.NewError(domain.ErrPreconditionFailed, "company version is 3", nil),
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -101,7 +308,7 @@ func TestPostgresCompanyDelete(t *testing.T) {
 
 			sqlxDB := sqlx.NewDb(db, "sqlmock")
 			r := NewCompanyRepository(context.TODO(), sqlxDB)
-			err = r.Delete(context.TODO(), tt.uuid)
+			err = r.Delete(context.TODO(), tt.uuid, domain.DeleteCompany{Version: tt.version, Purge: tt.purge})
 			assert.Equal(t, tt.wantErr, err)
 		})
 	}
@@ -142,19 +349,101 @@ func TestPostgresCompanyPatch(t *testing.T) {
 					testUUID.String(),
 					"1", "2", 3, true, domain.SoleProprietorshipType,
 				)
-				s.ExpectQuery(`^UPDATE "company" SET "amount_of_employees"=3,"description"='2',"name"='1',"registered"=TRUE,"type"='Sole Proprietorship'`).
+				s.ExpectPrepare(`^UPDATE "company" SET "amount_of_employees"=\$1,"description"=\$2,"name"=\$3,"registered"=\$4,"type"=\$5,`).
+					ExpectQuery().
+					WithArgs(3, "2", "1", true, "Sole Proprietorship", "10000000-0000-0000-0000-000000000000", "").
 					WillReturnRows(rows)
 			},
 			wantErr: "",
 		},
 		{
-			name: "Failed",
-			uuid: uuid.Nil,
+			name: "Empty",
+			uuid: testUUID,
+			company: domain.Company{
+				ID:      testUUID,
+				Name:    "1",
+				Version: 2,
+			},
+			rf: func(s sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"id", "name", "version"}).
+					AddRow(testUUID.String(), "1", 2)
+				s.ExpectPrepare(`^SELECT (.+) FROM "company" `+
+					`WHERE \(\("deleted_at" IS NULL\) AND \("id" = \$1\) AND \("tenant_id" = \$2\)\)$`).
+					ExpectQuery().
+					WithArgs("10000000-0000-0000-0000-000000000000", "").
+					WillReturnRows(rows)
+			},
+			wantErr: "",
+		},
+		{
+			name: "EmptyNotFound",
+			uuid: testUUID,
+			rf: func(s sqlmock.Sqlmock) {
+				s.ExpectPrepare(`^SELECT (.+) FROM "company" `).
+					ExpectQuery().
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			wantErr: "GetContext: failed with resource not found: company does not exist: sql: no rows in result set",
+		},
+		{
+			name: "Version",
+			uuid: testUUID,
+			patchCompany: domain.PatchCompany{
+				Name:    getPointer("1"),
+				Version: getPointer(int64(2)),
+			},
+			company: domain.Company{
+				ID:      testUUID,
+				Name:    "1",
+				Version: 3,
+			},
 			rf: func(s sqlmock.Sqlmock) {
-				s.ExpectExec("^(.+)").
-					WillReturnError(errors.New("test error"))
+				rows := sqlmock.NewRows([]string{"id", "name", "version"}).
+					AddRow(testUUID.String(), "1", 3)
+				s.ExpectPrepare(`^UPDATE "company" SET "name"=\$1,"version"="version" \+ 1 `+
+					`WHERE \(\("deleted_at" IS NULL\) AND \("id" = \$2\) AND \("tenant_id" = \$3\) AND \("version" = \$4\)\) `+
+					`RETURNING (.+)$`).
+					ExpectQuery().
+					WithArgs("1", "10000000-0000-0000-0000-000000000000", "", 2).
+					WillReturnRows(rows)
+			},
+			wantErr: "",
+		},
+		{
+			name: "VersionMismatch",
+			uuid: testUUID,
+			patchCompany: domain.PatchCompany{
+				Name:    getPointer("1"),
+				Version: getPointer(int64(2)),
+			},
+			rf: func(s sqlmock.Sqlmock) {
+				s.ExpectPrepare(`^UPDATE "company" (.+) AND \("version" = \$4\)\) RETURNING (.+)$`).
+					ExpectQuery().
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				s.ExpectPrepare(`^SELECT "version" FROM "company" WHERE \(\("deleted_at" IS NULL\) AND \("id" = \$1\) `+
+					`AND \("tenant_id" = \$2\)\)$`).
+					ExpectQuery().
+					WithArgs("10000000-0000-0000-0000-000000000000", "").
+					WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(3))
+			},
+			wantErr: "failed with resource modified: company version is 3",
+		},
+		{
+			name: "VersionNotFound",
+			uuid: testUUID,
+			patchCompany: domain.PatchCompany{
+				Name:    getPointer("1"),
+				Version: getPointer(int64(2)),
+			},
+			rf: func(s sqlmock.Sqlmock) {
+				s.ExpectPrepare(`^UPDATE "company" `).
+					ExpectQuery().
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				s.ExpectPrepare(`^SELECT "version" FROM "company" `).
+					ExpectQuery().
+					WillReturnRows(sqlmock.NewRows([]string{"version"}))
 			},
-			wantErr: "cannot build query: goqu: no update values provided",
+			wantErr: "GetContext: failed with resource not found: company does not exist: sql: no rows in result set",
 		},
 	}
 	for _, tt := range tests {
@@ -178,6 +467,68 @@ func TestPostgresCompanyPatch(t *testing.T) {
 	}
 }
 
+func TestPostgresCompanyRestore(t *testing.T) {
+	testErr := errors.New("test error")
+	tests := []struct {
+		name    string
+		rf      registerFunc
+		company domain.Company
+		wantErr error
+	}{
+		{
+			name: "Success",
+			company: domain.Company{
+				ID:          testUUID,
+				Name:        "1",
+				CompanyType: domain.CooperativeType,
+				Version:     3,
+			},
+			rf: func(s sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"id", "name", "type", "version"}).
+					AddRow(testUUID.String(), "1", domain.CooperativeType, 3)
+				s.ExpectPrepare(`^UPDATE "company" SET "deleted_at"=NULL,"version"="version" \+ 1 `+
+					`WHERE \(\("id" = \$1\) AND \("deleted_at" IS NOT NULL\) AND \("tenant_id" = \$2\)\) RETURNING (.+)$`).
+					ExpectQuery().
+					WithArgs("10000000-0000-0000-0000-000000000000", "").
+					WillReturnRows(rows)
+			},
+			wantErr: nil,
+		},
+		{
+			name: "Failed",
+			rf: func(s sqlmock.Sqlmock) {
+				s.ExpectPrepare("^UPDATE (.+)").
+					ExpectQuery().
+					WillReturnError(testErr)
+			},
+			wantErr: fmt.Errorf("GetContext: %w", testErr),
+		},
+		{
+			name: "NotDeleted",
+			rf: func(s sqlmock.Sqlmock) {
+				s.ExpectPrepare("^UPDATE (.+)").
+					ExpectQuery().
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			wantErr: domain.NewError(domain.ErrNotFound, "deleted company does not exist", sql.ErrNoRows),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, dbMock, err := sqlmock.New()
+			assert.NoError(t, err)
+			tt.rf(dbMock)
+			dbMock.ExpectClose()
+
+			sqlxDB := sqlx.NewDb(db, "sqlmock")
+			r := NewCompanyRepository(context.TODO(), sqlxDB)
+			company, err := r.Restore(context.TODO(), testUUID)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.company, company)
+		})
+	}
+}
+
 func TestPostgresCompanyGetByID(t *testing.T) {
 	testErr := errors.New("test error")
 	tests := []struct {
@@ -206,7 +557,10 @@ func TestPostgresCompanyGetByID(t *testing.T) {
 					testUUID.String(),
 					"1", "2", 3, true, domain.CooperativeType,
 				)
-				s.ExpectQuery(`^SELECT \* FROM "company" WHERE \("id" = '10000000-0000-0000-0000-000000000000'\)`).
+				s.ExpectPrepare(`^SELECT "id", "name", "description", "amount_of_employees", "registered", "type", "version" FROM "company" `+
+					`WHERE \(\("deleted_at" IS NULL\) AND \("id" = \$1\) AND \("tenant_id" = \$2\)\)`).
+					ExpectQuery().
+					WithArgs("10000000-0000-0000-0000-000000000000", "").
 					WillReturnRows(rows)
 			},
 			wantErr: nil,
@@ -215,10 +569,22 @@ func TestPostgresCompanyGetByID(t *testing.T) {
 			name: "Failed",
 			uuid: uuid.Nil,
 			rf: func(s sqlmock.Sqlmock) {
-				s.ExpectQuery("^(.+)").
+				s.ExpectPrepare("^(.+)").
+					ExpectQuery().
 					WillReturnError(testErr)
 			},
-			wantErr: fmt.Errorf("QueryRowxContext: %w", testErr),
+			wantErr: fmt.Errorf("GetContext: %w", testErr),
+		},
+		{
+			name: "NotFound",
+			uuid: testUUID,
+			rf: func(s sqlmock.Sqlmock) {
+				s.ExpectPrepare("^(.+)").
+					ExpectQuery().
+					WillReturnError(sql.ErrNoRows)
+			},
+			wantErr: fmt.Errorf("GetContext: %w",
+				domain.NewError(domain.ErrNotFound, "company does not exist", sql.ErrNoRows)),
 		},
 	}
 	for _, tt := range tests {
@@ -237,6 +603,354 @@ func TestPostgresCompanyGetByID(t *testing.T) {
 	}
 }
 
+func TestPostgresCompanyGetByIDAsOf(t *testing.T) {
+	at := time.Date(2026, 3, 31, 12, 0, 0, 0, time.FixedZone("CEST", 2*60*60))
+	tests := []struct {
+		name    string
+		rf      registerFunc
+		company domain.Company
+		wantErr error
+	}{
+		{
+			name: "Success",
+			company: domain.Company{
+				ID:          testUUID,
+				Name:        "1",
+				CompanyType: domain.CooperativeType,
+				Version:     2,
+			},
+			rf: func(s sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"id", "name", "type", "version"}).
+					AddRow(testUUID.String(), "1", domain.CooperativeType, 2)
+				s.ExpectPrepare(`^SELECT "id", "name", "description", "amount_of_employees", "registered", "type", "version" `+
+					`FROM "company_history" WHERE \(\("id" = \$1\) AND \("tenant_id" = \$2\) `+
+					`AND \("valid_from" <= \$3\) AND \("valid_to" > \$4\)\)$`).
+					ExpectQuery().
+					WithArgs("10000000-0000-0000-0000-000000000000", "", at.UTC(), at.UTC()).
+					WillReturnRows(rows)
+			},
+			wantErr: nil,
+		},
+		{
+			name: "NotFound",
+			rf: func(s sqlmock.Sqlmock) {
+				s.ExpectPrepare("^SELECT (.+)").
+					ExpectQuery().
+					WillReturnError(sql.ErrNoRows)
+			},
+			wantErr: domain.NewError(domain.ErrNotFound, "company did not exist at that time", sql.ErrNoRows),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, dbMock, err := sqlmock.New()
+			require.NoError(t, err)
+			tt.rf(dbMock)
+
+			r := NewCompanyRepository(context.TODO(), sqlx.NewDb(db, "sqlmock"))
+			company, err := r.GetByIDAsOf(context.TODO(), testUUID, at)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.company, company)
+		})
+	}
+}
+
+func TestPostgresCompanyExport(t *testing.T) {
+	testErr := errors.New("test error")
+	columns := []string{"id", "name", "type", "version"}
+	fullFetch := sqlmock.NewRows(columns)
+	for i := 0; i < exportFetchSize; i++ {
+		fullFetch.AddRow(uuid.New().String(), "a", domain.NonProfitType, 1)
+	}
+
+	tests := []struct {
+		name         string
+		rf           registerFunc
+		q            domain.ExportCompanies
+		fnErr        error
+		wantExported int
+		wantErr      error
+	}{
+		{
+			name: "Success",
+			q: domain.ExportCompanies{
+				CompanyType: getPointer(domain.NonProfitType),
+				Registered:  getPointer(false),
+			},
+			rf: func(s sqlmock.Sqlmock) {
+				s.ExpectBegin()
+				s.ExpectExec(`^DECLARE company_export NO SCROLL CURSOR FOR `+
+					`SELECT "id", "name", "description", "amount_of_employees", "registered", "type", "version" FROM "company" `+
+					`WHERE \(\("deleted_at" IS NULL\) AND \("tenant_id" = \$1\) AND \("type" = \$2\) AND \("registered" IS FALSE\)\) `+
+					`ORDER BY "id" ASC$`).
+					WithArgs("", "NonProfit").
+					WillReturnResult(driver.ResultNoRows)
+				s.ExpectQuery(`^FETCH FORWARD 500 FROM company_export$`).
+					WillReturnRows(fullFetch)
+				s.ExpectQuery(`^FETCH FORWARD 500 FROM company_export$`).
+					WillReturnRows(sqlmock.NewRows(columns).AddRow(testUUID.String(), "b", domain.NonProfitType, 1))
+				s.ExpectExec(`^CLOSE company_export$`).
+					WillReturnResult(driver.ResultNoRows)
+				s.ExpectCommit()
+			},
+			wantExported: exportFetchSize + 1,
+		},
+		{
+			name:  "Stopped",
+			fnErr: testErr,
+			rf: func(s sqlmock.Sqlmock) {
+				s.ExpectBegin()
+				s.ExpectExec(`^DECLARE company_export NO SCROLL CURSOR FOR SELECT (.+) ` +
+					`WHERE \(\("deleted_at" IS NULL\) AND \("tenant_id" = \$1\)\) ORDER BY "id" ASC$`).
+					WillReturnResult(driver.ResultNoRows)
+				s.ExpectQuery(`^FETCH FORWARD 500 FROM company_export$`).
+					WillReturnRows(sqlmock.NewRows(columns).AddRow(testUUID.String(), "b", domain.NonProfitType, 1))
+				s.ExpectRollback()
+			},
+			wantExported: 1,
+			wantErr:      testErr,
+		},
+		{
+			name: "Failed",
+			rf: func(s sqlmock.Sqlmock) {
+				s.ExpectBegin()
+				s.ExpectExec(`^DECLARE company_export `).
+					WillReturnError(testErr)
+				s.ExpectRollback()
+			},
+			wantErr: fmt.Errorf("failed to declare cursor: %w", testErr),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, dbMock, err := sqlmock.New()
+			require.NoError(t, err)
+			tt.rf(dbMock)
+
+			exported := 0
+			r := NewCompanyRepository(context.TODO(), sqlx.NewDb(db, "sqlmock"))
+			err = r.Export(context.TODO(), tt.q, func(domain.Company) error {
+				exported++
+				return tt.fnErr
+			})
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantExported, exported)
+			assert.NoError(t, dbMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPostgresCompanyList(t *testing.T) {
+	testErr := errors.New("test error")
+	secondUUID := uuid.MustParse("20000000-0000-0000-0000-000000000000")
+	columns := []string{"id", "name", "description", "amount_of_employees", "registered", "type"}
+	nameCursor, err := keyset.NewCursor(domain.ListCompanies{SortBy: domain.SortByName}, domain.Company{ID: testUUID, Name: "a"})
+	require.NoError(t, err)
+	tests := []struct {
+		name     string
+		rf       registerFunc
+		q        domain.ListCompanies
+		wantPage domain.CompanyPage
+		wantErr  error
+	}{
+		{
+			name: "Success",
+			q: domain.ListCompanies{
+				CompanyType:  getPointer(domain.CooperativeType),
+				Registered:   getPointer(true),
+				MinEmployees: getPointer(uint32(1)),
+				MaxEmployees: getPointer(uint32(10)),
+				NamePrefix:   "a_",
+				SortBy:       domain.SortByAmountOfEmployees,
+				Desc:         true,
+				Limit:        2,
+			},
+			wantPage: domain.CompanyPage{
+				Companies: []domain.Company{},
+			},
+			rf: func(s sqlmock.Sqlmock) {
+				s.ExpectPrepare(`^SELECT "id", "name", "description", "amount_of_employees", "registered", "type", "version" FROM "company" `+
+					`WHERE \(\("deleted_at" IS NULL\) AND \("tenant_id" = \$1\) AND \("type" = \$2\) AND \("registered" IS TRUE\) `+
+					`AND \("amount_of_employees" >= \$3\) AND \("amount_of_employees" <= \$4\) AND \("name" ILIKE \$5\)\) `+
+					`ORDER BY "amount_of_employees" DESC, "id" DESC LIMIT \$6$`).
+					ExpectQuery().
+					WithArgs("", "Cooperative", 1, 10, `a\_%`, 3).
+					WillReturnRows(sqlmock.NewRows(columns))
+			},
+		},
+		{
+			name: "NextPage",
+			q: domain.ListCompanies{
+				SortBy: domain.SortByName,
+				Limit:  1,
+				Cursor: nameCursor,
+			},
+			wantPage: domain.CompanyPage{
+				Companies: []domain.Company{{
+					ID:          secondUUID,
+					Name:        "b",
+					CompanyType: domain.CooperativeType,
+				}},
+				NextCursor: "eyJzIjoibmFtZSIsInYiOiJiIiwiaSI6IjIwMDAwMDAwLTAwMDAtMDAwMC0wMDAwLTAwMDAwMDAwMDAwMCJ9",
+			},
+			rf: func(s sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows(columns).
+					AddRow(secondUUID.String(), "b", "", 0, false, domain.CooperativeType).
+					AddRow(uuid.NewString(), "c", "", 0, false, domain.CooperativeType)
+				s.ExpectPrepare(`^SELECT (.+) FROM "company" `+
+					`WHERE \(\("deleted_at" IS NULL\) AND \("tenant_id" = \$1\) AND \("name", "id"\) > \(\$2, \$3\)\) `+
+					`ORDER BY "name" ASC, "id" ASC LIMIT \$4$`).
+					ExpectQuery().
+					WithArgs("", "a", "10000000-0000-0000-0000-000000000000", 2).
+					WillReturnRows(rows)
+			},
+		},
+		{
+			name: "SortByID",
+			q: domain.ListCompanies{
+				SortBy: domain.SortByID,
+				Desc:   true,
+				Limit:  10,
+			},
+			wantPage: domain.CompanyPage{
+				Companies: []domain.Company{},
+			},
+			rf: func(s sqlmock.Sqlmock) {
+				s.ExpectPrepare(`^SELECT (.+) FROM "company" WHERE \(\("deleted_at" IS NULL\) AND \("tenant_id" = \$1\)\) `+
+					`ORDER BY "id" DESC LIMIT \$2$`).
+					ExpectQuery().
+					WithArgs("", 11).
+					WillReturnRows(sqlmock.NewRows(columns))
+			},
+		},
+		{
+			name:    "CursorOfOtherSort",
+			q:       domain.ListCompanies{SortBy: domain.SortByName, Desc: true, Cursor: nameCursor},
+			rf:      func(s sqlmock.Sqlmock) {},
+			wantErr: domain.NewError(domain.ErrBadRequest, "invalid cursor", nil),
+		},
+		{
+			name:    "InvalidCursor",
+			q:       domain.ListCompanies{SortBy: domain.SortByName, Cursor: "not a cursor"},
+			rf:      func(s sqlmock.Sqlmock) {},
+			wantErr: domain.NewError(domain.ErrBadRequest, "invalid cursor", nil),
+		},
+		{
+			name:    "UnknownSort",
+			q:       domain.ListCompanies{SortBy: "description"},
+			rf:      func(s sqlmock.Sqlmock) {},
+			wantErr: domain.NewError(domain.ErrBadRequest, "unknown sort field", nil),
+		},
+		{
+			name: "Failed",
+			q:    domain.ListCompanies{SortBy: domain.SortByName},
+			rf: func(s sqlmock.Sqlmock) {
+				s.ExpectPrepare("^(.+)").
+					ExpectQuery().
+					WillReturnError(testErr)
+			},
+			wantErr: fmt.Errorf("SelectContext: %w", testErr),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, dbMock, err := sqlmock.New()
+			require.NoError(t, err)
+			tt.rf(dbMock)
+
+			sqlxDB := sqlx.NewDb(db, "sqlmock")
+			r := NewCompanyRepository(context.TODO(), sqlxDB)
+			page, err := r.List(context.TODO(), tt.q)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantPage, page)
+			assert.NoError(t, dbMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPostgresCompanySearch(t *testing.T) {
+	testErr := errors.New("test error")
+	tests := []struct {
+		name        string
+		rf          registerFunc
+		q           domain.SearchCompanies
+		wantResults []domain.CompanySearchResult
+		wantErr     error
+	}{
+		{
+			name: "Success",
+			q:    domain.SearchCompanies{Query: `"open source" dat*`, Limit: 5},
+			wantResults: []domain.CompanySearchResult{{
+				Company: domain.Company{
+					ID:          testUUID,
+					Name:        "1",
+					Description: "open source data",
+					CompanyType: domain.CooperativeType,
+				},
+				Rank:    0.5,
+				Snippet: "<mark>open</mark> <mark>source</mark> <mark>data</mark>",
+			}},
+			rf: func(s sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{
+					"id", "name", "description", "amount_of_employees", "registered", "type", "rank", "snippet",
+				})
+				rows.AddRow(
+					testUUID.String(), "1", "open source data", 0, false, domain.CooperativeType,
+					0.5, "<mark>open</mark> <mark>source</mark> <mark>data</mark>",
+				)
+				s.ExpectPrepare(`^SELECT "id", "name", "description", "amount_of_employees", "registered", "type", "version", `+
+					`ts_rank\("search", "query"\) AS "rank", ts_headline\(\$1, coalesce\("description", ''\), "query", \$2\) AS "snippet" `+
+					`FROM "company", to_tsquery\(\$3, \$4\) AS "query" `+
+					`WHERE \("search" @@ "query" AND \("deleted_at" IS NULL\) AND \("tenant_id" = \$5\)\) `+
+					`ORDER BY "rank" DESC, "id" ASC LIMIT \$6$`).
+					ExpectQuery().
+					WithArgs(searchConfig, headlineOptions, searchConfig, "(open <-> source) & dat:*", "", 5).
+					WillReturnRows(rows)
+			},
+		},
+		{
+			name:    "NoWords",
+			q:       domain.SearchCompanies{Query: "!*"},
+			rf:      func(s sqlmock.Sqlmock) {},
+			wantErr: domain.NewError(domain.ErrBadRequest, "search query has no words", nil),
+		},
+		{
+			name: "Failed",
+			q:    domain.SearchCompanies{Query: "data"},
+			rf: func(s sqlmock.Sqlmock) {
+				s.ExpectPrepare("^(.+)").
+					ExpectQuery().
+					WillReturnError(testErr)
+			},
+			wantErr: fmt.Errorf("SelectContext: %w", testErr),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, dbMock, err := sqlmock.New()
+			require.NoError(t, err)
+			tt.rf(dbMock)
+
+			sqlxDB := sqlx.NewDb(db, "sqlmock")
+			r := NewCompanyRepository(context.TODO(), sqlxDB)
+			results, err := r.Search(context.TODO(), tt.q)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantResults, results)
+			assert.NoError(t, dbMock.ExpectationsWereMet())
+		})
+	}
+}
+
+var testUUID = uuid.MustParse("10000000-0000-0000-0000-000000000000")
+
+var testPqErr = &pq.Error{Code: "23505", Message: "duplicate key value violates unique constraint"}
+
+var testNamePqErr = &pq.Error{
+	Code:       "23505",
+	Message:    "duplicate key value violates unique constraint",
+	Constraint: uniqueNameIndex,
+}
+
 type registerFunc func(sqlmock.Sqlmock)
 
 // Generic to get pointer
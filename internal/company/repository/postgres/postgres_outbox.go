@@ -0,0 +1,181 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// outboxLockID is the advisory lock key held while dispatching,
+// so concurrent relays can't send events out of order
+const outboxLockID = 7336512
+
+type companyOutboxRepository struct {
+	db    *sqlx.DB
+	stmts *statements
+}
+
+// Dispatch implements domain.CompanyOutboxRepository
+func (r *companyOutboxRepository) Dispatch(
+	ctx context.Context,
+	limit int,
+	send func(context.Context, domain.CompanyEvent) error,
+) (int, error) {
+	var sent []int64
+	var sendErr error
+
+	err := withTx(ctx, r.db, func(ctx context.Context) error {
+		locked, err := r.lock(ctx)
+		if err != nil || !locked {
+			return err
+		}
+
+		q, args, err := dialect.From("company_outbox").
+			Prepared(true).
+			Select("id", "payload").
+			Order(goqu.C("id").Asc()).
+			Limit(uint(limit)).
+			ToSQL()
+		if err != nil {
+			return fmt.Errorf("cannot build query: %w", err)
+		}
+
+		var messages []OutboxMessage
+		if err := r.stmts.selectAll(ctx, r.db, &messages, q, args); err != nil {
+			return fmt.Errorf("SelectContext: %w", err)
+		}
+
+		for _, m := range messages {
+			var event domain.CompanyEvent
+			if err := json.Unmarshal(m.Payload, &event); err != nil {
+				sendErr = fmt.Errorf("failed to decode outbox message %d: %w", m.ID, err)
+			} else if err := send(ctx, event); err != nil {
+				sendErr = fmt.Errorf("failed to send outbox message %d: %w", m.ID, err)
+			}
+
+			if sendErr != nil {
+				if err := r.markFailed(ctx, m.ID, sendErr); err != nil {
+					return err
+				}
+				break
+			}
+			sent = append(sent, m.ID)
+		}
+
+		return r.remove(ctx, sent)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return len(sent), sendErr
+}
+
+// Pending implements domain.CompanyOutboxRepository
+func (r *companyOutboxRepository) Pending(ctx context.Context) (int, error) {
+	q, args, err := dialect.From("company_outbox").Prepared(true).Select(goqu.COUNT("*")).ToSQL()
+	if err != nil {
+		return 0, fmt.Errorf("cannot build query: %w", err)
+	}
+
+	var n int
+	if err := r.stmts.get(ctx, r.db, &n, q, args); err != nil {
+		return 0, fmt.Errorf("GetContext: %w", err)
+	}
+
+	return n, nil
+}
+
+func (r *companyOutboxRepository) lock(ctx context.Context) (bool, error) {
+	q, args, err := dialect.Select(goqu.Func("pg_try_advisory_xact_lock", outboxLockID)).Prepared(true).ToSQL()
+	if err != nil {
+		return false, fmt.Errorf("cannot build query: %w", err)
+	}
+
+	var locked bool
+	if err := r.stmts.get(ctx, r.db, &locked, q, args); err != nil {
+		return false, fmt.Errorf("GetContext: %w", err)
+	}
+
+	return locked, nil
+}
+
+func (r *companyOutboxRepository) markFailed(ctx context.Context, id int64, sendErr error) error {
+	q, args, err := dialect.Update("company_outbox").
+		Prepared(true).
+		Set(goqu.Record{
+			"attempts":   goqu.L("attempts + 1"),
+			"last_error": sendErr.Error(),
+		}).
+		Where(goqu.Ex{"id": id}).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("cannot build query: %w", err)
+	}
+
+	if _, err := r.stmts.exec(ctx, r.db, q, args); err != nil {
+		return fmt.Errorf("ExecContext: %w", err)
+	}
+
+	return nil
+}
+
+func (r *companyOutboxRepository) remove(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	// the IDs are passed as one array, so that the statement is the same for any number of them
+	q, args, err := dialect.Delete("company_outbox").
+		Prepared(true).
+		Where(goqu.L("? = ANY(?)", goqu.C("id"), pq.Array(ids))).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("cannot build query: %w", err)
+	}
+
+	if _, err := r.stmts.exec(ctx, r.db, q, args); err != nil {
+		return fmt.Errorf("ExecContext: %w", err)
+	}
+
+	return nil
+}
+
+// addOutboxEvent stores the event in the outbox using the transaction carried by ctx
+func addOutboxEvent(ctx context.Context, db *sqlx.DB, stmts *statements, event domain.CompanyEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	q, args, err := dialect.Insert("company_outbox").
+		Prepared(true).
+		Rows(goqu.Record{
+			"company_id": event.ID,
+			"payload":    string(payload),
+		}).
+		ToSQL()
+	if err != nil {
+		return fmt.Errorf("cannot build query: %w", err)
+	}
+
+	if _, err := stmts.exec(ctx, db, q, args); err != nil {
+		return fmt.Errorf("ExecContext: %w", err)
+	}
+
+	return nil
+}
+
+// NewCompanyOutboxRepository creates an object that represent the domain.CompanyOutboxRepository interface
+func NewCompanyOutboxRepository(db *sqlx.DB) domain.CompanyOutboxRepository {
+	return &companyOutboxRepository{
+		db:    db,
+		stmts: newStatements(),
+	}
+}
@@ -0,0 +1,139 @@
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+)
+
+const testEventPayload = `{"Action":"delete","ID":"10000000-0000-0000-0000-000000000000","State":{}}`
+
+func TestPostgresOutboxDispatch(t *testing.T) {
+	testErr := errors.New("test error")
+	tests := []struct {
+		name     string
+		rf       registerFunc
+		sendErr  error
+		wantSent int
+		wantErr  string
+	}{
+		{
+			name: "Success",
+			rf: func(s sqlmock.Sqlmock) {
+				s.ExpectBegin()
+				s.ExpectPrepare(`^SELECT pg_try_advisory_xact_lock\(\$1\)$`).
+					ExpectQuery().
+					WithArgs(7336512).
+					WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+				s.ExpectPrepare(`^SELECT "id", "payload" FROM "company_outbox" ORDER BY "id" ASC LIMIT \$1$`).
+					ExpectQuery().
+					WithArgs(10).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "payload"}).
+						AddRow(1, []byte(testEventPayload)).
+						AddRow(2, []byte(testEventPayload)))
+				s.ExpectPrepare(`^DELETE FROM "company_outbox" WHERE "id" = ANY\(\$1\)$`).
+					ExpectExec().
+					WithArgs("{1,2}").
+					WillReturnResult(driver.RowsAffected(2))
+				s.ExpectCommit()
+			},
+			wantSent: 2,
+		},
+		{
+			name: "Locked by another relay",
+			rf: func(s sqlmock.Sqlmock) {
+				s.ExpectBegin()
+				s.ExpectPrepare(`^SELECT pg_try_advisory_xact_lock`).
+					ExpectQuery().
+					WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(false))
+				s.ExpectCommit()
+			},
+			wantSent: 0,
+		},
+		{
+			name:    "Send failed",
+			sendErr: testErr,
+			rf: func(s sqlmock.Sqlmock) {
+				s.ExpectBegin()
+				s.ExpectPrepare(`^SELECT pg_try_advisory_xact_lock`).
+					ExpectQuery().
+					WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+				s.ExpectPrepare(`^SELECT "id", "payload" FROM "company_outbox"`).
+					ExpectQuery().
+					WillReturnRows(sqlmock.NewRows([]string{"id", "payload"}).
+						AddRow(1, []byte(testEventPayload)))
+				s.ExpectPrepare(`^UPDATE "company_outbox" SET "attempts"=attempts \+ 1,"last_error"=\$1 WHERE \("id" = \$2\)$`).
+					ExpectExec().
+					WithArgs("failed to send outbox message 1: test error", 1).
+					WillReturnResult(driver.RowsAffected(1))
+				s.ExpectCommit()
+			},
+			wantSent: 0,
+			wantErr:  "failed to send outbox message 1: test error",
+		},
+		{
+			name: "Select failed",
+			rf: func(s sqlmock.Sqlmock) {
+				s.ExpectBegin()
+				s.ExpectPrepare(`^SELECT pg_try_advisory_xact_lock`).
+					ExpectQuery().
+					WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+				s.ExpectPrepare(`^SELECT "id", "payload" FROM "company_outbox"`).
+					ExpectQuery().
+					WillReturnError(testErr)
+				s.ExpectRollback()
+			},
+			wantSent: 0,
+			wantErr:  "SelectContext: test error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, dbMock, err := sqlmock.New()
+			require.NoError(t, err)
+			tt.rf(dbMock)
+
+			var events []domain.CompanyEvent
+			send := func(_ context.Context, e domain.CompanyEvent) error {
+				events = append(events, e)
+				return tt.sendErr
+			}
+
+			r := NewCompanyOutboxRepository(sqlx.NewDb(db, "sqlmock"))
+			sent, err := r.Dispatch(context.TODO(), 10, send)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				assert.Equal(t, tt.wantErr, err.Error())
+			}
+			assert.Equal(t, tt.wantSent, sent)
+			for _, e := range events {
+				assert.Equal(t, domain.DeleteEventActionType, e.Action)
+				assert.Equal(t, testUUID, e.ID)
+			}
+			assert.NoError(t, dbMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPostgresOutboxPending(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	require.NoError(t, err)
+	dbMock.ExpectPrepare(`^SELECT COUNT\(\*\) FROM "company_outbox"$`).
+		ExpectQuery().
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(42))
+
+	r := NewCompanyOutboxRepository(sqlx.NewDb(db, "sqlmock"))
+	n, err := r.Pending(context.TODO())
+	require.NoError(t, err)
+	assert.Equal(t, 42, n)
+}
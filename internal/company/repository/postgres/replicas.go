@@ -0,0 +1,203 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog"
+
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+)
+
+// Replicas routes the company reads to the read replicas of the primary database, round robin.
+// The reads go to the primary in a transaction, if no replica is healthy, and for the stickiness window
+// after the mutation of the same client, so that the client reads its own writes despite the replication lag.
+type Replicas struct {
+	dbs        []*sqlx.DB
+	stickiness time.Duration
+	log        zerolog.Logger
+	now        func() time.Time
+
+	mu      sync.Mutex
+	healthy []bool
+	next    int
+	// writes holds the time of the last mutation of the clients within the stickiness window
+	writes map[string]time.Time
+}
+
+// Run checks the health of the replicas every interval until ctx is done
+func (r *Replicas) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		r.check(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check pings the replicas and forgets the clients whose stickiness window has passed
+func (r *Replicas) check(ctx context.Context) {
+	for i, db := range r.dbs {
+		err := db.PingContext(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		r.setHealth(i, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for client, t := range r.writes {
+		if !r.now().Before(t.Add(r.stickiness)) {
+			delete(r.writes, client)
+		}
+	}
+}
+
+// setHealth marks the replica i healthy if err is nil and unhealthy otherwise
+func (r *Replicas) setHealth(i int, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	healthy := err == nil
+	if r.healthy[i] == healthy {
+		return
+	}
+	r.healthy[i] = healthy
+
+	if healthy {
+		r.log.Info().Int("replica", i).Msg("replica is healthy again")
+	} else {
+		r.log.Warn().Err(err).Int("replica", i).Msg("replica is unhealthy, its reads go to the primary")
+	}
+}
+
+// pick returns the replica the reads of ctx go to, false if they go to the primary
+func (r *Replicas) pick(ctx context.Context) (int, bool) {
+	if r == nil {
+		return 0, false
+	}
+	if _, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return 0, false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if client := domain.ClientFromContext(ctx); client != "" {
+		if t, ok := r.writes[client]; ok {
+			if r.now().Before(t.Add(r.stickiness)) {
+				return 0, false
+			}
+			delete(r.writes, client)
+		}
+	}
+
+	for n := 0; n < len(r.dbs); n++ {
+		i := (r.next + n) % len(r.dbs)
+		if r.healthy[i] {
+			r.next = i + 1
+			return i, true
+		}
+	}
+
+	return 0, false
+}
+
+// wrote starts the stickiness window of the client of ctx
+func (r *Replicas) wrote(ctx context.Context) {
+	if r == nil || r.stickiness <= 0 {
+		return
+	}
+
+	client := domain.ClientFromContext(ctx)
+	if client == "" {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.writes[client] = r.now()
+}
+
+// noFallback wraps the error of the read that must not be retried on the primary
+type noFallback struct {
+	err error
+}
+
+func (e noFallback) Error() string {
+	return e.err.Error()
+}
+
+func (e noFallback) Unwrap() error {
+	return e.err
+}
+
+// replicaFailed tells whether the read failed because of the replica rather than the request
+func replicaFailed(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+
+	var domainErr *domain.Error
+	return !errors.As(err, &domainErr) || errors.Is(err, domain.ErrUnavailable)
+}
+
+// read runs fn on the replica picked for ctx or on primary. If the replica fails, it's marked unhealthy
+// until the next health check and fn is retried on primary, unless the error is wrapped in noFallback.
+func (r *Replicas) read(ctx context.Context, primary *sqlx.DB, fn func(db *sqlx.DB) error) error {
+	err := r.readFallback(ctx, primary, fn)
+
+	var nf noFallback
+	if errors.As(err, &nf) {
+		return nf.err
+	}
+
+	return err
+}
+
+func (r *Replicas) readFallback(ctx context.Context, primary *sqlx.DB, fn func(db *sqlx.DB) error) error {
+	i, ok := r.pick(ctx)
+	if !ok {
+		return fn(primary)
+	}
+
+	err := fn(r.dbs[i])
+	var nf noFallback
+	if !replicaFailed(ctx, err) || errors.As(err, &nf) {
+		return err
+	}
+
+	r.setHealth(i, err)
+
+	return fn(primary)
+}
+
+// NewReplicas routes the reads to dbs, the reads of the client go to the primary for stickiness after its mutation.
+// The replicas are considered healthy until Run checks them.
+func NewReplicas(dbs []*sqlx.DB, stickiness time.Duration, log zerolog.Logger) *Replicas {
+	healthy := make([]bool, len(dbs))
+	for i := range healthy {
+		healthy[i] = true
+	}
+
+	return &Replicas{
+		dbs:        dbs,
+		stickiness: stickiness,
+		log:        log,
+		now:        time.Now,
+		healthy:    healthy,
+		writes:     map[string]time.Time{},
+	}
+}
@@ -0,0 +1,149 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+)
+
+const testStickiness = 5 * time.Second
+
+// newTestReplicas returns the mocks of the primary and replica databases and the replicas routing the reads
+// of the repository, their clock is advanced by the returned func
+func newTestReplicas(t *testing.T) (sqlmock.Sqlmock, sqlmock.Sqlmock, *Replicas, domain.CompanyRepository, func(time.Duration)) {
+	primary, primaryMock, err := sqlmock.New()
+	require.NoError(t, err)
+	replica, replicaMock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	replicas := NewReplicas([]*sqlx.DB{sqlx.NewDb(replica, "sqlmock")}, testStickiness, zerolog.Nop())
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	replicas.now = func() time.Time { return now }
+
+	r := NewReplicatedCompanyRepository(context.TODO(), sqlx.NewDb(primary, "sqlmock"), replicas)
+
+	return primaryMock, replicaMock, replicas, r, func(d time.Duration) { now = now.Add(d) }
+}
+
+const getByIDQuery = `^SELECT (.+) FROM "company"`
+
+func companyRows() *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"id", "name", "description", "amount_of_employees", "registered", "type"})
+	return rows.AddRow(testUUID.String(), "1", "2", 3, true, domain.CooperativeType)
+}
+
+func TestReplicas_ReadFromReplica(t *testing.T) {
+	primaryMock, replicaMock, _, r, _ := newTestReplicas(t)
+	replicaMock.ExpectPrepare(getByIDQuery).ExpectQuery().WillReturnRows(companyRows())
+
+	company, err := r.GetByID(context.TODO(), testUUID)
+	assert.NoError(t, err)
+	assert.Equal(t, testUUID, company.ID)
+
+	assert.NoError(t, primaryMock.ExpectationsWereMet())
+	assert.NoError(t, replicaMock.ExpectationsWereMet())
+}
+
+func TestReplicas_ReadYourWrites(t *testing.T) {
+	primaryMock, replicaMock, _, r, advance := newTestReplicas(t)
+	ctx := domain.ContextWithClient(context.TODO(), "client")
+
+	primaryMock.ExpectPrepare(`^UPDATE "company" SET "deleted_at"=now\(\)`).
+		ExpectExec().
+		WillReturnResult(driver.RowsAffected(1))
+	primaryMock.ExpectPrepare(getByIDQuery).ExpectQuery().WillReturnRows(companyRows())
+	replicaMock.ExpectPrepare(getByIDQuery).ExpectQuery().WillReturnRows(companyRows())
+	replicaMock.ExpectQuery(getByIDQuery).WillReturnRows(companyRows())
+
+	assert.NoError(t, r.Delete(ctx, testUUID, domain.DeleteCompany{}))
+
+	// only the client that wrote reads from the primary within the window
+	_, err := r.GetByID(ctx, testUUID)
+	assert.NoError(t, err)
+	_, err = r.GetByID(domain.ContextWithClient(context.TODO(), "other"), testUUID)
+	assert.NoError(t, err)
+
+	advance(testStickiness)
+	_, err = r.GetByID(ctx, testUUID)
+	assert.NoError(t, err)
+
+	assert.NoError(t, primaryMock.ExpectationsWereMet())
+	assert.NoError(t, replicaMock.ExpectationsWereMet())
+}
+
+func TestReplicas_FallbackToPrimary(t *testing.T) {
+	primaryMock, replicaMock, replicas, r, _ := newTestReplicas(t)
+	replicaMock.ExpectPrepare(getByIDQuery).ExpectQuery().WillReturnError(errors.New("connection refused"))
+	primaryMock.ExpectPrepare(getByIDQuery).ExpectQuery().WillReturnRows(companyRows())
+	primaryMock.ExpectQuery(getByIDQuery).WillReturnRows(companyRows())
+
+	_, err := r.GetByID(context.TODO(), testUUID)
+	assert.NoError(t, err)
+	assert.Equal(t, []bool{false}, replicas.healthy)
+
+	// the unhealthy replica is skipped until the health check
+	_, err = r.GetByID(context.TODO(), testUUID)
+	assert.NoError(t, err)
+
+	assert.NoError(t, primaryMock.ExpectationsWereMet())
+	assert.NoError(t, replicaMock.ExpectationsWereMet())
+}
+
+func TestReplicas_NotFoundOnReplica(t *testing.T) {
+	primaryMock, replicaMock, replicas, r, _ := newTestReplicas(t)
+	replicaMock.ExpectPrepare(getByIDQuery).ExpectQuery().WillReturnError(sql.ErrNoRows)
+
+	_, err := r.GetByID(context.TODO(), testUUID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	assert.Equal(t, []bool{true}, replicas.healthy)
+
+	assert.NoError(t, primaryMock.ExpectationsWereMet())
+	assert.NoError(t, replicaMock.ExpectationsWereMet())
+}
+
+func TestReplicas_ExportNotRestarted(t *testing.T) {
+	primaryMock, replicaMock, _, r, _ := newTestReplicas(t)
+	testErr := errors.New("test error")
+
+	replicaMock.ExpectBegin()
+	replicaMock.ExpectExec("^DECLARE company_export").WillReturnResult(driver.ResultNoRows)
+	replicaMock.ExpectQuery("^FETCH FORWARD").WillReturnRows(companyRows())
+	replicaMock.ExpectRollback()
+
+	err := r.Export(context.TODO(), domain.ExportCompanies{}, func(domain.Company) error {
+		return testErr
+	})
+	assert.Equal(t, testErr, err)
+
+	assert.NoError(t, primaryMock.ExpectationsWereMet())
+	assert.NoError(t, replicaMock.ExpectationsWereMet())
+}
+
+func TestReplicas_Check(t *testing.T) {
+	replica, replicaMock, err := sqlmock.New()
+	require.NoError(t, err)
+	replicaMock.ExpectClose()
+
+	replicas := NewReplicas([]*sqlx.DB{sqlx.NewDb(replica, "sqlmock")}, testStickiness, zerolog.Nop())
+	replicas.writes["client"] = replicas.now().Add(-testStickiness)
+
+	replicas.healthy[0] = false
+	replicas.check(context.TODO())
+	assert.Equal(t, []bool{true}, replicas.healthy)
+	assert.Empty(t, replicas.writes)
+
+	require.NoError(t, replica.Close())
+	replicas.check(context.TODO())
+	assert.Equal(t, []bool{false}, replicas.healthy)
+}
@@ -0,0 +1,136 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+
+	"github.com/AlisskaPie/project-xm/internal/company/repository/repotest"
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+)
+
+// openTestDB opens the migrated database of TEST_POSTGRES_DSN, the test is skipped without it
+func openTestDB(tb testing.TB) *sqlx.DB {
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if testing.Short() || dsn == "" {
+		tb.Skip("skipping test without TEST_POSTGRES_DSN")
+	}
+
+	m, err := migrate.New("file://../../../../migrations", dsn)
+	require.NoError(tb, err)
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		require.NoError(tb, err)
+	}
+
+	db, err := sqlx.Open("postgres", dsn)
+	require.NoError(tb, err)
+	tb.Cleanup(func() { _ = db.Close() })
+
+	require.NoError(tb, EnsureUniqueNames(context.TODO(), db, false))
+
+	return db
+}
+
+// truncate empties the company tables
+func truncate(tb testing.TB, db *sqlx.DB) {
+	_, err := db.ExecContext(context.TODO(), "TRUNCATE company, company_history, company_audit, company_outbox")
+	require.NoError(tb, err)
+}
+
+// TestPostgresCompanyRepository runs the behavioural tests shared with the other repositories
+// against the database of TEST_POSTGRES_DSN. The database is migrated and emptied, so it must be a disposable one.
+func TestPostgresCompanyRepository(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.TODO()
+
+	repotest.TestCompanyRepository(t, func(t *testing.T) domain.CompanyRepository {
+		truncate(t, db)
+
+		return NewAuditWrapper(db, NewCompanyRepository(ctx, db))
+	})
+}
+
+// BenchmarkPostgresCompanyRepository compares the repository with the prepared statements cache to the one
+// sending the same statements unprepared, so that postgres plans every query as it did for the interpolated SQL.
+// Like TestPostgresCompanyRepository it empties the database of TEST_POSTGRES_DSN, e.g.
+// TEST_POSTGRES_DSN=... go test -run '^$' -bench . -count 10 ./internal/company/repository/postgres | benchstat -
+func BenchmarkPostgresCompanyRepository(b *testing.B) {
+	db := openTestDB(b)
+	ctx := context.TODO()
+
+	repositories := []struct {
+		name  string
+		stmts func() *statements
+	}{
+		{name: "prepared", stmts: newStatements},
+		{name: "unprepared", stmts: func() *statements { return nil }},
+	}
+	for _, repo := range repositories {
+		newRepo := func(b *testing.B) *companyRepository {
+			truncate(b, db)
+
+			return &companyRepository{ctx: ctx, db: db, stmts: repo.stmts()}
+		}
+
+		b.Run("Create/"+repo.name, func(b *testing.B) {
+			r := newRepo(b)
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				_, err := r.Create(ctx, domain.CreateCompany{
+					Name:              fmt.Sprintf("company %d", i),
+					AmountOfEmployees: 10,
+					CompanyType:       domain.CorporationsType,
+				})
+				require.NoError(b, err)
+			}
+		})
+
+		b.Run("GetByID/"+repo.name, func(b *testing.B) {
+			r := newRepo(b)
+			ids := createBenchCompanies(b, r)
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				_, err := r.GetByID(ctx, ids[i%len(ids)])
+				require.NoError(b, err)
+			}
+		})
+
+		b.Run("Patch/"+repo.name, func(b *testing.B) {
+			r := newRepo(b)
+			ids := createBenchCompanies(b, r)
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				employees := uint32(i)
+				_, err := r.Patch(ctx, ids[i%len(ids)], domain.PatchCompany{AmountOfEmployees: &employees})
+				require.NoError(b, err)
+			}
+		})
+	}
+}
+
+// createBenchCompanies creates the companies read and patched by the benchmarks
+func createBenchCompanies(b *testing.B, r domain.CompanyRepository) []uuid.UUID {
+	ids := make([]uuid.UUID, 100)
+	for i := range ids {
+		c, err := r.Create(context.TODO(), domain.CreateCompany{
+			Name:        fmt.Sprintf("company %d", i),
+			CompanyType: domain.CorporationsType,
+		})
+		require.NoError(b, err)
+		ids[i] = c.ID
+	}
+
+	return ids
+}
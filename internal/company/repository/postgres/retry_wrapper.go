@@ -0,0 +1,267 @@
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"github.com/AlisskaPie/project-xm/internal/config"
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+)
+
+// failure classifies the errors of the postgres operations by whether they can be retried
+type failure int
+
+const (
+	// permanent failures are returned as is
+	permanent failure = iota
+	// rolledBack failures abort the transaction, e.g. serialization failures and deadlocks,
+	// so any operation can be retried
+	rolledBack
+	// connectionLost failures may happen after the transaction was committed,
+	// so only the idempotent operations can be retried
+	connectionLost
+)
+
+// classify returns the failure of err by its SQLSTATE
+func classify(err error) failure {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code.Name() {
+		case "serialization_failure", "deadlock_detected":
+			return rolledBack
+		case "admin_shutdown", "crash_shutdown", "cannot_connect_now":
+			return connectionLost
+		}
+
+		if pqErr.Code.Class().Name() == "connection_exception" {
+			return connectionLost
+		}
+
+		return permanent
+	}
+
+	// lib/pq reports the fatal errors of the connection as driver.ErrBadConn
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return connectionLost
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return connectionLost
+	}
+
+	return permanent
+}
+
+// retryWrapper retries the company operations failed by the transient postgres errors, see classify.
+// The operations of the transaction carried by the context are not retried, as the failure aborts the whole
+// transaction, it's up to its owner to retry it.
+type retryWrapper struct {
+	repo  domain.CompanyRepository
+	conf  config.Retry
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// idempotent tells that the failed attempt of the operation can be repeated
+func idempotent() bool {
+	return true
+}
+
+// do runs fn until it succeeds, fails permanently or conf.MaxAttempts are made. The connectionLost failures
+// are retried only if idempotent, if not nil, tells that the failed attempt can be repeated.
+func (r *retryWrapper) do(ctx context.Context, idempotent func() bool, fn func() error) error {
+	if _, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return fn()
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= r.conf.MaxAttempts || ctx.Err() != nil {
+			return err
+		}
+
+		switch classify(err) {
+		case permanent:
+			return err
+		case connectionLost:
+			if idempotent == nil || !idempotent() {
+				return err
+			}
+		}
+
+		if r.sleep(ctx, backoff(r.conf, attempt)) != nil {
+			return err
+		}
+	}
+}
+
+// Create implements domain.CompanyRepository
+func (r *retryWrapper) Create(ctx context.Context, c domain.CreateCompany) (domain.Company, error) {
+	var company domain.Company
+	err := r.do(ctx, nil, func() (err error) {
+		company, err = r.repo.Create(ctx, c)
+		return err
+	})
+	if err != nil {
+		return company, fmt.Errorf("repo.Create: %w", err)
+	}
+
+	return company, nil
+}
+
+// CreateBatch implements domain.CompanyRepository
+func (r *retryWrapper) CreateBatch(ctx context.Context, b domain.CreateCompanies) ([]domain.CreateCompanyResult, error) {
+	var results []domain.CreateCompanyResult
+	err := r.do(ctx, nil, func() (err error) {
+		results, err = r.repo.CreateBatch(ctx, b)
+		return err
+	})
+	if err != nil {
+		return results, fmt.Errorf("repo.CreateBatch: %w", err)
+	}
+
+	return results, nil
+}
+
+// Delete implements domain.CompanyRepository
+func (r *retryWrapper) Delete(ctx context.Context, id uuid.UUID, d domain.DeleteCompany) error {
+	err := r.do(ctx, nil, func() error {
+		return r.repo.Delete(ctx, id, d)
+	})
+	if err != nil {
+		return fmt.Errorf("repo.Delete: %w", err)
+	}
+
+	return nil
+}
+
+// Export implements domain.CompanyRepository. It's retried only until the first company is passed to fn.
+func (r *retryWrapper) Export(ctx context.Context, q domain.ExportCompanies, fn func(domain.Company) error) error {
+	exported := false
+	return r.do(ctx, func() bool { return !exported }, func() error {
+		return r.repo.Export(ctx, q, func(c domain.Company) error {
+			exported = true
+			return fn(c)
+		})
+	})
+}
+
+// GetByID implements domain.CompanyRepository
+func (r *retryWrapper) GetByID(ctx context.Context, id uuid.UUID) (domain.Company, error) {
+	var company domain.Company
+	err := r.do(ctx, idempotent, func() (err error) {
+		company, err = r.repo.GetByID(ctx, id)
+		return err
+	})
+
+	return company, err
+}
+
+// GetByIDAsOf implements domain.CompanyRepository
+func (r *retryWrapper) GetByIDAsOf(ctx context.Context, id uuid.UUID, at time.Time) (domain.Company, error) {
+	var company domain.Company
+	err := r.do(ctx, idempotent, func() (err error) {
+		company, err = r.repo.GetByIDAsOf(ctx, id, at)
+		return err
+	})
+
+	return company, err
+}
+
+// List implements domain.CompanyRepository
+func (r *retryWrapper) List(ctx context.Context, q domain.ListCompanies) (domain.CompanyPage, error) {
+	var page domain.CompanyPage
+	err := r.do(ctx, idempotent, func() (err error) {
+		page, err = r.repo.List(ctx, q)
+		return err
+	})
+
+	return page, err
+}
+
+// Search implements domain.CompanyRepository
+func (r *retryWrapper) Search(ctx context.Context, q domain.SearchCompanies) ([]domain.CompanySearchResult, error) {
+	var results []domain.CompanySearchResult
+	err := r.do(ctx, idempotent, func() (err error) {
+		results, err = r.repo.Search(ctx, q)
+		return err
+	})
+
+	return results, err
+}
+
+// Patch implements domain.CompanyRepository
+func (r *retryWrapper) Patch(ctx context.Context, id uuid.UUID, c domain.PatchCompany) (domain.Company, error) {
+	var company domain.Company
+	err := r.do(ctx, nil, func() (err error) {
+		company, err = r.repo.Patch(ctx, id, c)
+		return err
+	})
+	if err != nil {
+		return company, fmt.Errorf("repo.Patch: %w", err)
+	}
+
+	return company, nil
+}
+
+// Restore implements domain.CompanyRepository
+func (r *retryWrapper) Restore(ctx context.Context, id uuid.UUID) (domain.Company, error) {
+	var company domain.Company
+	err := r.do(ctx, nil, func() (err error) {
+		company, err = r.repo.Restore(ctx, id)
+		return err
+	})
+	if err != nil {
+		return company, fmt.Errorf("repo.Restore: %w", err)
+	}
+
+	return company, nil
+}
+
+// backoff returns the wait before the next attempt after the failed ones, doubling from conf.MinBackoff
+// up to conf.MaxBackoff
+func backoff(conf config.Retry, attempts int) time.Duration {
+	wait := conf.MinBackoff
+	for i := 1; i < attempts && wait < conf.MaxBackoff; i++ {
+		wait *= 2
+	}
+
+	if wait > conf.MaxBackoff {
+		return conf.MaxBackoff
+	}
+
+	return wait
+}
+
+// sleep waits for d unless ctx is done first
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// NewRetryWrapper wraps the postgres repo so that its operations failed by the transient errors are retried
+// with the backoff of conf. The serialization failures and deadlocks are retried for every operation,
+// the lost connections only for the reads, as the mutation might have been committed.
+func NewRetryWrapper(repo domain.CompanyRepository, conf config.Retry) domain.CompanyRepository {
+	return &retryWrapper{
+		repo:  repo,
+		conf:  conf,
+		sleep: sleep,
+	}
+}
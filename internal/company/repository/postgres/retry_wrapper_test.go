@@ -0,0 +1,192 @@
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/AlisskaPie/project-xm/internal/config"
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+	"github.com/AlisskaPie/project-xm/pkg/domain/mocks"
+)
+
+var testRetryConfig = config.Retry{
+	MaxAttempts: 3,
+	MinBackoff:  10 * time.Millisecond,
+	MaxBackoff:  15 * time.Millisecond,
+}
+
+var (
+	serializationErr = &pq.Error{Code: "40001"}
+	adminShutdownErr = &pq.Error{Code: "57P01"}
+)
+
+// newTestRetryWrapper returns the retry wrapper of m and the waits between its attempts
+func newTestRetryWrapper(m *mocks.CompanyRepository) (*retryWrapper, *[]time.Duration) {
+	w := NewRetryWrapper(m, testRetryConfig).(*retryWrapper)
+
+	var waits []time.Duration
+	w.sleep = func(_ context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+
+	return w, &waits
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want failure
+	}{
+		{name: "Serialization", err: serializationErr, want: rolledBack},
+		{name: "Deadlock", err: &pq.Error{Code: "40P01"}, want: rolledBack},
+		{name: "Translated", err: translateError(fmt.Errorf("Commit: %w", serializationErr)), want: rolledBack},
+		{name: "AdminShutdown", err: adminShutdownErr, want: connectionLost},
+		{name: "ConnectionFailure", err: &pq.Error{Code: "08006"}, want: connectionLost},
+		{name: "BadConn", err: translateError(driver.ErrBadConn), want: connectionLost},
+		{name: "UniqueViolation", err: testPqErr, want: permanent},
+		{name: "NotFound", err: domain.ErrNotFound, want: permanent},
+		{name: "Unknown", err: errors.New("test error"), want: permanent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, classify(tt.err))
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	conf := config.Retry{MinBackoff: time.Second, MaxBackoff: 5 * time.Second}
+
+	assert.Equal(t, time.Second, backoff(conf, 1))
+	assert.Equal(t, 2*time.Second, backoff(conf, 2))
+	assert.Equal(t, 4*time.Second, backoff(conf, 3))
+	assert.Equal(t, 5*time.Second, backoff(conf, 4))
+	assert.Equal(t, 5*time.Second, backoff(conf, 100))
+}
+
+func TestRetryWrapper_CreateRolledBack(t *testing.T) {
+	company := domain.Company{ID: testUUID, Name: "1"}
+
+	m := &mocks.CompanyRepository{}
+	m.On("Create", mock.Anything, domain.CreateCompany{Name: "1"}).Return(domain.Company{}, serializationErr).Once()
+	m.On("Create", mock.Anything, domain.CreateCompany{Name: "1"}).Return(company, nil).Once()
+	w, waits := newTestRetryWrapper(m)
+
+	got, err := w.Create(context.TODO(), domain.CreateCompany{Name: "1"})
+	assert.NoError(t, err)
+	assert.Equal(t, company, got)
+	assert.Equal(t, []time.Duration{testRetryConfig.MinBackoff}, *waits)
+
+	m.AssertExpectations(t)
+}
+
+func TestRetryWrapper_CreateConnectionLost(t *testing.T) {
+	m := &mocks.CompanyRepository{}
+	m.On("Create", mock.Anything, domain.CreateCompany{Name: "1"}).Return(domain.Company{}, adminShutdownErr).Once()
+	w, waits := newTestRetryWrapper(m)
+
+	// the company might have been created before the connection was lost
+	_, err := w.Create(context.TODO(), domain.CreateCompany{Name: "1"})
+	assert.ErrorIs(t, err, adminShutdownErr)
+	assert.Empty(t, *waits)
+
+	m.AssertExpectations(t)
+}
+
+func TestRetryWrapper_GetByIDConnectionLost(t *testing.T) {
+	m := &mocks.CompanyRepository{}
+	m.On("GetByID", mock.Anything, testUUID).Return(domain.Company{}, adminShutdownErr).Times(testRetryConfig.MaxAttempts)
+	w, waits := newTestRetryWrapper(m)
+
+	_, err := w.GetByID(context.TODO(), testUUID)
+	assert.ErrorIs(t, err, adminShutdownErr)
+	assert.Equal(t, []time.Duration{testRetryConfig.MinBackoff, testRetryConfig.MaxBackoff}, *waits)
+
+	m.AssertExpectations(t)
+}
+
+func TestRetryWrapper_GetByIDPermanent(t *testing.T) {
+	m := &mocks.CompanyRepository{}
+	m.On("GetByID", mock.Anything, testUUID).Return(domain.Company{}, domain.ErrNotFound).Once()
+	w, _ := newTestRetryWrapper(m)
+
+	_, err := w.GetByID(context.TODO(), testUUID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	m.AssertExpectations(t)
+}
+
+func TestRetryWrapper_InTx(t *testing.T) {
+	ctx := context.WithValue(context.TODO(), txKey{}, &sqlx.Tx{})
+
+	m := &mocks.CompanyRepository{}
+	m.On("Patch", ctx, testUUID, domain.PatchCompany{}).Return(domain.Company{}, serializationErr).Once()
+	w, _ := newTestRetryWrapper(m)
+
+	// the failure aborted the whole transaction
+	_, err := w.Patch(ctx, testUUID, domain.PatchCompany{})
+	assert.ErrorIs(t, err, serializationErr)
+
+	m.AssertExpectations(t)
+}
+
+func TestRetryWrapper_ExportStarted(t *testing.T) {
+	m := &mocks.CompanyRepository{}
+	m.On("Export", mock.Anything, domain.ExportCompanies{}, mock.Anything).
+		Return(adminShutdownErr).
+		Run(func(args mock.Arguments) {
+			_ = args.Get(2).(func(domain.Company) error)(domain.Company{ID: testUUID})
+		}).
+		Once()
+	w, _ := newTestRetryWrapper(m)
+
+	var exported []domain.Company
+	err := w.Export(context.TODO(), domain.ExportCompanies{}, func(c domain.Company) error {
+		exported = append(exported, c)
+		return nil
+	})
+	assert.ErrorIs(t, err, adminShutdownErr)
+	assert.Len(t, exported, 1)
+
+	m.AssertExpectations(t)
+}
+
+func TestRetryWrapper_ExportNotStarted(t *testing.T) {
+	m := &mocks.CompanyRepository{}
+	m.On("Export", mock.Anything, domain.ExportCompanies{}, mock.Anything).Return(adminShutdownErr).Once()
+	m.On("Export", mock.Anything, domain.ExportCompanies{}, mock.Anything).Return(nil).Once()
+	w, _ := newTestRetryWrapper(m)
+
+	err := w.Export(context.TODO(), domain.ExportCompanies{}, func(domain.Company) error { return nil })
+	assert.NoError(t, err)
+
+	m.AssertExpectations(t)
+}
+
+func TestRetryWrapper_ContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+
+	m := &mocks.CompanyRepository{}
+	m.On("List", ctx, domain.ListCompanies{}).Return(domain.CompanyPage{}, adminShutdownErr).Once()
+	w, _ := newTestRetryWrapper(m)
+	w.sleep = func(ctx context.Context, _ time.Duration) error {
+		cancel()
+		return ctx.Err()
+	}
+
+	_, err := w.List(ctx, domain.ListCompanies{})
+	assert.ErrorIs(t, err, adminShutdownErr)
+
+	m.AssertExpectations(t)
+}
@@ -0,0 +1,51 @@
+package postgres
+
+import (
+	"strings"
+	"unicode"
+)
+
+// searchConfig is the text search configuration of the company search column
+const searchConfig = "english"
+
+// headlineOptions highlights the matched words of the snippets
+const headlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=20, MinWords=5"
+
+// toTSQuery converts the search text into the to_tsquery syntax, so that all the words must match,
+// the words of "quoted phrases" must be adjacent and the words ending with * match as prefixes.
+// Everything except letters and digits is dropped, so the user can't inject tsquery operators.
+func toTSQuery(text string) string {
+	var terms []string
+	for i, part := range strings.Split(text, `"`) {
+		if i%2 == 1 {
+			if words := tsWords(part); len(words) > 0 {
+				terms = append(terms, "("+strings.Join(words, " <-> ")+")")
+			}
+			continue
+		}
+
+		for _, field := range strings.Fields(part) {
+			words := tsWords(field)
+			if len(words) == 0 {
+				continue
+			}
+
+			term := strings.Join(words, " <-> ")
+			if strings.HasSuffix(field, "*") {
+				term += ":*"
+			}
+			if len(words) > 1 {
+				term = "(" + term + ")"
+			}
+			terms = append(terms, term)
+		}
+	}
+
+	return strings.Join(terms, " & ")
+}
+
+func tsWords(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
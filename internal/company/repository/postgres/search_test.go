@@ -0,0 +1,29 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToTSQuery(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{text: "software", want: "software"},
+		{text: "  big   data ", want: "big & data"},
+		{text: `"open source" software`, want: "(open <-> source) & software"},
+		{text: "dat* soft", want: "dat:* & soft"},
+		{text: "e-mail", want: "(e <-> mail)"},
+		{text: `"unclosed phrase`, want: "(unclosed <-> phrase)"},
+		{text: "a & b | !c <-> ':*'", want: "a & b & c"},
+		{text: "Café 42", want: "Café & 42"},
+		{text: `*** "" !`, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			assert.Equal(t, tt.want, toTSQuery(tt.text))
+		})
+	}
+}
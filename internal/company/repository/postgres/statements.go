@@ -0,0 +1,123 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+
+	"github.com/doug-martin/goqu/v9"
+	_ "github.com/doug-martin/goqu/v9/dialect/postgres"
+	"github.com/jmoiron/sqlx"
+)
+
+// dialect builds the company queries with $n placeholders once they are Prepared(true)
+var dialect = goqu.Dialect("postgres")
+
+// maxStatements bounds the statements cache, the queries of the shapes beyond it are not prepared,
+// e.g. those of the batches of unusual sizes
+const maxStatements = 512
+
+// statements caches the prepared statements of the company queries by their SQL.
+// database/sql prepares a statement once per connection, so every query of the same shape reuses the plan
+// of its connection. A nil cache prepares nothing, the queries are still sent with their args.
+type statements struct {
+	mu    sync.Mutex
+	stmts map[statementKey]*sqlx.Stmt
+}
+
+type statementKey struct {
+	db    *sqlx.DB
+	query string
+}
+
+// stmt returns the statement of the query prepared on db, or nil if the cache is full. In the transaction carried
+// by ctx the cached statement is bound to it and the missing one is prepared on its connection without caching,
+// so that no other connection of the pool is taken while the transaction holds one
+func (s *statements) stmt(ctx context.Context, db *sqlx.DB, query string) (*sqlx.Stmt, error) {
+	if s == nil {
+		return nil, nil
+	}
+
+	key := statementKey{db: db, query: query}
+
+	s.mu.Lock()
+	stmt, ok := s.stmts[key]
+	full := len(s.stmts) >= maxStatements
+	s.mu.Unlock()
+
+	// the statements of the transaction are closed with it
+	if tx, inTx := ctx.Value(txKey{}).(*sqlx.Tx); inTx {
+		if ok {
+			return tx.StmtxContext(ctx, stmt), nil
+		}
+
+		return tx.PreparexContext(ctx, query)
+	}
+
+	if ok {
+		return stmt, nil
+	}
+	if full {
+		return nil, nil
+	}
+
+	prepared, err := db.PreparexContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// the same query may have been prepared concurrently
+	if stmt, ok = s.stmts[key]; ok {
+		_ = prepared.Close()
+		return stmt, nil
+	}
+	s.stmts[key] = prepared
+
+	return prepared, nil
+}
+
+// get scans the row of the query into dest
+func (s *statements) get(ctx context.Context, db *sqlx.DB, dest any, query string, args []any) error {
+	stmt, err := s.stmt(ctx, db, query)
+	if err != nil {
+		return err
+	}
+	if stmt == nil {
+		return sqlx.GetContext(ctx, conn(ctx, db), dest, query, args...)
+	}
+
+	return stmt.GetContext(ctx, dest, args...)
+}
+
+// selectAll scans the rows of the query into dest
+func (s *statements) selectAll(ctx context.Context, db *sqlx.DB, dest any, query string, args []any) error {
+	stmt, err := s.stmt(ctx, db, query)
+	if err != nil {
+		return err
+	}
+	if stmt == nil {
+		return sqlx.SelectContext(ctx, conn(ctx, db), dest, query, args...)
+	}
+
+	return stmt.SelectContext(ctx, dest, args...)
+}
+
+// exec executes the query
+func (s *statements) exec(ctx context.Context, db *sqlx.DB, query string, args []any) (sql.Result, error) {
+	stmt, err := s.stmt(ctx, db, query)
+	if err != nil {
+		return nil, err
+	}
+	if stmt == nil {
+		return conn(ctx, db).ExecContext(ctx, query, args...)
+	}
+
+	return stmt.ExecContext(ctx, args...)
+}
+
+func newStatements() *statements {
+	return &statements{stmts: map[statementKey]*sqlx.Stmt{}}
+}
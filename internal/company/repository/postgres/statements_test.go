@@ -0,0 +1,71 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestStatements_Reused(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	require.NoError(t, err)
+	dbMock.ExpectPrepare(`^SELECT \$1$`).
+		ExpectQuery().
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(1))
+	dbMock.ExpectQuery(`^SELECT \$1$`).
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(2))
+
+	s := newStatements()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	for i := 1; i <= 2; i++ {
+		var n int
+		require.NoError(t, s.get(context.TODO(), sqlxDB, &n, "SELECT $1", []any{i}))
+		assert.Equal(t, i, n)
+	}
+
+	assert.Len(t, s.stmts, 1)
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
+
+func TestStatements_Full(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	require.NoError(t, err)
+	dbMock.ExpectExec(`^DELETE FROM "company"$`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	s := newStatements()
+	for i := 0; i < maxStatements; i++ {
+		s.stmts[statementKey{query: fmt.Sprint(i)}] = nil
+	}
+
+	_, err = s.exec(context.TODO(), sqlx.NewDb(db, "sqlmock"), `DELETE FROM "company"`, nil)
+	assert.NoError(t, err)
+	assert.Len(t, s.stmts, maxStatements)
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
+
+func TestStatements_InTx(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	require.NoError(t, err)
+	dbMock.ExpectBegin()
+	dbMock.ExpectPrepare(`^DELETE FROM "company"$`).
+		ExpectExec().
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectCommit()
+
+	s := newStatements()
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	err = withTx(context.TODO(), sqlxDB, func(ctx context.Context) error {
+		_, err := s.exec(ctx, sqlxDB, `DELETE FROM "company"`, nil)
+		return err
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, s.stmts)
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
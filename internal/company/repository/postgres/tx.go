@@ -0,0 +1,142 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/AlisskaPie/project-xm/internal/config"
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+)
+
+type txKey struct{}
+
+// withTx runs fn in a transaction carried by the context passed to fn.
+// If ctx already carries a transaction fn joins it instead of starting a new one.
+func withTx(ctx context.Context, db *sqlx.DB, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("BeginTxx: %w", err)
+	}
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("Commit: %w", err)
+	}
+
+	return nil
+}
+
+// conn returns the transaction carried by ctx or db if there is none
+func conn(ctx context.Context, db *sqlx.DB) sqlx.ExtContext {
+	if tx, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return tx
+	}
+
+	return db
+}
+
+// txManager implements domain.TxManager with the transactions of the postgres db
+type txManager struct {
+	db    *sqlx.DB
+	repo  domain.CompanyRepository
+	conf  config.Retry
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// WithTx implements domain.TxManager, the transactions failed by serialization failures and deadlocks
+// are run again with the backoff of conf
+func (m *txManager) WithTx(
+	ctx context.Context,
+	opts domain.TxOptions,
+	fn func(ctx context.Context, repo domain.CompanyRepository) error,
+) error {
+	if _, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return fn(ctx, m.repo)
+	}
+
+	txOpts, err := txOptions(opts)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 1; ; attempt++ {
+		afterCommit, err := m.run(ctx, txOpts, fn)
+		if err == nil {
+			return afterCommit(ctx)
+		}
+
+		if attempt >= m.conf.MaxAttempts || classify(err) != rolledBack || ctx.Err() != nil {
+			return err
+		}
+		if m.sleep(ctx, backoff(m.conf, attempt)) != nil {
+			return err
+		}
+	}
+}
+
+// run runs fn in a new transaction, it returns the func running the AfterCommit funcs once it's committed
+func (m *txManager) run(
+	ctx context.Context,
+	opts *sql.TxOptions,
+	fn func(ctx context.Context, repo domain.CompanyRepository) error,
+) (func(ctx context.Context) error, error) {
+	tx, err := m.db.BeginTxx(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("BeginTxx: %w", translateError(err))
+	}
+
+	txCtx, afterCommit := domain.ContextWithTx(context.WithValue(ctx, txKey{}, tx))
+	if err := fn(txCtx, m.repo); err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("Commit: %w", translateError(err))
+	}
+
+	return afterCommit, nil
+}
+
+// txOptions converts the domain options into the database/sql ones
+func txOptions(opts domain.TxOptions) (*sql.TxOptions, error) {
+	txOpts := &sql.TxOptions{ReadOnly: opts.ReadOnly}
+
+	switch opts.Isolation {
+	case "":
+		txOpts.Isolation = sql.LevelDefault
+	case domain.ReadCommitted:
+		txOpts.Isolation = sql.LevelReadCommitted
+	case domain.RepeatableRead:
+		txOpts.Isolation = sql.LevelRepeatableRead
+	case domain.Serializable:
+		txOpts.Isolation = sql.LevelSerializable
+	default:
+		return nil, fmt.Errorf("unknown isolation level %q", opts.Isolation)
+	}
+
+	return txOpts, nil
+}
+
+// NewTxManager creates the domain.TxManager of the postgres db passing repo to the units of work,
+// repo must be the repository of db so that its calls join the transactions
+func NewTxManager(db *sqlx.DB, repo domain.CompanyRepository, conf config.Retry) domain.TxManager {
+	return &txManager{
+		db:    db,
+		repo:  repo,
+		conf:  conf,
+		sleep: sleep,
+	}
+}
@@ -0,0 +1,138 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+)
+
+// newTestTxManager returns the mock of the db and the tx manager passing the outbox wrapped repository of the db
+func newTestTxManager(t *testing.T) (sqlmock.Sqlmock, *txManager) {
+	db, dbMock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+	repo := NewOutboxWrapper(sqlxDB, NewCompanyRepository(context.TODO(), sqlxDB))
+
+	m := NewTxManager(sqlxDB, repo, testRetryConfig).(*txManager)
+	m.sleep = func(context.Context, time.Duration) error { return nil }
+
+	return dbMock, m
+}
+
+func TestTxManager_Commit(t *testing.T) {
+	dbMock, m := newTestTxManager(t)
+	dbMock.ExpectBegin()
+	dbMock.ExpectPrepare(`^INSERT INTO "company" `).
+		ExpectQuery().
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(testUUID.String()))
+	dbMock.ExpectPrepare(`^INSERT INTO "company_outbox" `).ExpectExec().WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectCommit()
+
+	committed := false
+	err := m.WithTx(context.TODO(), domain.TxOptions{}, func(ctx context.Context, repo domain.CompanyRepository) error {
+		assert.True(t, domain.InTx(ctx))
+
+		// the outbox wrapper joins the transaction instead of starting its own
+		_, err := repo.Create(ctx, domain.CreateCompany{ID: testUUID})
+		assert.NoError(t, err)
+
+		return domain.AfterCommit(ctx, func(context.Context) error {
+			committed = true
+			return nil
+		})
+	})
+	assert.NoError(t, err)
+	assert.True(t, committed)
+
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
+
+func TestTxManager_Rollback(t *testing.T) {
+	dbMock, m := newTestTxManager(t)
+	dbMock.ExpectBegin()
+	dbMock.ExpectRollback()
+
+	testErr := errors.New("test error")
+	committed := false
+	err := m.WithTx(context.TODO(), domain.TxOptions{}, func(ctx context.Context, _ domain.CompanyRepository) error {
+		_ = domain.AfterCommit(ctx, func(context.Context) error {
+			committed = true
+			return nil
+		})
+		return testErr
+	})
+	assert.Equal(t, testErr, err)
+	assert.False(t, committed)
+
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
+
+func TestTxManager_RetrySerializationFailure(t *testing.T) {
+	dbMock, m := newTestTxManager(t)
+	dbMock.ExpectBegin()
+	dbMock.ExpectCommit().WillReturnError(serializationErr)
+	dbMock.ExpectBegin()
+	dbMock.ExpectCommit()
+
+	runs := 0
+	err := m.WithTx(context.TODO(), domain.TxOptions{}, func(context.Context, domain.CompanyRepository) error {
+		runs++
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, runs)
+
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
+
+func TestTxManager_NoRetry(t *testing.T) {
+	dbMock, m := newTestTxManager(t)
+	dbMock.ExpectBegin()
+	dbMock.ExpectRollback()
+
+	runs := 0
+	err := m.WithTx(context.TODO(), domain.TxOptions{}, func(context.Context, domain.CompanyRepository) error {
+		runs++
+		return domain.ErrConflict
+	})
+	assert.ErrorIs(t, err, domain.ErrConflict)
+	assert.Equal(t, 1, runs)
+
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
+
+func TestTxManager_Joins(t *testing.T) {
+	dbMock, m := newTestTxManager(t)
+	ctx := context.WithValue(context.TODO(), txKey{}, &sqlx.Tx{})
+
+	err := m.WithTx(ctx, domain.TxOptions{}, func(txCtx context.Context, _ domain.CompanyRepository) error {
+		assert.Equal(t, ctx, txCtx)
+		return nil
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
+
+func TestTxOptions(t *testing.T) {
+	opts, err := txOptions(domain.TxOptions{Isolation: domain.Serializable, ReadOnly: true})
+	assert.NoError(t, err)
+	assert.Equal(t, &sql.TxOptions{Isolation: sql.LevelSerializable, ReadOnly: true}, opts)
+
+	opts, err = txOptions(domain.TxOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, sql.LevelDefault, opts.Isolation)
+
+	_, err = txOptions(domain.TxOptions{Isolation: "snapshot"})
+	assert.Error(t, err)
+}
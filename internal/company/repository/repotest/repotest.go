@@ -0,0 +1,429 @@
+// Package repotest implements the behavioural tests shared by the domain.CompanyRepository implementations
+package repotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+)
+
+// NewRepository creates the empty repository under test, the company names in it must be unique ignoring case
+type NewRepository func(t *testing.T) domain.CompanyRepository
+
+// TestCompanyRepository runs the behavioural tests of domain.CompanyRepository against the repositories of newRepo
+func TestCompanyRepository(t *testing.T, newRepo NewRepository) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, r domain.CompanyRepository)
+	}{
+		{"Create", testCreate},
+		{"CreateBatch", testCreateBatch},
+		{"Patch", testPatch},
+		{"DeleteRestore", testDeleteRestore},
+		{"List", testList},
+		{"Search", testSearch},
+		{"Export", testExport},
+		{"GetByIDAsOf", testGetByIDAsOf},
+		{"Tenants", testTenants},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newRepo(t))
+		})
+	}
+}
+
+func testCreate(t *testing.T, r domain.CompanyRepository) {
+	ctx := context.TODO()
+	id := uuid.New()
+
+	created, err := r.Create(ctx, newCompany(id, "Acme"))
+	require.NoError(t, err)
+	assert.Equal(t, domain.Company{
+		ID:                id,
+		Name:              "Acme",
+		Description:       "open source data",
+		AmountOfEmployees: 10,
+		Registered:        true,
+		CompanyType:       domain.CorporationsType,
+		Version:           1,
+	}, created)
+
+	got, err := r.GetByID(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, created, got)
+
+	generated, err := r.Create(ctx, newCompany(uuid.Nil, "Globex"))
+	require.NoError(t, err)
+	assert.NotEqual(t, uuid.Nil, generated.ID)
+
+	_, err = r.Create(ctx, newCompany(id, "Initech"))
+	assertError(t, err, domain.ErrConflict, "company already exists")
+
+	_, err = r.Create(ctx, newCompany(uuid.Nil, "ACME"))
+	assertError(t, err, domain.ErrConflict, "company name is taken by "+id.String())
+
+	_, err = r.Create(ctx, newCompany(uuid.Nil, "Name is too long"))
+	assertError(t, err, domain.ErrValidation, "value is too long")
+
+	invalid := newCompany(uuid.Nil, "Hooli")
+	invalid.CompanyType = "Partnership"
+	_, err = r.Create(ctx, invalid)
+	assertError(t, err, domain.ErrValidation, "value is not allowed")
+
+	_, err = r.GetByID(ctx, uuid.New())
+	assertError(t, err, domain.ErrNotFound, "company does not exist")
+}
+
+func testCreateBatch(t *testing.T, r domain.CompanyRepository) {
+	ctx := context.TODO()
+	first, second := uuid.New(), uuid.New()
+	batch := []domain.CreateCompany{
+		newCompany(first, "Acme"),
+		newCompany(second, "acme"),
+	}
+
+	_, err := r.CreateBatch(ctx, domain.CreateCompanies{Companies: batch, Mode: domain.AllOrNothingBatchMode})
+	assertError(t, err, domain.ErrConflict, "company 1 or its name already exists")
+
+	_, err = r.GetByID(ctx, first)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	results, err := r.CreateBatch(ctx, domain.CreateCompanies{Companies: batch, Mode: domain.BestEffortBatchMode})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, first, results[0].Company.ID)
+	assert.Equal(t, int64(1), results[0].Company.Version)
+	assertError(t, results[1].Err, domain.ErrConflict, "company or its name already exists")
+
+	got, err := r.GetByID(ctx, first)
+	require.NoError(t, err)
+	assert.Equal(t, results[0].Company, got)
+}
+
+func testPatch(t *testing.T, r domain.CompanyRepository) {
+	ctx := context.TODO()
+	id := uuid.New()
+
+	_, err := r.Create(ctx, newCompany(id, "Acme"))
+	require.NoError(t, err)
+	other, err := r.Create(ctx, newCompany(uuid.Nil, "Globex"))
+	require.NoError(t, err)
+
+	patched, err := r.Patch(ctx, id, domain.PatchCompany{
+		Description: pointer("closed source"),
+		Version:     pointer(int64(1)),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "closed source", patched.Description)
+	assert.Equal(t, "Acme", patched.Name)
+	assert.Equal(t, int64(2), patched.Version)
+
+	got, err := r.GetByID(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, patched, got)
+
+	_, err = r.Patch(ctx, id, domain.PatchCompany{Registered: pointer(false), Version: pointer(int64(1))})
+	assertError(t, err, domain.ErrPreconditionFailed, "company version is 2")
+
+	// an empty patch changes nothing, not even the version, but checks it all the same
+	unchanged, err := r.Patch(ctx, id, domain.PatchCompany{Version: pointer(int64(2))})
+	require.NoError(t, err)
+	assert.Equal(t, patched, unchanged)
+
+	_, err = r.Patch(ctx, id, domain.PatchCompany{Version: pointer(int64(1))})
+	assertError(t, err, domain.ErrPreconditionFailed, "company version is 2")
+
+	_, err = r.Patch(ctx, uuid.New(), domain.PatchCompany{})
+	assertError(t, err, domain.ErrNotFound, "company does not exist")
+
+	_, err = r.Patch(ctx, id, domain.PatchCompany{Name: pointer("GLOBEX")})
+	assertError(t, err, domain.ErrConflict, "company name is taken by "+other.ID.String())
+
+	_, err = r.Patch(ctx, id, domain.PatchCompany{CompanyType: pointer(domain.CompanyType("Partnership"))})
+	assertError(t, err, domain.ErrValidation, "value is not allowed")
+
+	_, err = r.Patch(ctx, uuid.New(), domain.PatchCompany{Registered: pointer(false)})
+	assertError(t, err, domain.ErrNotFound, "company does not exist")
+}
+
+func testDeleteRestore(t *testing.T, r domain.CompanyRepository) {
+	ctx := context.TODO()
+	id := uuid.New()
+
+	_, err := r.Create(ctx, newCompany(id, "Acme"))
+	require.NoError(t, err)
+
+	err = r.Delete(ctx, id, domain.DeleteCompany{Version: pointer(int64(2))})
+	assertError(t, err, domain.ErrPreconditionFailed, "company version is 1")
+
+	require.NoError(t, r.Delete(ctx, id, domain.DeleteCompany{Version: pointer(int64(1))}))
+	_, err = r.GetByID(ctx, id)
+	assertError(t, err, domain.ErrNotFound, "company does not exist")
+
+	err = r.Delete(ctx, id, domain.DeleteCompany{})
+	assertError(t, err, domain.ErrNotFound, "company does not exist")
+
+	// the deleted company doesn't hold its name
+	taken, err := r.Create(ctx, newCompany(uuid.Nil, "ACME"))
+	require.NoError(t, err)
+
+	_, err = r.Restore(ctx, id)
+	assertError(t, err, domain.ErrConflict, "company name is taken by "+taken.ID.String())
+
+	require.NoError(t, r.Delete(ctx, taken.ID, domain.DeleteCompany{}))
+	restored, err := r.Restore(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), restored.Version)
+
+	_, err = r.Restore(ctx, id)
+	assertError(t, err, domain.ErrNotFound, "deleted company does not exist")
+
+	require.NoError(t, r.Delete(ctx, id, domain.DeleteCompany{Purge: true}))
+	err = r.Delete(ctx, id, domain.DeleteCompany{Purge: true})
+	assertError(t, err, domain.ErrNotFound, "company does not exist")
+
+	// the deleted companies can be purged
+	require.NoError(t, r.Delete(ctx, taken.ID, domain.DeleteCompany{Purge: true}))
+}
+
+func testList(t *testing.T, r domain.CompanyRepository) {
+	ctx := context.TODO()
+
+	for i, name := range []string{"Delta", "Alpha", "Charlie", "Bravo", "Echo"} {
+		c := newCompany(uuid.Nil, name)
+		c.AmountOfEmployees = uint32(i)
+		c.Registered = i%2 == 0
+		_, err := r.Create(ctx, c)
+		require.NoError(t, err)
+	}
+
+	for _, tt := range []struct {
+		sortBy domain.CompanySortField
+		desc   bool
+		want   []string
+	}{
+		{domain.SortByName, false, []string{"Alpha", "Bravo", "Charlie", "Delta", "Echo"}},
+		{domain.SortByAmountOfEmployees, true, []string{"Echo", "Bravo", "Charlie", "Alpha", "Delta"}},
+	} {
+		t.Run(fmt.Sprintf("%s desc=%t", tt.sortBy, tt.desc), func(t *testing.T) {
+			q := domain.ListCompanies{SortBy: tt.sortBy, Desc: tt.desc, Limit: 2}
+			var names []string
+			for {
+				page, err := r.List(ctx, q)
+				require.NoError(t, err)
+				for _, c := range page.Companies {
+					names = append(names, c.Name)
+				}
+
+				if page.NextCursor == "" {
+					break
+				}
+				q.Cursor = page.NextCursor
+			}
+			assert.Equal(t, tt.want, names)
+		})
+	}
+
+	page, err := r.List(ctx, domain.ListCompanies{
+		SortBy:       domain.SortByName,
+		NamePrefix:   "c",
+		Registered:   pointer(true),
+		MaxEmployees: pointer(uint32(3)),
+	})
+	require.NoError(t, err)
+	require.Len(t, page.Companies, 1)
+	assert.Equal(t, "Charlie", page.Companies[0].Name)
+	assert.Empty(t, page.NextCursor)
+
+	page, err = r.List(ctx, domain.ListCompanies{SortBy: domain.SortByID, NamePrefix: "%"})
+	require.NoError(t, err)
+	assert.Empty(t, page.Companies)
+
+	first, err := r.List(ctx, domain.ListCompanies{SortBy: domain.SortByName, Limit: 1})
+	require.NoError(t, err)
+	_, err = r.List(ctx, domain.ListCompanies{SortBy: domain.SortByID, Cursor: first.NextCursor})
+	assertError(t, err, domain.ErrBadRequest, "invalid cursor")
+
+	_, err = r.List(ctx, domain.ListCompanies{SortBy: "description"})
+	assertError(t, err, domain.ErrBadRequest, "unknown sort field")
+}
+
+func testSearch(t *testing.T, r domain.CompanyRepository) {
+	ctx := context.TODO()
+
+	acme, err := r.Create(ctx, newCompany(uuid.Nil, "Acme"))
+	require.NoError(t, err)
+	globex := newCompany(uuid.Nil, "Globex")
+	globex.Description = "data source"
+	_, err = r.Create(ctx, globex)
+	require.NoError(t, err)
+	deleted, err := r.Create(ctx, newCompany(uuid.Nil, "Initech"))
+	require.NoError(t, err)
+	require.NoError(t, r.Delete(ctx, deleted.ID, domain.DeleteCompany{}))
+
+	results, err := r.Search(ctx, domain.SearchCompanies{Query: `"open source" dat*`})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, acme, results[0].Company)
+	assert.Greater(t, results[0].Rank, 0.0)
+	assert.Contains(t, results[0].Snippet, "<mark>")
+
+	results, err = r.Search(ctx, domain.SearchCompanies{Query: "source"})
+	require.NoError(t, err)
+	assert.Len(t, results, 2)
+
+	results, err = r.Search(ctx, domain.SearchCompanies{Query: "acme"})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, acme.ID, results[0].Company.ID)
+
+	_, err = r.Search(ctx, domain.SearchCompanies{Query: `" & "`})
+	assertError(t, err, domain.ErrBadRequest, "search query has no words")
+}
+
+func testExport(t *testing.T, r domain.CompanyRepository) {
+	ctx := context.TODO()
+
+	var want []uuid.UUID
+	for i, name := range []string{"Acme", "Globex", "Initech"} {
+		c := newCompany(uuid.Nil, name)
+		c.Registered = i != 1
+		created, err := r.Create(ctx, c)
+		require.NoError(t, err)
+		if c.Registered {
+			want = append(want, created.ID)
+		}
+	}
+
+	var got []uuid.UUID
+	err := r.Export(ctx, domain.ExportCompanies{Registered: pointer(true)}, func(c domain.Company) error {
+		got = append(got, c.ID)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, want, got)
+	for i := 1; i < len(got); i++ {
+		assert.Less(t, got[i-1].String(), got[i].String())
+	}
+
+	stop := errors.New("stop")
+	err = r.Export(ctx, domain.ExportCompanies{}, func(domain.Company) error { return stop })
+	assert.ErrorIs(t, err, stop)
+}
+
+func testGetByIDAsOf(t *testing.T, r domain.CompanyRepository) {
+	ctx := context.TODO()
+	id := uuid.New()
+
+	beforeCreate := time.Now()
+	// the history of the databases has the millisecond precision
+	time.Sleep(10 * time.Millisecond)
+	_, err := r.Create(ctx, newCompany(id, "Acme"))
+	require.NoError(t, err)
+	time.Sleep(10 * time.Millisecond)
+	created := time.Now()
+	time.Sleep(10 * time.Millisecond)
+
+	_, err = r.Patch(ctx, id, domain.PatchCompany{AmountOfEmployees: pointer(uint32(20))})
+	require.NoError(t, err)
+	time.Sleep(10 * time.Millisecond)
+	patched := time.Now()
+	time.Sleep(10 * time.Millisecond)
+
+	require.NoError(t, r.Delete(ctx, id, domain.DeleteCompany{}))
+
+	_, err = r.GetByIDAsOf(ctx, id, beforeCreate)
+	assertError(t, err, domain.ErrNotFound, "company did not exist at that time")
+
+	c, err := r.GetByIDAsOf(ctx, id, created)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(10), c.AmountOfEmployees)
+	assert.Equal(t, int64(1), c.Version)
+
+	c, err = r.GetByIDAsOf(ctx, id, patched)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(20), c.AmountOfEmployees)
+
+	_, err = r.GetByIDAsOf(ctx, id, time.Now())
+	assertError(t, err, domain.ErrNotFound, "company did not exist at that time")
+}
+
+func testTenants(t *testing.T, r domain.CompanyRepository) {
+	acme := domain.ContextWithTenant(context.TODO(), "acme")
+	other := domain.ContextWithTenant(context.TODO(), "other")
+	id := uuid.New()
+
+	created, err := r.Create(acme, newCompany(id, "Acme"))
+	require.NoError(t, err)
+
+	// the company is not seen by the other tenant
+	_, err = r.GetByID(other, id)
+	assertError(t, err, domain.ErrNotFound, "company does not exist")
+	_, err = r.GetByIDAsOf(other, id, time.Now())
+	assertError(t, err, domain.ErrNotFound, "company did not exist at that time")
+	_, err = r.Patch(other, id, domain.PatchCompany{Registered: pointer(false)})
+	assertError(t, err, domain.ErrNotFound, "company does not exist")
+	err = r.Delete(other, id, domain.DeleteCompany{Purge: true})
+	assertError(t, err, domain.ErrNotFound, "company does not exist")
+
+	page, err := r.List(other, domain.ListCompanies{SortBy: domain.SortByID})
+	require.NoError(t, err)
+	assert.Empty(t, page.Companies)
+
+	results, err := r.Search(other, domain.SearchCompanies{Query: "acme"})
+	require.NoError(t, err)
+	assert.Empty(t, results)
+
+	err = r.Export(other, domain.ExportCompanies{}, func(c domain.Company) error {
+		t.Errorf("company %s of the other tenant is exported", c.ID)
+		return nil
+	})
+	require.NoError(t, err)
+
+	// the names are unique in the tenant, the IDs across the tenants
+	_, err = r.Create(other, newCompany(uuid.Nil, "ACME"))
+	require.NoError(t, err)
+	_, err = r.Create(other, newCompany(id, "Globex"))
+	assertError(t, err, domain.ErrConflict, "company already exists")
+
+	got, err := r.GetByID(acme, id)
+	require.NoError(t, err)
+	assert.Equal(t, created, got)
+}
+
+// assertError checks that err is the domain.Error of the kind and the detail
+func assertError(t *testing.T, err error, kind error, detail string) {
+	t.Helper()
+
+	var domainErr *domain.Error
+	if !assert.ErrorAs(t, err, &domainErr) {
+		return
+	}
+	assert.Equal(t, kind, domainErr.Kind)
+	assert.Equal(t, detail, domainErr.Detail)
+}
+
+func newCompany(id uuid.UUID, name string) domain.CreateCompany {
+	return domain.CreateCompany{
+		ID:                id,
+		Name:              name,
+		Description:       "open source data",
+		AmountOfEmployees: 10,
+		Registered:        true,
+		CompanyType:       domain.CorporationsType,
+	}
+}
+
+func pointer[T any](v T) *T {
+	return &v
+}
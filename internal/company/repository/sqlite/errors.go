@@ -0,0 +1,53 @@
+package sqlite
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+)
+
+// translateError converts the driver errors into the domain ones, unknown errors are returned as is
+func translateError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.NewError(domain.ErrNotFound, "company does not exist", err)
+	}
+
+	if errors.Is(err, driver.ErrBadConn) {
+		return domain.NewError(domain.ErrUnavailable, "", err)
+	}
+
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return err
+	}
+
+	switch sqliteErr.Code() {
+	case sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+		if _, ok := nameViolation(err); ok {
+			return domain.NewError(domain.ErrConflict, "company name is already taken", err)
+		}
+		return domain.NewError(domain.ErrConflict, "company already exists", err)
+	case sqlite3.SQLITE_CONSTRAINT_CHECK:
+		// the length constraints are named company_<column>_length
+		if strings.Contains(sqliteErr.Error(), "_length") {
+			return domain.NewError(domain.ErrValidation, "value is too long", err)
+		}
+		return domain.NewError(domain.ErrValidation, "value is not allowed", err)
+	case sqlite3.SQLITE_CONSTRAINT_NOTNULL:
+		return domain.NewError(domain.ErrValidation, "value is not allowed", err)
+	}
+
+	// the primary result code is the low byte of the extended one
+	switch sqliteErr.Code() & 0xff {
+	case sqlite3.SQLITE_BUSY, sqlite3.SQLITE_LOCKED, sqlite3.SQLITE_CANTOPEN:
+		return domain.NewError(domain.ErrUnavailable, "", err)
+	}
+
+	return err
+}
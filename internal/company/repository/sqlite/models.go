@@ -0,0 +1,77 @@
+package sqlite
+
+import (
+	"context"
+	"time"
+
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+
+	"github.com/google/uuid"
+)
+
+// companyColumns lists the columns of Company
+var companyColumns = []any{"id", "name", "description", "amount_of_employees", "registered", "type", "version"}
+
+type Company struct {
+	ID                uuid.UUID          `db:"id"`
+	Name              string             `db:"name"`
+	Description       string             `db:"description"`
+	AmountOfEmployees uint32             `db:"amount_of_employees"`
+	Registered        bool               `db:"registered"`
+	CompanyType       domain.CompanyType `db:"type"`
+	Version           int64              `db:"version" goqu:"skipinsert"`
+}
+
+// companyRow is the inserted row of the company owned by the tenant
+type companyRow struct {
+	Company
+	TenantID string `db:"tenant_id"`
+}
+
+// newCompany returns the row of the company created by the tenant of ctx
+func newCompany(ctx context.Context, c domain.CreateCompany) companyRow {
+	return companyRow{
+		Company: Company{
+			ID:                c.ID,
+			Name:              c.Name,
+			Description:       c.Description,
+			AmountOfEmployees: c.AmountOfEmployees,
+			Registered:        c.Registered,
+			CompanyType:       c.CompanyType,
+		},
+		TenantID: domain.TenantFromContext(ctx),
+	}
+}
+
+type CompanySearchResult struct {
+	Company
+	Rank    float64 `db:"rank"`
+	Snippet string  `db:"snippet"`
+}
+
+type AuditEntry struct {
+	ID        int64     `db:"id"`
+	CompanyID uuid.UUID `db:"company_id"`
+	Action    string    `db:"action"`
+	Actor     string    `db:"actor"`
+	RequestID string    `db:"request_id"`
+	Changes   []byte    `db:"changes"`
+	CreatedAt string    `db:"created_at"`
+}
+
+// AuditChange is the JSON of domain.FieldChange stored in AuditEntry.Changes
+type AuditChange struct {
+	Field string `json:"field"`
+	Old   any    `json:"old"`
+	New   any    `json:"new"`
+}
+
+// timeFormat is the format of the timestamps, it has a fixed length so they compare as strings
+const timeFormat = "2006-01-02T15:04:05.000Z"
+
+// now is the current timestamp in timeFormat
+const now = "strftime('%Y-%m-%dT%H:%M:%fZ', 'now')"
+
+func parseTime(s string) (time.Time, error) {
+	return time.Parse(timeFormat, s)
+}
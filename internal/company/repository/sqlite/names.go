@@ -0,0 +1,200 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+)
+
+// The unique indexes of the company names of the tenant ignoring case, only one of them exists,
+// see EnsureUniqueNames. SQLite lower() folds the ASCII letters only.
+const (
+	uniqueNameIndex        = "company_tenant_lower_name_key"
+	uniqueNamePerTypeIndex = "company_tenant_lower_name_type_key"
+)
+
+// legacyNameIndexes are the unique indexes of the names across the tenants, they are dropped by EnsureUniqueNames
+var legacyNameIndexes = []string{"company_lower_name_key", "company_lower_name_type_key"}
+
+// NameDuplicate is the group of companies of the tenant whose names differ only in case,
+// CompanyType is set if the names are unique per type
+type NameDuplicate struct {
+	TenantID    string
+	Name        string
+	CompanyType domain.CompanyType
+	IDs         []uuid.UUID
+}
+
+func (d NameDuplicate) String() string {
+	if d.CompanyType != "" {
+		return fmt.Sprintf("%q of type %s in tenant %q: %v", d.Name, d.CompanyType, d.TenantID, d.IDs)
+	}
+
+	return fmt.Sprintf("%q in tenant %q: %v", d.Name, d.TenantID, d.IDs)
+}
+
+// FindNameDuplicates lists the companies that would violate the unique names index,
+// the deleted companies don't hold their names
+func FindNameDuplicates(ctx context.Context, db *sqlx.DB, perType bool) ([]NameDuplicate, error) {
+	same := []goqu.Expression{
+		goqu.I("other.deleted_at").IsNull(),
+		goqu.I("other.id").Neq(goqu.I("company.id")),
+		goqu.I("other.tenant_id").Eq(goqu.I("company.tenant_id")),
+		goqu.L("lower(?) = lower(?)", goqu.I("other.name"), goqu.I("company.name")),
+	}
+	if perType {
+		same = append(same, goqu.I("other.type").Eq(goqu.I("company.type")))
+	}
+
+	q, _, err := dialect.From("company").
+		Select(goqu.C("tenant_id"), goqu.L("lower(?)", goqu.C("name")).As("name"), goqu.C("type"), goqu.C("id")).
+		Where(
+			goqu.C("deleted_at").IsNull(),
+			goqu.L("EXISTS ?", dialect.From(goqu.T("company").As("other")).Select(goqu.L("1")).Where(same...)),
+		).
+		Order(goqu.C("tenant_id").Asc(), goqu.I("name").Asc(), goqu.C("type").Asc(), goqu.C("id").Asc()).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("cannot build query: %w", err)
+	}
+
+	var rows []struct {
+		TenantID    string             `db:"tenant_id"`
+		Name        string             `db:"name"`
+		CompanyType domain.CompanyType `db:"type"`
+		ID          uuid.UUID          `db:"id"`
+	}
+	if err := db.SelectContext(ctx, &rows, q); err != nil {
+		return nil, fmt.Errorf("SelectContext: %w", translateError(err))
+	}
+
+	// the rows of the same duplicate are adjacent
+	var res []NameDuplicate
+	for _, row := range rows {
+		if !perType {
+			row.CompanyType = ""
+		}
+
+		if n := len(res); n > 0 && res[n-1].TenantID == row.TenantID && res[n-1].Name == row.Name &&
+			res[n-1].CompanyType == row.CompanyType {
+			res[n-1].IDs = append(res[n-1].IDs, row.ID)
+			continue
+		}
+		res = append(res, NameDuplicate{
+			TenantID:    row.TenantID,
+			Name:        row.Name,
+			CompanyType: row.CompanyType,
+			IDs:         []uuid.UUID{row.ID},
+		})
+	}
+
+	return res, nil
+}
+
+// EnsureUniqueNames creates the unique index of the company names of the tenant ignoring case, per type
+// if perType is set, and drops the indexes of the other scopes. It fails if the existing names are not unique,
+// see FindNameDuplicates.
+func EnsureUniqueNames(ctx context.Context, db *sqlx.DB, perType bool) error {
+	create := fmt.Sprintf(
+		"CREATE UNIQUE INDEX IF NOT EXISTS %s ON company (tenant_id, lower(name)) WHERE deleted_at IS NULL",
+		uniqueNameIndex)
+	drop := []string{uniqueNamePerTypeIndex}
+	if perType {
+		create = fmt.Sprintf(
+			"CREATE UNIQUE INDEX IF NOT EXISTS %s ON company (tenant_id, lower(name), type) WHERE deleted_at IS NULL",
+			uniqueNamePerTypeIndex)
+		drop = []string{uniqueNameIndex}
+	}
+
+	queries := []string{create}
+	for _, index := range append(drop, legacyNameIndexes...) {
+		queries = append(queries, "DROP INDEX IF EXISTS "+index)
+	}
+
+	return withTx(ctx, db, func(ctx context.Context) error {
+		for _, q := range queries {
+			if _, err := conn(ctx, db).ExecContext(ctx, q); err != nil {
+				return fmt.Errorf("ExecContext: %w", translateError(err))
+			}
+		}
+
+		return nil
+	})
+}
+
+// nameViolation returns the unique names index violated by err,
+// SQLite reports it as "UNIQUE constraint failed: index '<name>'"
+func nameViolation(err error) (string, bool) {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) || sqliteErr.Code() != sqlite3.SQLITE_CONSTRAINT_UNIQUE {
+		return "", false
+	}
+
+	for _, index := range []string{uniqueNameIndex, uniqueNamePerTypeIndex} {
+		if strings.Contains(sqliteErr.Error(), "'"+index+"'") {
+			return index, true
+		}
+	}
+
+	return "", false
+}
+
+// nameConflict converts the violation of the unique names index into the conflict naming the company
+// that already has the name. The nil name and type are those of the company id.
+// Unlike postgres, SQLite keeps the transaction usable after the violation, so the companies are read in it.
+func (r *companyRepository) nameConflict(
+	ctx context.Context,
+	err error,
+	index string,
+	id uuid.UUID,
+	name *string,
+	companyType *domain.CompanyType,
+) error {
+	conflict := translateError(err)
+
+	if name == nil || companyType == nil {
+		current, getErr := r.get(ctx, goqu.Ex{"id": id.String()})
+		if getErr != nil {
+			return conflict
+		}
+		if name == nil {
+			name = &current.Name
+		}
+		if companyType == nil {
+			companyType = &current.CompanyType
+		}
+	}
+
+	ds := dialect.From("company").
+		Select("id").
+		Where(
+			goqu.L("lower(?) = lower(?)", goqu.C("name"), *name),
+			goqu.C("deleted_at").IsNull(),
+			goqu.C("id").Neq(id.String()),
+			tenantScope(ctx),
+		)
+	if index == uniqueNamePerTypeIndex {
+		ds = ds.Where(goqu.C("type").Eq(string(*companyType)))
+	}
+
+	q, _, qErr := ds.Limit(1).ToSQL()
+	if qErr != nil {
+		return conflict
+	}
+
+	var existing uuid.UUID
+	if err := sqlx.GetContext(ctx, conn(ctx, r.db), &existing, q); err != nil {
+		return conflict
+	}
+
+	return domain.NewError(domain.ErrConflict, fmt.Sprintf("company name is taken by %s", existing), err)
+}
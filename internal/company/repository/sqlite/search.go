@@ -0,0 +1,49 @@
+package sqlite
+
+import (
+	"strings"
+	"unicode"
+)
+
+// nameWeight is the bm25 weight of the name column relative to the description one,
+// as the A and B weights of the postgres search column
+const nameWeight = 2.5
+
+// snippetTokens is the maximum number of the description tokens in the snippets
+const snippetTokens = 20
+
+// toMatchQuery converts the search text into the FTS5 query syntax, so that all the words must match,
+// the words of "quoted phrases" must be adjacent and the words ending with * match as prefixes.
+// Every word is quoted and everything except letters and digits is dropped, so the user can't inject FTS5 operators.
+func toMatchQuery(text string) string {
+	var terms []string
+	for i, part := range strings.Split(text, `"`) {
+		if i%2 == 1 {
+			if words := matchWords(part); len(words) > 0 {
+				terms = append(terms, `"`+strings.Join(words, " ")+`"`)
+			}
+			continue
+		}
+
+		for _, field := range strings.Fields(part) {
+			words := matchWords(field)
+			if len(words) == 0 {
+				continue
+			}
+
+			term := `"` + strings.Join(words, " ") + `"`
+			if strings.HasSuffix(field, "*") {
+				term += "*"
+			}
+			terms = append(terms, term)
+		}
+	}
+
+	return strings.Join(terms, " ")
+}
+
+func matchWords(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
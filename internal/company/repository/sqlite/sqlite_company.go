@@ -0,0 +1,571 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/AlisskaPie/project-xm/internal/company/repository/keyset"
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+
+	"github.com/doug-martin/goqu/v9"
+	_ "github.com/doug-martin/goqu/v9/dialect/sqlite3"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	_ "modernc.org/sqlite"
+)
+
+// Scheme is the DSN scheme of the SQLite databases, e.g. sqlite://company.db or sqlite:///var/lib/company.db
+const Scheme = "sqlite"
+
+// pragmas are added to the DSN query: the writers wait for each other instead of failing,
+// and the transactions take the write lock upfront, so the read-then-write ones don't deadlock
+const pragmas = "_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_txlock=immediate"
+
+var dialect = goqu.Dialect("sqlite3")
+
+type companyRepository struct {
+	db *sqlx.DB
+}
+
+// Create implements domain.CompanyRepository
+func (r *companyRepository) Create(ctx context.Context, c domain.CreateCompany) (domain.Company, error) {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+
+	query, _, err := dialect.Insert("company").Rows(newCompany(ctx, c)).ToSQL()
+	if err != nil {
+		return domain.Company{}, fmt.Errorf("cannot build query: %w", err)
+	}
+
+	// SQLite has RETURNING but goqu doesn't support it for SQLite, so the company is read back in the transaction
+	var res Company
+	err = withTx(ctx, r.db, func(ctx context.Context) error {
+		_, err := conn(ctx, r.db).ExecContext(ctx, query)
+		if index, ok := nameViolation(err); ok {
+			return r.nameConflict(ctx, err, index, c.ID, &c.Name, &c.CompanyType)
+		}
+		if err != nil {
+			return fmt.Errorf("ExecContext: %w", translateError(err))
+		}
+
+		res, err = r.get(ctx, goqu.Ex{"id": c.ID.String()})
+		return err
+	})
+	if err != nil {
+		return domain.Company{}, err
+	}
+
+	return domain.Company(res), nil
+}
+
+// CreateBatch implements domain.CompanyRepository
+func (r *companyRepository) CreateBatch(
+	ctx context.Context,
+	b domain.CreateCompanies,
+) ([]domain.CreateCompanyResult, error) {
+	results := make([]domain.CreateCompanyResult, len(b.Companies))
+
+	err := withTx(ctx, r.db, func(ctx context.Context) error {
+		for i, c := range b.Companies {
+			if c.ID == uuid.Nil {
+				c.ID = uuid.New()
+			}
+
+			// the companies conflicting by ID or name are skipped, goqu would render INSERT OR IGNORE
+			// for DoNothing, which skips the CHECK violations too
+			query, _, err := dialect.Insert("company").Rows(newCompany(ctx, c)).ToSQL()
+			if err != nil {
+				return fmt.Errorf("cannot build query: %w", err)
+			}
+
+			res, err := conn(ctx, r.db).ExecContext(ctx, query+" ON CONFLICT DO NOTHING")
+			if err != nil {
+				return fmt.Errorf("ExecContext: %w", translateError(err))
+			}
+
+			n, err := res.RowsAffected()
+			if err != nil {
+				return fmt.Errorf("RowsAffected: %w", err)
+			}
+
+			if n == 0 {
+				if b.Mode == domain.AllOrNothingBatchMode {
+					return domain.NewError(domain.ErrConflict, fmt.Sprintf("company %d or its name already exists", i), nil)
+				}
+
+				results[i].Err = domain.NewError(domain.ErrConflict, "company or its name already exists", nil)
+				continue
+			}
+
+			created, err := r.get(ctx, goqu.Ex{"id": c.ID.String()})
+			if err != nil {
+				return err
+			}
+			results[i].Company = domain.Company(created)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// Delete implements domain.CompanyRepository
+func (r *companyRepository) Delete(ctx context.Context, id uuid.UUID, d domain.DeleteCompany) error {
+	where := goqu.Ex{"id": id.String(), "tenant_id": domain.TenantFromContext(ctx)}
+	if d.Version != nil {
+		where["version"] = *d.Version
+	}
+
+	var q string
+	var err error
+	if d.Purge {
+		q, _, err = dialect.Delete("company").Where(where).ToSQL()
+	} else {
+		where["deleted_at"] = nil
+		q, _, err = dialect.Update("company").
+			Set(goqu.Record{
+				"deleted_at": goqu.L(now),
+				"version":    goqu.L("`version` + 1"),
+			}).
+			Where(where).
+			ToSQL()
+	}
+	if err != nil {
+		return fmt.Errorf("cannot build query: %w", err)
+	}
+
+	res, err := conn(ctx, r.db).ExecContext(ctx, q)
+	if err != nil {
+		return fmt.Errorf("ExecContext: %w", translateError(err))
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("RowsAffected: %w", err)
+	}
+
+	if n == 0 {
+		return r.missingVersion(ctx, id, d.Version, d.Purge)
+	}
+
+	return nil
+}
+
+// Export implements domain.CompanyRepository.
+// The companies are read row by row by a single query, which SQLite runs on a consistent snapshot,
+// so there is no transaction holding the write lock for the export duration.
+func (r *companyRepository) Export(ctx context.Context, q domain.ExportCompanies, fn func(domain.Company) error) error {
+	ds := dialect.From("company").Select(companyColumns...).Where(goqu.C("deleted_at").IsNull(), tenantScope(ctx))
+
+	if q.CompanyType != nil {
+		ds = ds.Where(goqu.C("type").Eq(string(*q.CompanyType)))
+	}
+	if q.Registered != nil {
+		ds = ds.Where(goqu.C("registered").Eq(*q.Registered))
+	}
+
+	query, _, err := ds.Order(goqu.C("id").Asc()).ToSQL()
+	if err != nil {
+		return fmt.Errorf("cannot build query: %w", err)
+	}
+
+	rows, err := conn(ctx, r.db).QueryxContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("QueryxContext: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c Company
+		if err := rows.StructScan(&c); err != nil {
+			return fmt.Errorf("StructScan: %w", err)
+		}
+
+		if err := fn(domain.Company(c)); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("rows.Err: %w", translateError(err))
+	}
+
+	return nil
+}
+
+// GetByID implements domain.CompanyRepository
+func (r *companyRepository) GetByID(ctx context.Context, id uuid.UUID) (domain.Company, error) {
+	res, err := r.get(ctx, goqu.Ex{"id": id.String(), "deleted_at": nil})
+	if err != nil {
+		return domain.Company{}, err
+	}
+
+	return domain.Company(res), nil
+}
+
+// GetByIDAsOf implements domain.CompanyRepository
+func (r *companyRepository) GetByIDAsOf(ctx context.Context, id uuid.UUID, at time.Time) (domain.Company, error) {
+	t := at.UTC().Format(timeFormat)
+	q, _, err := dialect.From("company_history").
+		Select(companyColumns...).
+		Where(
+			goqu.C("id").Eq(id.String()),
+			tenantScope(ctx),
+			goqu.C("valid_from").Lte(t),
+			goqu.Or(goqu.C("valid_to").IsNull(), goqu.C("valid_to").Gt(t)),
+		).
+		ToSQL()
+	if err != nil {
+		return domain.Company{}, fmt.Errorf("cannot build query: %w", err)
+	}
+
+	var res Company
+	err = conn(ctx, r.db).QueryRowxContext(ctx, q).StructScan(&res)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Company{}, domain.NewError(domain.ErrNotFound, "company did not exist at that time", err)
+	}
+	if err != nil {
+		return domain.Company{}, fmt.Errorf("QueryRowxContext: %w", translateError(err))
+	}
+
+	return domain.Company(res), nil
+}
+
+// List implements domain.CompanyRepository
+func (r *companyRepository) List(ctx context.Context, q domain.ListCompanies) (domain.CompanyPage, error) {
+	column, ok := keyset.SortColumns[q.SortBy]
+	if !ok {
+		return domain.CompanyPage{}, domain.NewError(domain.ErrBadRequest, "unknown sort field", nil)
+	}
+	if q.Limit <= 0 {
+		q.Limit = domain.DefaultListLimit
+	}
+
+	ds := dialect.From("company").Select(companyColumns...).Where(goqu.C("deleted_at").IsNull(), tenantScope(ctx))
+
+	if q.CompanyType != nil {
+		ds = ds.Where(goqu.C("type").Eq(string(*q.CompanyType)))
+	}
+	if q.Registered != nil {
+		ds = ds.Where(goqu.C("registered").Eq(*q.Registered))
+	}
+	if q.MinEmployees != nil {
+		ds = ds.Where(goqu.C("amount_of_employees").Gte(*q.MinEmployees))
+	}
+	if q.MaxEmployees != nil {
+		ds = ds.Where(goqu.C("amount_of_employees").Lte(*q.MaxEmployees))
+	}
+	if q.NamePrefix != "" {
+		// SQLite LIKE ignores the case of the ASCII letters only
+		ds = ds.Where(goqu.L(`? LIKE ? ESCAPE '\'`, goqu.C("name"), likeEscaper.Replace(q.NamePrefix)+"%"))
+	}
+
+	op, order := ">", goqu.C(column).Asc()
+	orderByID := goqu.C("id").Asc()
+	if q.Desc {
+		op, order = "<", goqu.C(column).Desc()
+		orderByID = goqu.C("id").Desc()
+	}
+
+	if q.Cursor != "" {
+		value, id, err := keyset.ParseCursor(q)
+		if err != nil {
+			return domain.CompanyPage{}, err
+		}
+
+		if q.SortBy == domain.SortByID {
+			ds = ds.Where(goqu.L("? "+op+" ?", goqu.C("id"), id.String()))
+		} else {
+			ds = ds.Where(goqu.L("(?, ?) "+op+" (?, ?)", goqu.C(column), goqu.C("id"), value, id.String()))
+		}
+	}
+
+	if q.SortBy == domain.SortByID {
+		ds = ds.Order(orderByID)
+	} else {
+		ds = ds.Order(order, orderByID)
+	}
+
+	// one more company tells whether there is the next page
+	query, _, err := ds.Limit(uint(q.Limit + 1)).ToSQL()
+	if err != nil {
+		return domain.CompanyPage{}, fmt.Errorf("cannot build query: %w", err)
+	}
+
+	var res []Company
+	if err := sqlx.SelectContext(ctx, conn(ctx, r.db), &res, query); err != nil {
+		return domain.CompanyPage{}, fmt.Errorf("SelectContext: %w", translateError(err))
+	}
+
+	page := domain.CompanyPage{
+		Companies: make([]domain.Company, 0, len(res)),
+	}
+	if len(res) > q.Limit {
+		res = res[:q.Limit]
+
+		page.NextCursor, err = keyset.NewCursor(q, domain.Company(res[len(res)-1]))
+		if err != nil {
+			return domain.CompanyPage{}, fmt.Errorf("keyset.NewCursor: %w", err)
+		}
+	}
+
+	for _, c := range res {
+		page.Companies = append(page.Companies, domain.Company(c))
+	}
+
+	return page, nil
+}
+
+// Search implements domain.CompanyRepository
+func (r *companyRepository) Search(ctx context.Context, q domain.SearchCompanies) ([]domain.CompanySearchResult, error) {
+	matchQuery := toMatchQuery(q.Query)
+	if matchQuery == "" {
+		return nil, domain.NewError(domain.ErrBadRequest, "search query has no words", nil)
+	}
+	if q.Limit <= 0 {
+		q.Limit = domain.DefaultListLimit
+	}
+
+	// the columns of company_search shadow the company ones
+	columns := make([]any, 0, len(companyColumns)+2)
+	for _, c := range companyColumns {
+		columns = append(columns, goqu.T("company").Col(c))
+	}
+	columns = append(columns,
+		// bm25 is lower for the better matches
+		goqu.L("-bm25(?, ?, 1)", goqu.T("company_search"), nameWeight).As("rank"),
+		goqu.L("snippet(?, 1, '<mark>', '</mark>', '…', ?)", goqu.T("company_search"), snippetTokens).As("snippet"),
+	)
+
+	query, _, err := dialect.From("company_search").
+		Join(goqu.T("company"), goqu.On(goqu.T("company").Col("seq").Eq(goqu.T("company_search").Col("rowid")))).
+		Select(columns...).
+		Where(
+			goqu.L("? MATCH ?", goqu.T("company_search"), matchQuery),
+			goqu.T("company").Col("deleted_at").IsNull(),
+			goqu.T("company").Col("tenant_id").Eq(domain.TenantFromContext(ctx)),
+		).
+		Order(goqu.I("rank").Desc(), goqu.T("company").Col("id").Asc()).
+		Limit(uint(q.Limit)).
+		ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("cannot build query: %w", err)
+	}
+
+	var res []CompanySearchResult
+	if err := sqlx.SelectContext(ctx, conn(ctx, r.db), &res, query); err != nil {
+		return nil, fmt.Errorf("SelectContext: %w", translateError(err))
+	}
+
+	results := make([]domain.CompanySearchResult, 0, len(res))
+	for _, c := range res {
+		results = append(results, domain.CompanySearchResult{
+			Company: domain.Company(c.Company),
+			Rank:    c.Rank,
+			Snippet: c.Snippet,
+		})
+	}
+
+	return results, nil
+}
+
+// tenantScope restricts the query to the companies of the tenant of ctx
+func tenantScope(ctx context.Context) exp.BooleanExpression {
+	return goqu.C("tenant_id").Eq(domain.TenantFromContext(ctx))
+}
+
+// likeEscaper escapes the LIKE pattern wildcards
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// Patch implements domain.CompanyRepository
+func (r *companyRepository) Patch(ctx context.Context, id uuid.UUID, c domain.PatchCompany) (domain.Company, error) {
+	updates := map[string]any{}
+
+	if c.Description != nil {
+		updates["description"] = *c.Description
+	}
+	if c.Name != nil {
+		updates["name"] = *c.Name
+	}
+
+	if c.AmountOfEmployees != nil {
+		updates["amount_of_employees"] = *c.AmountOfEmployees
+	}
+
+	if c.Registered != nil {
+		updates["registered"] = *c.Registered
+	}
+
+	if c.CompanyType != nil {
+		updates["type"] = string(*c.CompanyType)
+	}
+
+	where := goqu.Ex{"id": id.String(), "deleted_at": nil, "tenant_id": domain.TenantFromContext(ctx)}
+	if c.Version != nil {
+		where["version"] = *c.Version
+	}
+
+	var res Company
+	err := withTx(ctx, r.db, func(ctx context.Context) error {
+		if len(updates) > 0 {
+			updates["version"] = goqu.L("`version` + 1")
+
+			q, _, err := dialect.Update("company").Set(updates).Where(where).ToSQL()
+			if err != nil {
+				return fmt.Errorf("cannot build query: %w", err)
+			}
+
+			result, err := conn(ctx, r.db).ExecContext(ctx, q)
+			if index, ok := nameViolation(err); ok {
+				return r.nameConflict(ctx, err, index, id, c.Name, c.CompanyType)
+			}
+			if err != nil {
+				return fmt.Errorf("ExecContext: %w", translateError(err))
+			}
+
+			n, err := result.RowsAffected()
+			if err != nil {
+				return fmt.Errorf("RowsAffected: %w", err)
+			}
+			if n == 0 {
+				return r.missingVersion(ctx, id, c.Version, false)
+			}
+
+			// the updated company has the next version
+			delete(where, "version")
+		}
+
+		var err error
+		res, err = r.get(ctx, where)
+		if errors.Is(err, domain.ErrNotFound) {
+			return r.missingVersion(ctx, id, c.Version, false)
+		}
+
+		return err
+	})
+	if err != nil {
+		return domain.Company{}, err
+	}
+
+	return domain.Company(res), nil
+}
+
+// Restore implements domain.CompanyRepository
+func (r *companyRepository) Restore(ctx context.Context, id uuid.UUID) (domain.Company, error) {
+	q, _, err := dialect.Update("company").
+		Set(goqu.Record{
+			"deleted_at": nil,
+			"version":    goqu.L("`version` + 1"),
+		}).
+		Where(goqu.C("id").Eq(id.String()), goqu.C("deleted_at").IsNotNull(), tenantScope(ctx)).
+		ToSQL()
+	if err != nil {
+		return domain.Company{}, fmt.Errorf("cannot build query: %w", err)
+	}
+
+	var res Company
+	err = withTx(ctx, r.db, func(ctx context.Context) error {
+		result, err := conn(ctx, r.db).ExecContext(ctx, q)
+		if index, ok := nameViolation(err); ok {
+			return r.nameConflict(ctx, err, index, id, nil, nil)
+		}
+		if err != nil {
+			return fmt.Errorf("ExecContext: %w", translateError(err))
+		}
+
+		n, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("RowsAffected: %w", err)
+		}
+		if n == 0 {
+			return domain.NewError(domain.ErrNotFound, "deleted company does not exist", nil)
+		}
+
+		res, err = r.get(ctx, goqu.Ex{"id": id.String()})
+		return err
+	})
+	if err != nil {
+		return domain.Company{}, err
+	}
+
+	return domain.Company(res), nil
+}
+
+// get reads the company of the tenant of ctx matching where, using the transaction carried by ctx
+func (r *companyRepository) get(ctx context.Context, where goqu.Ex) (Company, error) {
+	q, _, err := dialect.From("company").Select(companyColumns...).Where(where, tenantScope(ctx)).ToSQL()
+	if err != nil {
+		return Company{}, fmt.Errorf("cannot build query: %w", err)
+	}
+
+	var res Company
+	if err := conn(ctx, r.db).QueryRowxContext(ctx, q).StructScan(&res); err != nil {
+		return Company{}, fmt.Errorf("QueryRowxContext: %w", translateError(err))
+	}
+
+	return res, nil
+}
+
+// missingVersion tells why the company of the version was not found: it doesn't exist or has another version.
+// Deleted companies are taken into account only if withDeleted is set.
+func (r *companyRepository) missingVersion(ctx context.Context, id uuid.UUID, version *int64, withDeleted bool) error {
+	if version == nil {
+		return domain.NewError(domain.ErrNotFound, "company does not exist", nil)
+	}
+
+	where := goqu.Ex{"id": id.String(), "tenant_id": domain.TenantFromContext(ctx)}
+	if !withDeleted {
+		where["deleted_at"] = nil
+	}
+
+	q, _, err := dialect.From("company").Select("version").Where(where).ToSQL()
+	if err != nil {
+		return fmt.Errorf("cannot build query: %w", err)
+	}
+
+	var current int64
+	if err := sqlx.GetContext(ctx, conn(ctx, r.db), &current, q); err != nil {
+		return fmt.Errorf("GetContext: %w", translateError(err))
+	}
+
+	return domain.NewError(domain.ErrPreconditionFailed, fmt.Sprintf("company version is %d", current), nil)
+}
+
+// Open opens the SQLite database of the DSN with the Scheme, its query is passed to the driver.
+// The schema is created by the migrations/sqlite ones.
+func Open(dsn string) (*sqlx.DB, error) {
+	if !strings.HasPrefix(dsn, Scheme+"://") {
+		return nil, fmt.Errorf("DSN scheme is not %s", Scheme)
+	}
+
+	path := strings.TrimPrefix(dsn, Scheme+"://")
+
+	if strings.Contains(path, "?") {
+		path += "&" + pragmas
+	} else {
+		path += "?" + pragmas
+	}
+
+	db, err := sqlx.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("sqlx.Open: %w", err)
+	}
+
+	return db, nil
+}
+
+// NewCompanyRepository creates the domain.CompanyRepository of the SQLite database opened by Open
+func NewCompanyRepository(db *sqlx.DB) domain.CompanyRepository {
+	return &companyRepository{
+		db: db,
+	}
+}
@@ -0,0 +1,197 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/sqlite"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/AlisskaPie/project-xm/internal/company/repository/repotest"
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+)
+
+// newTestDB creates the migrated database in the temporary directory of the test
+func newTestDB(t *testing.T) *sqlx.DB {
+	dsn := Scheme + "://" + filepath.Join(t.TempDir(), "company.db")
+
+	m, err := migrate.New("file://../../../../migrations/sqlite", dsn)
+	require.NoError(t, err)
+	require.NoError(t, m.Up())
+	srcErr, dbErr := m.Close()
+	require.NoError(t, srcErr)
+	require.NoError(t, dbErr)
+
+	db, err := Open(dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	require.NoError(t, EnsureUniqueNames(context.TODO(), db, false))
+
+	return db
+}
+
+func TestSQLiteCompanyRepository(t *testing.T) {
+	repotest.TestCompanyRepository(t, func(t *testing.T) domain.CompanyRepository {
+		return NewCompanyRepository(newTestDB(t))
+	})
+}
+
+func TestSQLiteCompanyRepository_Audit(t *testing.T) {
+	repotest.TestCompanyRepository(t, func(t *testing.T) domain.CompanyRepository {
+		db := newTestDB(t)
+		return NewAuditWrapper(db, NewCompanyRepository(db))
+	})
+}
+
+func TestSQLiteCompanyAuditHistory(t *testing.T) {
+	db := newTestDB(t)
+	r := NewAuditWrapper(db, NewCompanyRepository(db))
+	ctx := domain.ContextWithActor(context.TODO(), domain.Actor{Subject: "admin", RequestID: "req"})
+
+	c, err := r.Create(ctx, domain.CreateCompany{Name: "Acme", CompanyType: domain.NonProfitType})
+	require.NoError(t, err)
+	_, err = r.Patch(ctx, c.ID, domain.PatchCompany{CompanyType: getPointer(domain.CooperativeType)})
+	require.NoError(t, err)
+	require.NoError(t, r.Delete(ctx, c.ID, domain.DeleteCompany{}))
+
+	// the failed mutation records nothing
+	_, err = r.Patch(ctx, c.ID, domain.PatchCompany{Registered: getPointer(true)})
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	audit := NewCompanyAuditRepository(db)
+	page, err := audit.History(ctx, domain.CompanyHistory{CompanyID: c.ID, Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, page.Entries, 2)
+	assert.Equal(t, domain.DeleteEventActionType, page.Entries[0].Action)
+	assert.Equal(t, domain.Actor{Subject: "admin", RequestID: "req"}, page.Entries[0].Actor)
+	assert.False(t, page.Entries[0].Time.IsZero())
+	assert.Equal(t, []domain.FieldChange{
+		{Field: "name", Old: "Acme", New: ""},
+		{Field: "type", Old: "Cooperative", New: ""},
+	}, page.Entries[0].Changes)
+	assert.Equal(t, domain.UpdateEventActionType, page.Entries[1].Action)
+	assert.Equal(t, []domain.FieldChange{{Field: "type", Old: "NonProfit", New: "Cooperative"}}, page.Entries[1].Changes)
+
+	page, err = audit.History(ctx, domain.CompanyHistory{CompanyID: c.ID, Cursor: page.NextCursor})
+	require.NoError(t, err)
+	require.Len(t, page.Entries, 1)
+	assert.Equal(t, domain.InsertEventActionType, page.Entries[0].Action)
+	assert.Empty(t, page.NextCursor)
+
+	_, err = audit.History(ctx, domain.CompanyHistory{CompanyID: c.ID, Cursor: "x"})
+	assert.Equal(t, domain.NewError(domain.ErrBadRequest, "invalid cursor", nil), err)
+}
+
+func TestSQLiteCompanyExport_Nested(t *testing.T) {
+	db := newTestDB(t)
+	r := NewCompanyRepository(db)
+	ctx := context.TODO()
+
+	c, err := r.Create(ctx, domain.CreateCompany{Name: "Acme", CompanyType: domain.NonProfitType})
+	require.NoError(t, err)
+
+	// the export holds no lock, so the repository can be used while it runs
+	err = r.Export(ctx, domain.ExportCompanies{}, func(exported domain.Company) error {
+		_, err := r.Patch(ctx, exported.ID, domain.PatchCompany{Registered: getPointer(true)})
+		return err
+	})
+	require.NoError(t, err)
+
+	got, err := r.GetByID(ctx, c.ID)
+	require.NoError(t, err)
+	assert.True(t, got.Registered)
+}
+
+func TestTxManager(t *testing.T) {
+	db := newTestDB(t)
+	m := NewTxManager(db, NewAuditWrapper(db, NewCompanyRepository(db)))
+	ctx := context.TODO()
+	testErr := errors.New("test error")
+
+	var rolledBack domain.Company
+	err := m.WithTx(ctx, domain.TxOptions{}, func(ctx context.Context, repo domain.CompanyRepository) (err error) {
+		rolledBack, err = repo.Create(ctx, domain.CreateCompany{Name: "Acme", CompanyType: domain.NonProfitType})
+		require.NoError(t, err)
+		return testErr
+	})
+	assert.Equal(t, testErr, err)
+
+	var committed domain.Company
+	opts := domain.TxOptions{Isolation: domain.Serializable}
+	err = m.WithTx(ctx, opts, func(ctx context.Context, repo domain.CompanyRepository) (err error) {
+		committed, err = repo.Create(ctx, domain.CreateCompany{Name: "Acme", CompanyType: domain.NonProfitType})
+		if err != nil {
+			return err
+		}
+		_, err = repo.Patch(ctx, committed.ID, domain.PatchCompany{Registered: getPointer(true)})
+		return err
+	})
+	require.NoError(t, err)
+
+	r := NewCompanyRepository(db)
+	_, err = r.GetByID(ctx, rolledBack.ID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	got, err := r.GetByID(ctx, committed.ID)
+	require.NoError(t, err)
+	assert.True(t, got.Registered)
+}
+
+func TestFindNameDuplicates(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.TODO()
+
+	// the names are unique per type, so the duplicates across the types can be added
+	require.NoError(t, EnsureUniqueNames(ctx, db, true))
+
+	r := NewCompanyRepository(db)
+	var ids []uuid.UUID
+	for _, companyType := range []domain.CompanyType{domain.NonProfitType, domain.CooperativeType} {
+		for _, name := range []string{"Acme", "Globex"} {
+			if name == "Globex" && companyType == domain.CooperativeType {
+				name = "Initech"
+			}
+
+			c, err := r.Create(ctx, domain.CreateCompany{Name: name, CompanyType: companyType})
+			require.NoError(t, err)
+			ids = append(ids, c.ID)
+		}
+	}
+
+	// the names are unique per tenant
+	_, err := r.Create(domain.ContextWithTenant(ctx, "other"), domain.CreateCompany{
+		Name:        "Acme",
+		CompanyType: domain.NonProfitType,
+	})
+	require.NoError(t, err)
+
+	duplicates, err := FindNameDuplicates(ctx, db, true)
+	require.NoError(t, err)
+	assert.Empty(t, duplicates)
+
+	duplicates, err = FindNameDuplicates(ctx, db, false)
+	require.NoError(t, err)
+	require.Len(t, duplicates, 1)
+	assert.Equal(t, "", duplicates[0].TenantID)
+	assert.Equal(t, "acme", duplicates[0].Name)
+	assert.ElementsMatch(t, []uuid.UUID{ids[0], ids[2]}, duplicates[0].IDs)
+
+	err = EnsureUniqueNames(ctx, db, false)
+	assert.ErrorIs(t, err, domain.ErrConflict)
+}
+
+func TestOpen(t *testing.T) {
+	_, err := Open("postgres://localhost/company")
+	assert.Equal(t, errors.New("DSN scheme is not sqlite"), err)
+}
+
+func getPointer[T any](v T) *T {
+	return &v
+}
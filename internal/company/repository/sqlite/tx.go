@@ -0,0 +1,88 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+)
+
+type txKey struct{}
+
+// withTx runs fn in a transaction carried by the context passed to fn.
+// If ctx already carries a transaction fn joins it instead of starting a new one.
+func withTx(ctx context.Context, db *sqlx.DB, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("BeginTxx: %w", err)
+	}
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("Commit: %w", err)
+	}
+
+	return nil
+}
+
+// conn returns the transaction carried by ctx or db if there is none
+func conn(ctx context.Context, db *sqlx.DB) sqlx.ExtContext {
+	if tx, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return tx
+	}
+
+	return db
+}
+
+// txManager implements domain.TxManager with the transactions of the SQLite db
+type txManager struct {
+	db   *sqlx.DB
+	repo domain.CompanyRepository
+}
+
+// WithTx implements domain.TxManager. The SQLite transactions are serializable, so every isolation level is met,
+// and the writes of the read only ones are not rejected.
+func (m *txManager) WithTx(
+	ctx context.Context,
+	opts domain.TxOptions,
+	fn func(ctx context.Context, repo domain.CompanyRepository) error,
+) error {
+	switch opts.Isolation {
+	case "", domain.ReadCommitted, domain.RepeatableRead, domain.Serializable:
+	default:
+		return fmt.Errorf("unknown isolation level %q", opts.Isolation)
+	}
+
+	if _, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return fn(ctx, m.repo)
+	}
+
+	txCtx, afterCommit := domain.ContextWithTx(ctx)
+	err := withTx(txCtx, m.db, func(ctx context.Context) error {
+		return fn(ctx, m.repo)
+	})
+	if err != nil {
+		return err
+	}
+
+	return afterCommit(ctx)
+}
+
+// NewTxManager creates the domain.TxManager of the SQLite db passing repo to the units of work,
+// repo must be the repository of db so that its calls join the transactions
+func NewTxManager(db *sqlx.DB, repo domain.CompanyRepository) domain.TxManager {
+	return &txManager{
+		db:   db,
+		repo: repo,
+	}
+}
@@ -3,6 +3,7 @@ package usecase
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/AlisskaPie/project-xm/pkg/domain"
 
@@ -11,25 +12,59 @@ import (
 
 type companyUsecase struct {
 	companyRepo domain.CompanyRepository
+	auditRepo   domain.CompanyAuditRepository
+	// txManager runs the operations of several repository calls atomically
+	txManager domain.TxManager
 }
 
 // Create implements domain.CompanyUsecase
-func (u *companyUsecase) Create(ctx context.Context, c domain.CreateCompany) error {
-	if err := u.companyRepo.Create(ctx, c); err != nil {
-		return fmt.Errorf("companyRepo.Create: %w", err)
+func (u *companyUsecase) Create(ctx context.Context, c domain.CreateCompany) (domain.Company, error) {
+	company, err := u.companyRepo.Create(ctx, c)
+	if err != nil {
+		return domain.Company{}, fmt.Errorf("companyRepo.Create: %w", err)
 	}
-	return nil
+	return company, nil
+}
+
+// CreateBatch implements domain.CompanyUsecase
+func (u *companyUsecase) CreateBatch(
+	ctx context.Context,
+	b domain.CreateCompanies,
+) ([]domain.CreateCompanyResult, error) {
+	if b.Mode == "" {
+		b.Mode = domain.AllOrNothingBatchMode
+	}
+	if b.Mode != domain.AllOrNothingBatchMode && b.Mode != domain.BestEffortBatchMode {
+		return nil, domain.NewError(domain.ErrBadRequest, "unknown batch mode", nil)
+	}
+	if len(b.Companies) > domain.MaxBatchSize {
+		return nil, domain.NewError(domain.ErrBadRequest, fmt.Sprintf("batch has more than %d companies", domain.MaxBatchSize), nil)
+	}
+
+	results, err := u.companyRepo.CreateBatch(ctx, b)
+	if err != nil {
+		return nil, fmt.Errorf("companyRepo.CreateBatch: %w", err)
+	}
+	return results, nil
 }
 
 // Delete implements domain.CompanyUsecase
-func (u *companyUsecase) Delete(ctx context.Context, id uuid.UUID) error {
-	err := u.companyRepo.Delete(ctx, id)
+func (u *companyUsecase) Delete(ctx context.Context, id uuid.UUID, d domain.DeleteCompany) error {
+	err := u.companyRepo.Delete(ctx, id, d)
 	if err != nil {
 		return fmt.Errorf("companyRepo.Delete: %w", err)
 	}
 	return nil
 }
 
+// Export implements domain.CompanyUsecase
+func (u *companyUsecase) Export(ctx context.Context, q domain.ExportCompanies, fn func(domain.Company) error) error {
+	if err := u.companyRepo.Export(ctx, q, fn); err != nil {
+		return fmt.Errorf("companyRepo.Export: %w", err)
+	}
+	return nil
+}
+
 // GetByID implements domain.CompanyUsecase
 func (u *companyUsecase) GetByID(ctx context.Context, id uuid.UUID) (domain.Company, error) {
 	res, err := u.companyRepo.GetByID(ctx, id)
@@ -39,18 +74,120 @@ func (u *companyUsecase) GetByID(ctx context.Context, id uuid.UUID) (domain.Comp
 	return res, nil
 }
 
-// Patch implements domain.CompanyUsecase
+// GetByIDAsOf implements domain.CompanyUsecase
+func (u *companyUsecase) GetByIDAsOf(ctx context.Context, id uuid.UUID, at time.Time) (domain.Company, error) {
+	res, err := u.companyRepo.GetByIDAsOf(ctx, id, at)
+	if err != nil {
+		return domain.Company{}, fmt.Errorf("companyRepo.GetByIDAsOf: %w", err)
+	}
+	return res, nil
+}
+
+// History implements domain.CompanyUsecase
+func (u *companyUsecase) History(ctx context.Context, q domain.CompanyHistory) (domain.CompanyHistoryPage, error) {
+	if q.Limit <= 0 {
+		q.Limit = domain.DefaultListLimit
+	}
+	if q.Limit > domain.MaxListLimit {
+		q.Limit = domain.MaxListLimit
+	}
+
+	page, err := u.auditRepo.History(ctx, q)
+	if err != nil {
+		return domain.CompanyHistoryPage{}, fmt.Errorf("auditRepo.History: %w", err)
+	}
+	return page, nil
+}
+
+// List implements domain.CompanyUsecase
+func (u *companyUsecase) List(ctx context.Context, q domain.ListCompanies) (domain.CompanyPage, error) {
+	if q.Limit <= 0 {
+		q.Limit = domain.DefaultListLimit
+	}
+	if q.Limit > domain.MaxListLimit {
+		q.Limit = domain.MaxListLimit
+	}
+	if q.SortBy == "" {
+		q.SortBy = domain.SortByName
+	}
+
+	page, err := u.companyRepo.List(ctx, q)
+	if err != nil {
+		return domain.CompanyPage{}, fmt.Errorf("companyRepo.List: %w", err)
+	}
+	return page, nil
+}
+
+// Search implements domain.CompanyUsecase
+func (u *companyUsecase) Search(ctx context.Context, q domain.SearchCompanies) ([]domain.CompanySearchResult, error) {
+	if q.Limit <= 0 {
+		q.Limit = domain.DefaultListLimit
+	}
+	if q.Limit > domain.MaxListLimit {
+		q.Limit = domain.MaxListLimit
+	}
+
+	res, err := u.companyRepo.Search(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("companyRepo.Search: %w", err)
+	}
+	return res, nil
+}
+
+// Patch implements domain.CompanyUsecase. The company patched under If-Match is read and patched
+// in one repeatable read transaction, so that the version it's checked against is the one that is patched.
 func (u *companyUsecase) Patch(ctx context.Context, id uuid.UUID, c domain.PatchCompany) (domain.Company, error) {
-	company, err := u.companyRepo.Patch(ctx, id, c)
+	if c.Version == nil {
+		company, err := u.companyRepo.Patch(ctx, id, c)
+		if err != nil {
+			return domain.Company{}, fmt.Errorf("companyRepo.Patch: %w", err)
+		}
+		return company, nil
+	}
+
+	var company domain.Company
+	opts := domain.TxOptions{Isolation: domain.RepeatableRead}
+	err := u.txManager.WithTx(ctx, opts, func(ctx context.Context, repo domain.CompanyRepository) error {
+		current, err := repo.GetByID(ctx, id)
+		if err != nil {
+			return fmt.Errorf("companyRepo.GetByID: %w", err)
+		}
+		if current.Version != *c.Version {
+			msg := fmt.Sprintf("company version is %d", current.Version)
+			return domain.NewError(domain.ErrPreconditionFailed, msg, nil)
+		}
+
+		company, err = repo.Patch(ctx, id, c)
+		if err != nil {
+			return fmt.Errorf("companyRepo.Patch: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return domain.Company{}, fmt.Errorf("txManager.WithTx: %w", err)
+	}
+	return company, nil
+}
+
+// Restore implements domain.CompanyUsecase
+func (u *companyUsecase) Restore(ctx context.Context, id uuid.UUID) (domain.Company, error) {
+	company, err := u.companyRepo.Restore(ctx, id)
 	if err != nil {
-		return domain.Company{}, fmt.Errorf("companyRepo.Patch: %w", err)
+		return domain.Company{}, fmt.Errorf("companyRepo.Restore: %w", err)
 	}
 	return company, nil
 }
 
-// NewCompanyUsecase creates new usecase object representation of domain.CompanyUsecase interface
-func NewCompanyUsecase(r domain.CompanyRepository) domain.CompanyUsecase {
+// NewCompanyUsecase creates new usecase object representation of domain.CompanyUsecase interface,
+// tx must run the transactions of the storage of r
+func NewCompanyUsecase(
+	r domain.CompanyRepository,
+	audit domain.CompanyAuditRepository,
+	tx domain.TxManager,
+) domain.CompanyUsecase {
 	return &companyUsecase{
 		companyRepo: r,
+		auditRepo:   audit,
+		txManager:   tx,
 	}
 }
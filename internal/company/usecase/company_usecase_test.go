@@ -0,0 +1,109 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+	"github.com/AlisskaPie/project-xm/pkg/domain/mocks"
+)
+
+var testUUID = uuid.MustParse("10000000-0000-0000-0000-000000000000")
+
+// runTx runs the unit of work with repo as the transaction would
+func runTx(repo domain.CompanyRepository) func(context.Context, domain.TxOptions,
+	func(context.Context, domain.CompanyRepository) error) error {
+	return func(ctx context.Context, _ domain.TxOptions, fn func(context.Context, domain.CompanyRepository) error) error {
+		return fn(ctx, repo)
+	}
+}
+
+func TestPatch(t *testing.T) {
+	testErr := errors.New("test error")
+	version := int64(2)
+	name := "a"
+	company := domain.Company{ID: testUUID, Name: "b", CompanyType: domain.CooperativeType, Version: 2}
+	patched := domain.Company{ID: testUUID, Name: "a", CompanyType: domain.CooperativeType, Version: 3}
+	txOpts := domain.TxOptions{Isolation: domain.RepeatableRead}
+
+	tests := []struct {
+		name        string
+		patch       domain.PatchCompany
+		rf          func(repo *mocks.CompanyRepository, tx *mocks.TxManager)
+		wantCompany domain.Company
+		wantErr     error
+	}{
+		{
+			name:  "Success",
+			patch: domain.PatchCompany{Name: &name},
+			rf: func(repo *mocks.CompanyRepository, _ *mocks.TxManager) {
+				repo.On("Patch", mock.Anything, testUUID, domain.PatchCompany{Name: &name}).Return(patched, nil)
+			},
+			wantCompany: patched,
+		},
+		{
+			name:  "IfMatch",
+			patch: domain.PatchCompany{Name: &name, Version: &version},
+			rf: func(repo *mocks.CompanyRepository, tx *mocks.TxManager) {
+				tx.On("WithTx", mock.Anything, txOpts, mock.Anything).Return(runTx(repo))
+				repo.On("GetByID", mock.Anything, testUUID).Return(company, nil)
+				repo.On("Patch", mock.Anything, testUUID, domain.PatchCompany{Name: &name, Version: &version}).
+					Return(patched, nil)
+			},
+			wantCompany: patched,
+		},
+		{
+			name:  "PreconditionFailed",
+			patch: domain.PatchCompany{Name: &name, Version: &version},
+			rf: func(repo *mocks.CompanyRepository, tx *mocks.TxManager) {
+				tx.On("WithTx", mock.Anything, txOpts, mock.Anything).Return(runTx(repo))
+				repo.On("GetByID", mock.Anything, testUUID).Return(patched, nil)
+			},
+			wantErr: domain.ErrPreconditionFailed,
+		},
+		{
+			name:  "NotFound",
+			patch: domain.PatchCompany{Name: &name, Version: &version},
+			rf: func(repo *mocks.CompanyRepository, tx *mocks.TxManager) {
+				tx.On("WithTx", mock.Anything, txOpts, mock.Anything).Return(runTx(repo))
+				repo.On("GetByID", mock.Anything, testUUID).
+					Return(domain.Company{}, domain.NewError(domain.ErrNotFound, "company does not exist", nil))
+			},
+			wantErr: domain.ErrNotFound,
+		},
+		{
+			name:  "Failed",
+			patch: domain.PatchCompany{Name: &name, Version: &version},
+			rf: func(repo *mocks.CompanyRepository, tx *mocks.TxManager) {
+				tx.On("WithTx", mock.Anything, txOpts, mock.Anything).Return(runTx(repo))
+				repo.On("GetByID", mock.Anything, testUUID).Return(company, nil)
+				repo.On("Patch", mock.Anything, testUUID, domain.PatchCompany{Name: &name, Version: &version}).
+					Return(domain.Company{}, testErr)
+			},
+			wantErr: testErr,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mocks.CompanyRepository{}
+			tx := &mocks.TxManager{}
+			tt.rf(repo, tx)
+
+			u := NewCompanyUsecase(repo, nil, tx)
+			got, err := u.Patch(context.TODO(), testUUID, tt.patch)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantCompany, got)
+			repo.AssertExpectations(t)
+			tx.AssertExpectations(t)
+		})
+	}
+}
@@ -1,20 +1,127 @@
 package config
 
+import (
+	"strings"
+	"time"
+)
+
 type Config struct {
 	DB          DB
 	HTTP        HTTP
 	Auth        Auth
-	EventSender bool
+	EventSender EventSender
+	Outbox      Outbox
+	Cache       Cache
 }
 
+// DB selects the company storage: the database of the DSN, postgres:// or sqlite://, unless Type is "memory".
+// The memory storage needs no database and loses the companies on exit, it's meant for development.
 type DB struct {
-	DSN string
+	Type string
+	DSN  string
+	// UniqueNamesPerType allows the companies of different types to have the same name,
+	// the names are compared ignoring case
+	UniqueNamesPerType bool
+	// Replicas are the DSNs of the postgres read replicas of DSN, the company reads are spread over the healthy ones
+	Replicas []string
+	// ReplicaStickiness is how long the reads of the client go to DSN after its own write
+	ReplicaStickiness time.Duration
+	// ReplicaCheckInterval is the interval of the replica health checks
+	ReplicaCheckInterval time.Duration
+	// MaxOpenConns, MaxIdleConns, ConnMaxLifetime and ConnMaxIdleTime limit the postgres connection pools,
+	// zero keeps the database/sql defaults
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+	// ConnectTimeout bounds the establishment of a postgres connection, rounded up to seconds,
+	// unless the DSN sets connect_timeout
+	ConnectTimeout time.Duration
+	// StartupTimeout is how long the startup waits for postgres to accept connections, zero fails at once
+	StartupTimeout time.Duration
+	// Retry configures the retries of the connection at startup and of the company operations failed
+	// by transient postgres errors
+	Retry Retry
+}
+
+// Storage returns the company storage: "memory" or the scheme of the DSN, "postgres" or "sqlite"
+func (c DB) Storage() string {
+	if c.Type != "" {
+		return c.Type
+	}
+
+	scheme, _, _ := strings.Cut(c.DSN, "://")
+	if scheme == "postgresql" {
+		return "postgres"
+	}
+
+	return scheme
+}
+
+// Retry configures the retries with the exponential backoff
+type Retry struct {
+	// MaxAttempts includes the first attempt, 1 disables the retries
+	MaxAttempts int
+	MinBackoff  time.Duration
+	MaxBackoff  time.Duration
 }
 
 type HTTP struct {
 	ListenHostPort string
+	// RequireIfMatch rejects the company updates and deletes without the If-Match header
+	RequireIfMatch bool
 }
 
 type Auth struct {
 	JWTKey string
+	// TenantClaim is the JWT claim of the tenant owning the companies of the request
+	TenantClaim string
+}
+
+// EventSender selects the company events sender by Type: "noop", "kafka" or "amqp".
+// Company events are disabled if Type is empty.
+type EventSender struct {
+	Type string
+	// Source is the CloudEvents source of the company events
+	Source string
+	Kafka  Kafka
+	AMQP   AMQP
+}
+
+// Kafka configures the Kafka company events sender
+type Kafka struct {
+	Brokers []string
+	Topic   string
+	// Acks is one of none, one or all
+	Acks string
+	// Compression is one of none, gzip, snappy, lz4 or zstd
+	Compression  string
+	BatchTimeout time.Duration
+}
+
+// AMQP configures the AMQP 0-9-1 (RabbitMQ) company events sender
+type AMQP struct {
+	URL          string
+	Exchange     string
+	ExchangeType string
+	// RoutingKeyPrefix is joined with the event action, e.g. company.insert
+	RoutingKeyPrefix string
+}
+
+// Outbox configures the relay of company events from the outbox to the event sender
+type Outbox struct {
+	PollInterval    time.Duration
+	BatchSize       int
+	MinRetryBackoff time.Duration
+	MaxRetryBackoff time.Duration
+}
+
+// Cache configures the in-process cache of the companies read by ID
+type Cache struct {
+	Enabled bool
+	// Size is the maximum number of the cached companies, the least recently used ones are evicted
+	Size int
+	TTL  time.Duration
+	// NegativeTTL is how long the missing companies are cached, zero disables it
+	NegativeTTL time.Duration
 }
@@ -12,6 +12,33 @@ func GetConfig() (config.Config, error) {
 	viper.SetConfigName("config")
 	viper.SetConfigType("json")
 	viper.AddConfigPath(".")
+
+	viper.SetDefault("db.replicaStickiness", "5s")
+	viper.SetDefault("db.replicaCheckInterval", "5s")
+	viper.SetDefault("db.maxOpenConns", 25)
+	viper.SetDefault("db.maxIdleConns", 25)
+	viper.SetDefault("db.connMaxLifetime", "30m")
+	viper.SetDefault("db.connMaxIdleTime", "5m")
+	viper.SetDefault("db.connectTimeout", "5s")
+	viper.SetDefault("db.startupTimeout", "1m")
+	viper.SetDefault("db.retry.maxAttempts", 3)
+	viper.SetDefault("db.retry.minBackoff", "50ms")
+	viper.SetDefault("db.retry.maxBackoff", "1s")
+	viper.SetDefault("auth.tenantClaim", "tenant_id")
+	viper.SetDefault("eventSender.source", "/project-xm")
+	viper.SetDefault("eventSender.kafka.acks", "all")
+	viper.SetDefault("eventSender.kafka.compression", "none")
+	viper.SetDefault("eventSender.kafka.batchTimeout", "10ms")
+	viper.SetDefault("eventSender.amqp.exchangeType", "topic")
+	viper.SetDefault("eventSender.amqp.routingKeyPrefix", "company")
+	viper.SetDefault("outbox.pollInterval", "1s")
+	viper.SetDefault("outbox.batchSize", 100)
+	viper.SetDefault("outbox.minRetryBackoff", "1s")
+	viper.SetDefault("outbox.maxRetryBackoff", "1m")
+	viper.SetDefault("cache.size", 10000)
+	viper.SetDefault("cache.ttl", "1m")
+	viper.SetDefault("cache.negativeTTL", "5s")
+
 	if err := viper.ReadInConfig(); err != nil {
 		return config.Config{}, fmt.Errorf("failed to read in config: %w", err)
 	}
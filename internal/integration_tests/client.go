@@ -6,8 +6,11 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"time"
 
 	delivery "github.com/AlisskaPie/project-xm/internal/company/delivery/http"
+	"github.com/AlisskaPie/project-xm/pkg/domain"
 )
 
 type httpClient struct {
@@ -21,6 +24,8 @@ type httpClient struct {
 type ClientResponse struct {
 	Body       []byte
 	StatusCode int
+	Location   string
+	ETag       string
 }
 
 func NewClient(host, port string) *httpClient {
@@ -69,26 +74,211 @@ func (h httpClient) Create(postRequest delivery.CompanyPostRequest, authToken st
 	clientResp := &ClientResponse{
 		Body:       body,
 		StatusCode: resp.StatusCode,
+		Location:   resp.Header.Get("Location"),
+		ETag:       resp.Header.Get(delivery.HeaderETag),
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
-		errResp := delivery.ErrorResponse{}
-		err := json.NewDecoder(resp.Body).Decode(&errResp)
-		if err != nil {
-			return clientResp, err
-		}
-		return clientResp, fmt.Errorf(errResp.Message)
+		return clientResp, toProblemDetails(body)
 	}
 
 	return clientResp, nil
 }
 
-func (h httpClient) GetByID(id delivery.IDPathRequest) (*ClientResponse, error) {
+func (h httpClient) CreateBatch(
+	postRequests []delivery.CompanyPostRequest,
+	mode domain.BatchMode,
+	authToken string,
+) (*ClientResponse, error) {
+	b, err := json.Marshal(postRequests)
+	if err != nil {
+		return nil, err
+	}
+	url := fmt.Sprintf("%s://%s:%s/%s:batch?mode=%s", h.schema, h.host, h.port, h.api, mode)
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(b))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	bearer := "Bearer " + authToken
+	req.Header.Add("Authorization", bearer)
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	clientResp := &ClientResponse{
+		Body:       body,
+		StatusCode: resp.StatusCode,
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return clientResp, toProblemDetails(body)
+	}
+
+	return clientResp, nil
+}
+
+func (h httpClient) GetByID(id delivery.IDPathRequest, authToken string) (*ClientResponse, error) {
 	url := fmt.Sprintf("%s://%s:%s/%s/%s", h.schema, h.host, h.port, h.api, id.ID.String())
+	return h.get(url, authToken)
+}
+
+// GetByIDAsOf gets the company as it was at the instant
+func (h httpClient) GetByIDAsOf(id delivery.IDPathRequest, at time.Time, authToken string) (*ClientResponse, error) {
+	query := url.Values{"as_of": {at.Format(time.RFC3339Nano)}}
+	url := fmt.Sprintf("%s://%s:%s/%s/%s?%s", h.schema, h.host, h.port, h.api, id.ID.String(), query.Encode())
+	return h.get(url, authToken)
+}
+
+func (h httpClient) get(url, authToken string) (*ClientResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	bearer := "Bearer " + authToken
+	req.Header.Add("Authorization", bearer)
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	clientResp := &ClientResponse{
+		Body:       body,
+		StatusCode: resp.StatusCode,
+		ETag:       resp.Header.Get(delivery.HeaderETag),
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return clientResp, toProblemDetails(body)
+	}
+
+	return clientResp, nil
+}
+
+func (h httpClient) List(query url.Values, authToken string) (*ClientResponse, error) {
+	url := fmt.Sprintf("%s://%s:%s/%s?%s", h.schema, h.host, h.port, h.api, query.Encode())
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	bearer := "Bearer " + authToken
+	req.Header.Add("Authorization", bearer)
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	clientResp := &ClientResponse{
+		Body:       body,
+		StatusCode: resp.StatusCode,
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return clientResp, toProblemDetails(body)
+	}
+
+	return clientResp, nil
+}
+
+func (h httpClient) Export(query url.Values, accept, authToken string) (*ClientResponse, error) {
+	url := fmt.Sprintf("%s://%s:%s/%s/export?%s", h.schema, h.host, h.port, h.api, query.Encode())
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+
+	bearer := "Bearer " + authToken
+	req.Header.Add("Authorization", bearer)
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	clientResp := &ClientResponse{
+		Body:       body,
+		StatusCode: resp.StatusCode,
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return clientResp, toProblemDetails(body)
+	}
+
+	return clientResp, nil
+}
+
+func (h httpClient) Search(query url.Values, authToken string) (*ClientResponse, error) {
+	url := fmt.Sprintf("%s://%s:%s/%s/search?%s", h.schema, h.host, h.port, h.api, query.Encode())
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	bearer := "Bearer " + authToken
+	req.Header.Add("Authorization", bearer)
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	clientResp := &ClientResponse{
+		Body:       body,
+		StatusCode: resp.StatusCode,
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return clientResp, toProblemDetails(body)
+	}
+
+	return clientResp, nil
+}
+
+func (h httpClient) History(id delivery.IDPathRequest, query url.Values, authToken string) (*ClientResponse, error) {
+	url := fmt.Sprintf("%s://%s:%s/%s/%s/history?%s", h.schema, h.host, h.port, h.api, id.ID.String(), query.Encode())
 	req, err := http.NewRequest(http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
 	}
+
+	bearer := "Bearer " + authToken
+	req.Header.Add("Authorization", bearer)
+
 	resp, err := h.client.Do(req)
 	if err != nil {
 		return nil, err
@@ -106,12 +296,7 @@ func (h httpClient) GetByID(id delivery.IDPathRequest) (*ClientResponse, error)
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
-		errResp := delivery.ErrorResponse{}
-		err := json.NewDecoder(resp.Body).Decode(&errResp)
-		if err != nil {
-			return clientResp, err
-		}
-		return clientResp, fmt.Errorf(errResp.Message)
+		return clientResp, toProblemDetails(body)
 	}
 
 	return clientResp, nil
@@ -119,6 +304,16 @@ func (h httpClient) GetByID(id delivery.IDPathRequest) (*ClientResponse, error)
 
 func (h httpClient) Delete(id delivery.IDPathRequest, authToken string) (*ClientResponse, error) {
 	url := fmt.Sprintf("%s://%s:%s/%s/%s", h.schema, h.host, h.port, h.api, id.ID.String())
+	return h.delete(url, authToken)
+}
+
+// Purge deletes the company permanently, authToken must have the admin claim
+func (h httpClient) Purge(id delivery.IDPathRequest, authToken string) (*ClientResponse, error) {
+	url := fmt.Sprintf("%s://%s:%s/%s/%s?purge=true", h.schema, h.host, h.port, h.api, id.ID.String())
+	return h.delete(url, authToken)
+}
+
+func (h httpClient) delete(url, authToken string) (*ClientResponse, error) {
 	req, err := http.NewRequest(http.MethodDelete, url, nil)
 	if err != nil {
 		return nil, err
@@ -133,23 +328,63 @@ func (h httpClient) Delete(id delivery.IDPathRequest, authToken string) (*Client
 	}
 	defer resp.Body.Close()
 
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
 	clientResp := &ClientResponse{
+		Body:       body,
 		StatusCode: resp.StatusCode,
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
-		errResp := delivery.ErrorResponse{}
-		err := json.NewDecoder(resp.Body).Decode(&errResp)
-		if err != nil {
-			return clientResp, err
-		}
-		return clientResp, fmt.Errorf(errResp.Message)
+		return clientResp, toProblemDetails(body)
+	}
+
+	return clientResp, nil
+}
+
+func (h httpClient) Restore(id delivery.IDPathRequest, authToken string) (*ClientResponse, error) {
+	url := fmt.Sprintf("%s://%s:%s/%s/%s/restore", h.schema, h.host, h.port, h.api, id.ID.String())
+	req, err := http.NewRequest(http.MethodPost, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	bearer := "Bearer " + authToken
+	req.Header.Add("Authorization", bearer)
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	clientResp := &ClientResponse{
+		Body:       body,
+		StatusCode: resp.StatusCode,
+		ETag:       resp.Header.Get(delivery.HeaderETag),
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return clientResp, toProblemDetails(body)
 	}
 
 	return clientResp, nil
 }
 
 func (h httpClient) Patch(patchRequest delivery.CompanyPatchRequest, authToken string) (*ClientResponse, error) {
+	return h.PatchIfMatch(patchRequest, authToken, "")
+}
+
+// PatchIfMatch patches the company only if its current version matches the etag, empty etag skips the check
+func (h httpClient) PatchIfMatch(patchRequest delivery.CompanyPatchRequest, authToken, etag string) (*ClientResponse, error) {
 	b, err := json.Marshal(&patchRequest)
 	if err != nil {
 		return nil, err
@@ -163,6 +398,9 @@ func (h httpClient) Patch(patchRequest delivery.CompanyPatchRequest, authToken s
 
 	bearer := "Bearer " + authToken
 	req.Header.Add("Authorization", bearer)
+	if etag != "" {
+		req.Header.Set(delivery.HeaderIfMatch, etag)
+	}
 
 	resp, err := h.client.Do(req)
 	if err != nil {
@@ -178,16 +416,21 @@ func (h httpClient) Patch(patchRequest delivery.CompanyPatchRequest, authToken s
 	clientResp := &ClientResponse{
 		Body:       body,
 		StatusCode: resp.StatusCode,
+		ETag:       resp.Header.Get(delivery.HeaderETag),
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
-		errResp := delivery.ErrorResponse{}
-		err := json.NewDecoder(resp.Body).Decode(&errResp)
-		if err != nil {
-			return clientResp, err
-		}
-		return clientResp, fmt.Errorf(errResp.Message)
+		return clientResp, toProblemDetails(body)
 	}
 
 	return clientResp, nil
 }
+
+// toProblemDetails decodes the error response body
+func toProblemDetails(b []byte) error {
+	problem := delivery.ProblemDetails{}
+	if err := json.Unmarshal(b, &problem); err != nil {
+		return err
+	}
+	return problem
+}
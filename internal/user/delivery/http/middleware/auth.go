@@ -1,10 +1,88 @@
 package middleware
 
 import (
+	"net/http"
+
+	"github.com/golang-jwt/jwt"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+
+	"github.com/AlisskaPie/project-xm/pkg/domain"
 )
 
+// AdminClaim is the boolean JWT claim granting the admin rights
+const AdminClaim = "admin"
+
 func KeyAuth(key []byte) echo.MiddlewareFunc {
 	return middleware.JWT(key)
 }
+
+// Auth authorizes the requests by KeyAuth and puts their Tenant, Actor and Client into the context
+func Auth(key []byte, tenantClaim string) echo.MiddlewareFunc {
+	keyAuth, tenant, actor, client := KeyAuth(key), Tenant(tenantClaim), Actor(), Client()
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return keyAuth(tenant(actor(client(next))))
+	}
+}
+
+// Tenant puts the tenant of the request, the string claim of the KeyAuth token, into its context,
+// so it must follow KeyAuth. The requests without the claim are forbidden.
+func Tenant(claim string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			claims, _ := claims(c)
+			tenant, _ := claims[claim].(string)
+			if tenant == "" {
+				return echo.NewHTTPError(http.StatusForbidden, "token has no tenant claim")
+			}
+
+			req := c.Request()
+			c.SetRequest(req.WithContext(domain.ContextWithTenant(req.Context(), tenant)))
+
+			return next(c)
+		}
+	}
+}
+
+// Actor puts the domain.Actor of the request into its context: the subject of the KeyAuth token
+// and the request ID, so it must follow both KeyAuth and the RequestID middleware
+func Actor() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			actor := domain.Actor{
+				RequestID: c.Response().Header().Get(echo.HeaderXRequestID),
+			}
+			if claims, ok := claims(c); ok {
+				actor.Subject, _ = claims["sub"].(string)
+			}
+
+			req := c.Request()
+			c.SetRequest(req.WithContext(domain.ContextWithActor(req.Context(), actor)))
+
+			return next(c)
+		}
+	}
+}
+
+// IsAdmin reports whether the request is authorized by KeyAuth with the admin claim set
+func IsAdmin(c echo.Context) bool {
+	claims, ok := claims(c)
+	if !ok {
+		return false
+	}
+
+	admin, _ := claims[AdminClaim].(bool)
+	return admin
+}
+
+// claims returns the claims of the token validated by KeyAuth
+func claims(c echo.Context) (jwt.MapClaims, bool) {
+	token, ok := c.Get(middleware.DefaultJWTConfig.ContextKey).(*jwt.Token)
+	if !ok {
+		return nil, false
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	return claims, ok
+}
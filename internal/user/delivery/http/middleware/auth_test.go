@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+)
+
+var testKey = []byte("secret")
+
+func TestTenant(t *testing.T) {
+	tests := []struct {
+		name       string
+		claims     jwt.MapClaims
+		wantCode   int
+		wantTenant string
+	}{
+		{name: "Tenant", claims: jwt.MapClaims{"tenant_id": "acme"}, wantCode: http.StatusOK, wantTenant: "acme"},
+		{name: "MissingClaim", claims: jwt.MapClaims{"sub": "1"}, wantCode: http.StatusForbidden},
+		{name: "EmptyClaim", claims: jwt.MapClaims{"tenant_id": ""}, wantCode: http.StatusForbidden},
+		{name: "NonStringClaim", claims: jwt.MapClaims{"tenant_id": 1}, wantCode: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tenant string
+			e := echo.New()
+			e.GET("/", func(c echo.Context) error {
+				tenant = domain.TenantFromContext(c.Request().Context())
+				return c.NoContent(http.StatusOK)
+			}, KeyAuth(testKey), Tenant("tenant_id"))
+
+			rec := serve(t, e, tt.claims)
+			assert.Equal(t, tt.wantCode, rec.Code)
+			assert.Equal(t, tt.wantTenant, tenant)
+		})
+	}
+}
+
+func TestAuth(t *testing.T) {
+	tests := []struct {
+		name       string
+		claims     jwt.MapClaims
+		wantCode   int
+		wantActor  domain.Actor
+		wantClient string
+	}{
+		{
+			name:       "Subject",
+			claims:     jwt.MapClaims{"sub": "1", "tenant_id": "acme"},
+			wantCode:   http.StatusOK,
+			wantActor:  domain.Actor{Subject: "1"},
+			wantClient: "acme/1",
+		},
+		{
+			name:       "NoSubject",
+			claims:     jwt.MapClaims{"tenant_id": "acme"},
+			wantCode:   http.StatusOK,
+			wantClient: "acme/192.0.2.1",
+		},
+		{name: "MissingClaim", claims: jwt.MapClaims{"sub": "1"}, wantCode: http.StatusForbidden},
+		{name: "NonStringClaim", claims: jwt.MapClaims{"sub": "1", "tenant_id": true}, wantCode: http.StatusForbidden},
+		{name: "NoToken", wantCode: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var actor domain.Actor
+			var client string
+			e := echo.New()
+			e.GET("/", func(c echo.Context) error {
+				actor = domain.ActorFromContext(c.Request().Context())
+				client = domain.ClientFromContext(c.Request().Context())
+				return c.NoContent(http.StatusOK)
+			}, Auth(testKey, "tenant_id"))
+
+			rec := serve(t, e, tt.claims)
+			assert.Equal(t, tt.wantCode, rec.Code)
+			assert.Equal(t, tt.wantActor, actor)
+			assert.Equal(t, tt.wantClient, client)
+		})
+	}
+}
+
+// serve serves the GET request of the token with the claims, there is no token if they are nil
+func serve(t *testing.T, e *echo.Echo, claims jwt.MapClaims) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(echo.GET, "/", nil)
+	if claims != nil {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(testKey)
+		require.NoError(t, err)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	}
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	return rec
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"github.com/labstack/echo/v4"
+
+	"github.com/AlisskaPie/project-xm/pkg/domain"
+)
+
+// Client puts the client of the request into its context: the subject of the token within the tenant of the
+// request, so it must follow both Tenant and Actor. The clients of the tokens without the subject are told apart
+// by their real IP.
+func Client() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			subject := domain.ActorFromContext(req.Context()).Subject
+			if subject == "" {
+				subject = c.RealIP()
+			}
+
+			client := domain.TenantFromContext(req.Context()) + "/" + subject
+			c.SetRequest(req.WithContext(domain.ContextWithClient(req.Context(), client)))
+
+			return next(c)
+		}
+	}
+}
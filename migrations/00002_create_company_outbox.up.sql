@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS company_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    company_id uuid NOT NULL,
+    payload JSONB NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error VARCHAR,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
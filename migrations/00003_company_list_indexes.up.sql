@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS company_name_id_idx ON company (name, id);
+CREATE INDEX IF NOT EXISTS company_amount_of_employees_id_idx ON company (amount_of_employees, id);
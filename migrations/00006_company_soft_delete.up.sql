@@ -0,0 +1 @@
+ALTER TABLE company ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
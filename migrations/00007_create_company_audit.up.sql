@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS company_audit (
+    id BIGSERIAL PRIMARY KEY,
+    company_id uuid NOT NULL,
+    action VARCHAR NOT NULL,
+    actor VARCHAR NOT NULL,
+    request_id VARCHAR NOT NULL,
+    changes JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS company_audit_company_id_id_idx ON company_audit (company_id, id);
@@ -0,0 +1,41 @@
+CREATE TABLE IF NOT EXISTS company_history (
+    id uuid NOT NULL,
+    name VARCHAR(15) NOT NULL,
+    description VARCHAR(3000),
+    amount_of_employees INT NOT NULL,
+    registered BOOLEAN NOT NULL,
+    type companyType NOT NULL,
+    version BIGINT NOT NULL,
+    valid_from TIMESTAMPTZ NOT NULL,
+    valid_to TIMESTAMPTZ NOT NULL DEFAULT 'infinity'
+);
+
+CREATE INDEX IF NOT EXISTS company_history_id_valid_from_idx ON company_history (id, valid_from);
+
+-- company_history_write closes the current state of the changed company and opens the new one,
+-- deleted companies have no current state
+CREATE OR REPLACE FUNCTION company_history_write() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE company_history SET valid_to = now() WHERE id = OLD.id AND valid_to = 'infinity';
+    END IF;
+
+    IF TG_OP IN ('INSERT', 'UPDATE') AND NEW.deleted_at IS NULL THEN
+        INSERT INTO company_history (id, name, description, amount_of_employees, registered, type, version, valid_from)
+        VALUES (NEW.id, NEW.name, NEW.description, NEW.amount_of_employees, NEW.registered, NEW.type, NEW.version, now());
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS company_history_write ON company;
+CREATE TRIGGER company_history_write AFTER INSERT OR UPDATE OR DELETE ON company
+    FOR EACH ROW EXECUTE FUNCTION company_history_write();
+
+-- the history of the existing companies starts now
+INSERT INTO company_history (id, name, description, amount_of_employees, registered, type, version, valid_from)
+SELECT id, name, description, amount_of_employees, registered, type, version, now()
+FROM company
+WHERE deleted_at IS NULL
+  AND NOT EXISTS (SELECT 1 FROM company_history h WHERE h.id = company.id);
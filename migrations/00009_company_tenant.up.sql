@@ -0,0 +1,32 @@
+-- the companies are owned by the tenants, those created before are left to the empty tenant no request can have
+-- until they are moved to their tenants by UPDATE company SET tenant_id = ...
+ALTER TABLE company ADD COLUMN IF NOT EXISTS tenant_id VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE company_history ADD COLUMN IF NOT EXISTS tenant_id VARCHAR NOT NULL DEFAULT '';
+ALTER TABLE company_audit ADD COLUMN IF NOT EXISTS tenant_id VARCHAR NOT NULL DEFAULT '';
+
+-- the company lists and the audit are read per tenant
+CREATE INDEX IF NOT EXISTS company_tenant_name_id_idx ON company (tenant_id, name, id);
+CREATE INDEX IF NOT EXISTS company_tenant_amount_of_employees_id_idx ON company (tenant_id, amount_of_employees, id);
+DROP INDEX IF EXISTS company_name_id_idx;
+DROP INDEX IF EXISTS company_amount_of_employees_id_idx;
+
+CREATE INDEX IF NOT EXISTS company_audit_tenant_company_id_id_idx ON company_audit (tenant_id, company_id, id);
+DROP INDEX IF EXISTS company_audit_company_id_id_idx;
+
+-- the tenant of the company is kept in its history
+CREATE OR REPLACE FUNCTION company_history_write() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE company_history SET valid_to = now() WHERE id = OLD.id AND valid_to = 'infinity';
+    END IF;
+
+    IF TG_OP IN ('INSERT', 'UPDATE') AND NEW.deleted_at IS NULL THEN
+        INSERT INTO company_history (id, tenant_id, name, description, amount_of_employees, registered, type, version,
+                                     valid_from)
+        VALUES (NEW.id, NEW.tenant_id, NEW.name, NEW.description, NEW.amount_of_employees, NEW.registered, NEW.type,
+                NEW.version, now());
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
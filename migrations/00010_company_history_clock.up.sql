@@ -0,0 +1,21 @@
+-- the states of the company changed several times in one transaction get their own times rather than the start
+-- of the transaction now() returns, so that none of them is valid for no time and the history stays ordered.
+-- The state is closed and the next one opened at the same time, so that there is no gap between them.
+CREATE OR REPLACE FUNCTION company_history_write() RETURNS trigger AS $$
+DECLARE
+    changed_at TIMESTAMPTZ := clock_timestamp();
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE company_history SET valid_to = changed_at WHERE id = OLD.id AND valid_to = 'infinity';
+    END IF;
+
+    IF TG_OP IN ('INSERT', 'UPDATE') AND NEW.deleted_at IS NULL THEN
+        INSERT INTO company_history (id, tenant_id, name, description, amount_of_employees, registered, type, version,
+                                     valid_from)
+        VALUES (NEW.id, NEW.tenant_id, NEW.name, NEW.description, NEW.amount_of_employees, NEW.registered, NEW.type,
+                NEW.version, changed_at);
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,86 @@
+-- the schema matches the postgres migrations, the companyType enum is emulated by a CHECK constraint
+-- and the timestamps are UTC texts of the same length, so they compare as strings
+CREATE TABLE IF NOT EXISTS company (
+    -- seq keeps the rowid of the company stable for the company_search index
+    seq INTEGER PRIMARY KEY,
+    id TEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL CONSTRAINT company_name_length CHECK (length(name) <= 15),
+    description TEXT CONSTRAINT company_description_length CHECK (length(description) <= 3000),
+    amount_of_employees INTEGER NOT NULL DEFAULT 0 CHECK (amount_of_employees >= 0),
+    registered BOOLEAN NOT NULL DEFAULT FALSE,
+    type TEXT NOT NULL CONSTRAINT company_type CHECK (type IN ('Corporations', 'NonProfit', 'Cooperative', 'Sole Proprietorship')),
+    version INTEGER NOT NULL DEFAULT 1,
+    deleted_at TEXT
+);
+
+CREATE INDEX IF NOT EXISTS company_name_id_idx ON company (name, id);
+CREATE INDEX IF NOT EXISTS company_amount_of_employees_id_idx ON company (amount_of_employees, id);
+
+CREATE VIRTUAL TABLE IF NOT EXISTS company_search USING fts5(
+    name, description, content='company', content_rowid='seq', tokenize='porter unicode61'
+);
+
+CREATE TRIGGER IF NOT EXISTS company_search_insert AFTER INSERT ON company BEGIN
+    INSERT INTO company_search (rowid, name, description) VALUES (NEW.seq, NEW.name, coalesce(NEW.description, ''));
+END;
+
+CREATE TRIGGER IF NOT EXISTS company_search_delete AFTER DELETE ON company BEGIN
+    INSERT INTO company_search (company_search, rowid, name, description)
+    VALUES ('delete', OLD.seq, OLD.name, coalesce(OLD.description, ''));
+END;
+
+CREATE TRIGGER IF NOT EXISTS company_search_update AFTER UPDATE OF name, description ON company BEGIN
+    INSERT INTO company_search (company_search, rowid, name, description)
+    VALUES ('delete', OLD.seq, OLD.name, coalesce(OLD.description, ''));
+    INSERT INTO company_search (rowid, name, description) VALUES (NEW.seq, NEW.name, coalesce(NEW.description, ''));
+END;
+
+CREATE TABLE IF NOT EXISTS company_audit (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    company_id TEXT NOT NULL,
+    action TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    request_id TEXT NOT NULL,
+    changes TEXT NOT NULL,
+    created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%fZ', 'now'))
+);
+
+CREATE INDEX IF NOT EXISTS company_audit_company_id_id_idx ON company_audit (company_id, id);
+
+-- valid_to is NULL for the current state of the company
+CREATE TABLE IF NOT EXISTS company_history (
+    id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT,
+    amount_of_employees INTEGER NOT NULL,
+    registered BOOLEAN NOT NULL,
+    type TEXT NOT NULL,
+    version INTEGER NOT NULL,
+    valid_from TEXT NOT NULL,
+    valid_to TEXT
+);
+
+CREATE INDEX IF NOT EXISTS company_history_id_valid_from_idx ON company_history (id, valid_from);
+
+-- the history triggers close the current state of the changed company and open the new one,
+-- deleted companies have no current state
+CREATE TRIGGER IF NOT EXISTS company_history_insert AFTER INSERT ON company WHEN NEW.deleted_at IS NULL BEGIN
+    INSERT INTO company_history (id, name, description, amount_of_employees, registered, type, version, valid_from)
+    VALUES (NEW.id, NEW.name, NEW.description, NEW.amount_of_employees, NEW.registered, NEW.type, NEW.version,
+            strftime('%Y-%m-%dT%H:%M:%fZ', 'now'));
+END;
+
+CREATE TRIGGER IF NOT EXISTS company_history_update AFTER UPDATE ON company BEGIN
+    UPDATE company_history SET valid_to = strftime('%Y-%m-%dT%H:%M:%fZ', 'now')
+    WHERE id = OLD.id AND valid_to IS NULL;
+
+    INSERT INTO company_history (id, name, description, amount_of_employees, registered, type, version, valid_from)
+    SELECT NEW.id, NEW.name, NEW.description, NEW.amount_of_employees, NEW.registered, NEW.type, NEW.version,
+           strftime('%Y-%m-%dT%H:%M:%fZ', 'now')
+    WHERE NEW.deleted_at IS NULL;
+END;
+
+CREATE TRIGGER IF NOT EXISTS company_history_delete AFTER DELETE ON company BEGIN
+    UPDATE company_history SET valid_to = strftime('%Y-%m-%dT%H:%M:%fZ', 'now')
+    WHERE id = OLD.id AND valid_to IS NULL;
+END;
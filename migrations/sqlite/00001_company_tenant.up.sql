@@ -0,0 +1,34 @@
+-- the companies are owned by the tenants, see the postgres migration 00009_company_tenant
+ALTER TABLE company ADD COLUMN tenant_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE company_history ADD COLUMN tenant_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE company_audit ADD COLUMN tenant_id TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS company_tenant_name_id_idx ON company (tenant_id, name, id);
+CREATE INDEX IF NOT EXISTS company_tenant_amount_of_employees_id_idx ON company (tenant_id, amount_of_employees, id);
+DROP INDEX IF EXISTS company_name_id_idx;
+DROP INDEX IF EXISTS company_amount_of_employees_id_idx;
+
+CREATE INDEX IF NOT EXISTS company_audit_tenant_company_id_id_idx ON company_audit (tenant_id, company_id, id);
+DROP INDEX IF EXISTS company_audit_company_id_id_idx;
+
+-- the tenant of the company is kept in its history
+DROP TRIGGER IF EXISTS company_history_insert;
+DROP TRIGGER IF EXISTS company_history_update;
+
+CREATE TRIGGER company_history_insert AFTER INSERT ON company WHEN NEW.deleted_at IS NULL BEGIN
+    INSERT INTO company_history (id, tenant_id, name, description, amount_of_employees, registered, type, version,
+                                 valid_from)
+    VALUES (NEW.id, NEW.tenant_id, NEW.name, NEW.description, NEW.amount_of_employees, NEW.registered, NEW.type,
+            NEW.version, strftime('%Y-%m-%dT%H:%M:%fZ', 'now'));
+END;
+
+CREATE TRIGGER company_history_update AFTER UPDATE ON company BEGIN
+    UPDATE company_history SET valid_to = strftime('%Y-%m-%dT%H:%M:%fZ', 'now')
+    WHERE id = OLD.id AND valid_to IS NULL;
+
+    INSERT INTO company_history (id, tenant_id, name, description, amount_of_employees, registered, type, version,
+                                 valid_from)
+    SELECT NEW.id, NEW.tenant_id, NEW.name, NEW.description, NEW.amount_of_employees, NEW.registered, NEW.type,
+           NEW.version, strftime('%Y-%m-%dT%H:%M:%fZ', 'now')
+    WHERE NEW.deleted_at IS NULL;
+END;
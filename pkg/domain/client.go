@@ -0,0 +1,17 @@
+package domain
+
+import "context"
+
+type clientKey struct{}
+
+// ContextWithClient returns the context carrying the client of the request, e.g. its address,
+// so that the repository can route its reads after its own writes
+func ContextWithClient(ctx context.Context, client string) context.Context {
+	return context.WithValue(ctx, clientKey{}, client)
+}
+
+// ClientFromContext returns the client carried by ctx, it's empty if there is none
+func ClientFromContext(ctx context.Context) string {
+	client, _ := ctx.Value(clientKey{}).(string)
+	return client
+}
@@ -12,6 +12,8 @@ type Company struct {
 	AmountOfEmployees uint32
 	Registered        bool
 	CompanyType       CompanyType
+	// Version is incremented on every update of the company
+	Version int64
 }
 
 // CompanyType implements enum for type
@@ -24,3 +26,13 @@ const (
 	CooperativeType        CompanyType = "Cooperative"
 	SoleProprietorshipType CompanyType = "Sole Proprietorship"
 )
+
+// Valid reports whether t is one of the CompanyType values
+func (t CompanyType) Valid() bool {
+	switch t {
+	case CorporationsType, NonProfitType, CooperativeType, SoleProprietorshipType:
+		return true
+	}
+
+	return false
+}
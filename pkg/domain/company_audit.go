@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Actor is the author of the company mutation recorded in the audit
+type Actor struct {
+	// Subject is the subject of the authorization token
+	Subject   string
+	RequestID string
+}
+
+type actorKey struct{}
+
+// ContextWithActor returns the context carrying the actor of the mutations
+func ContextWithActor(ctx context.Context, a Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, a)
+}
+
+// ActorFromContext returns the actor carried by ctx, it's empty if there is none
+func ActorFromContext(ctx context.Context) Actor {
+	a, _ := ctx.Value(actorKey{}).(Actor)
+	return a
+}
+
+// CompanyAuditEntry records who performed the action on the company, when and what it changed
+type CompanyAuditEntry struct {
+	ID        int64
+	CompanyID uuid.UUID
+	Action    EventActionType
+	Actor     Actor
+	Time      time.Time
+	Changes   []FieldChange
+}
+
+// FieldChange is the change of the company field, Old is nil for the created company
+type FieldChange struct {
+	Field string
+	Old   any
+	New   any
+}
+
+// CompanyChanges lists the fields differing between the before and after states of the company,
+// all the fields are listed if there is no before state
+func CompanyChanges(before *Company, after Company) []FieldChange {
+	var old Company
+	if before != nil {
+		old = *before
+	}
+
+	fields := []FieldChange{
+		{Field: "name", Old: old.Name, New: after.Name},
+		{Field: "description", Old: old.Description, New: after.Description},
+		{Field: "amount_of_employees", Old: old.AmountOfEmployees, New: after.AmountOfEmployees},
+		{Field: "registered", Old: old.Registered, New: after.Registered},
+		{Field: "type", Old: old.CompanyType, New: after.CompanyType},
+	}
+
+	changes := make([]FieldChange, 0, len(fields))
+	for _, f := range fields {
+		if before == nil {
+			f.Old = nil
+		} else if f.Old == f.New {
+			continue
+		}
+		changes = append(changes, f)
+	}
+
+	return changes
+}
+
+// CompanyHistory pages through the audit entries of the company, the newest first.
+// Cursor continues from the CompanyHistoryPage.NextCursor of the same company.
+type CompanyHistory struct {
+	CompanyID uuid.UUID
+	Limit     int
+	Cursor    string
+}
+
+// CompanyHistoryPage is a page of audit entries, NextCursor is empty on the last page
+type CompanyHistoryPage struct {
+	Entries    []CompanyAuditEntry
+	NextCursor string
+}
+
+// CompanyAuditRepository represent the company audit contract.
+// Entries are recorded in the same transaction as the mutation they describe.
+type CompanyAuditRepository interface {
+	History(ctx context.Context, q CompanyHistory) (CompanyHistoryPage, error)
+}
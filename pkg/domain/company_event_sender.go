@@ -2,6 +2,7 @@ package domain
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -10,9 +11,26 @@ import (
 // Spec: On each mutating operation,
 // a JSON formatted event must be produced to a service bus (Kafka, RabbitMQ etc.)
 type CompanyEvent struct {
-	Action EventActionType
-	ID     uuid.UUID
-	State  Company
+	// EventID identifies the event, so consumers can deduplicate redelivered events
+	EventID uuid.UUID
+	Time    time.Time
+	Action  EventActionType
+	ID      uuid.UUID
+	State   Company
+	// TenantID is the tenant owning the company
+	TenantID string
+}
+
+// NewCompanyEvent creates an event of the action performed on the company of the tenant of ctx just now
+func NewCompanyEvent(ctx context.Context, action EventActionType, id uuid.UUID, state Company) CompanyEvent {
+	return CompanyEvent{
+		EventID:  uuid.New(),
+		Time:     time.Now().UTC(),
+		Action:   action,
+		ID:       id,
+		State:    state,
+		TenantID: TenantFromContext(ctx),
+	}
 }
 
 type EventActionType string
@@ -21,7 +39,11 @@ type EventActionType string
 const (
 	InsertEventActionType EventActionType = "insert"
 	UpdateEventActionType EventActionType = "update"
-	DeleteEventActionType EventActionType = "delete"
+	// DeleteEventActionType is the soft delete, the company can be restored until it's purged
+	DeleteEventActionType  EventActionType = "delete"
+	RestoreEventActionType EventActionType = "restore"
+	// PurgeEventActionType is the permanent delete of the company
+	PurgeEventActionType EventActionType = "purge"
 )
 
 // CompanyEventSender is an interface for service bus.
@@ -0,0 +1,17 @@
+package domain
+
+import (
+	"context"
+)
+
+// CompanyOutboxRepository represent the company events outbox contract.
+// Events are stored in the outbox in the same transaction as the mutation
+// they describe and are relayed to a CompanyEventSender afterwards.
+type CompanyOutboxRepository interface {
+	// Dispatch passes up to limit pending events to send, oldest first,
+	// and removes the sent ones from the outbox. It stops at the first
+	// event that failed to be sent and returns the number of sent events.
+	Dispatch(ctx context.Context, limit int, send func(context.Context, CompanyEvent) error) (int, error)
+	// Pending returns the number of events waiting to be sent.
+	Pending(ctx context.Context) (int, error)
+}
@@ -2,14 +2,24 @@ package domain
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 // CompanyRepository represent the company's repository contract
 type CompanyRepository interface {
-	Create(ctx context.Context, c CreateCompany) error
+	Create(ctx context.Context, c CreateCompany) (Company, error)
+	// CreateBatch returns the results in the order of the companies
+	CreateBatch(ctx context.Context, b CreateCompanies) ([]CreateCompanyResult, error)
 	GetByID(ctx context.Context, id uuid.UUID) (Company, error)
+	// GetByIDAsOf returns the company as it was at the instant
+	GetByIDAsOf(ctx context.Context, id uuid.UUID, at time.Time) (Company, error)
+	List(ctx context.Context, q ListCompanies) (CompanyPage, error)
+	Search(ctx context.Context, q SearchCompanies) ([]CompanySearchResult, error)
+	// Export calls fn with every company matching q in the ID order, it stops at the first error of fn
+	Export(ctx context.Context, q ExportCompanies, fn func(Company) error) error
 	Patch(ctx context.Context, id uuid.UUID, c PatchCompany) (Company, error)
-	Delete(ctx context.Context, id uuid.UUID) error
+	Delete(ctx context.Context, id uuid.UUID, d DeleteCompany) error
+	Restore(ctx context.Context, id uuid.UUID) (Company, error)
 }
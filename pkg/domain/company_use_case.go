@@ -2,16 +2,27 @@ package domain
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 // CompanyUsecase represent the company's usecases
 type CompanyUsecase interface {
-	Create(ctx context.Context, c CreateCompany) error
+	Create(ctx context.Context, c CreateCompany) (Company, error)
+	// CreateBatch returns the results in the order of the companies
+	CreateBatch(ctx context.Context, b CreateCompanies) ([]CreateCompanyResult, error)
 	GetByID(ctx context.Context, id uuid.UUID) (Company, error)
+	// GetByIDAsOf returns the company as it was at the instant
+	GetByIDAsOf(ctx context.Context, id uuid.UUID, at time.Time) (Company, error)
+	List(ctx context.Context, q ListCompanies) (CompanyPage, error)
+	Search(ctx context.Context, q SearchCompanies) ([]CompanySearchResult, error)
+	// Export calls fn with every company matching q in the ID order, it stops at the first error of fn
+	Export(ctx context.Context, q ExportCompanies, fn func(Company) error) error
 	Patch(ctx context.Context, id uuid.UUID, c PatchCompany) (Company, error)
-	Delete(ctx context.Context, id uuid.UUID) error
+	Delete(ctx context.Context, id uuid.UUID, d DeleteCompany) error
+	Restore(ctx context.Context, id uuid.UUID) (Company, error)
+	History(ctx context.Context, q CompanyHistory) (CompanyHistoryPage, error)
 }
 
 type PatchCompany struct {
@@ -20,6 +31,24 @@ type PatchCompany struct {
 	AmountOfEmployees *uint32
 	Registered        *bool
 	CompanyType       *CompanyType
+	// Version, if set, must be the current version of the company
+	Version *int64
+}
+
+// DeleteCompany hides the company until it's restored, or removes it permanently if Purge is set
+type DeleteCompany struct {
+	// Version, if set, must be the current version of the company
+	Version *int64
+	Purge   bool
+}
+
+// Action returns the event action of the deletion
+func (d DeleteCompany) Action() EventActionType {
+	if d.Purge {
+		return PurgeEventActionType
+	}
+
+	return DeleteEventActionType
 }
 
 type CreateCompany struct {
@@ -30,3 +59,83 @@ type CreateCompany struct {
 	Registered        bool
 	CompanyType       CompanyType
 }
+
+// BatchMode tells what to do with the rest of the batch when some of its companies can't be created
+type BatchMode string
+
+const (
+	// AllOrNothingBatchMode creates no companies if any of them can't be created
+	AllOrNothingBatchMode BatchMode = "all_or_nothing"
+	// BestEffortBatchMode creates all the companies that can be created
+	BestEffortBatchMode BatchMode = "best_effort"
+)
+
+// MaxBatchSize is the maximum number of companies created at once
+const MaxBatchSize = 1000
+
+// CreateCompanies creates the companies at once
+type CreateCompanies struct {
+	Companies []CreateCompany
+	Mode      BatchMode
+}
+
+// CreateCompanyResult is the outcome of the company of the batch, Err is nil if it's created
+type CreateCompanyResult struct {
+	Company Company
+	Err     error
+}
+
+// CompanySortField is the whitelisted field to sort the companies by
+type CompanySortField string
+
+const (
+	SortByName              CompanySortField = "name"
+	SortByAmountOfEmployees CompanySortField = "amount_of_employees"
+	SortByID                CompanySortField = "id"
+)
+
+const (
+	DefaultListLimit = 20
+	MaxListLimit     = 100
+)
+
+// ListCompanies filters the companies, nil and empty fields match any company.
+// Cursor continues the listing from the CompanyPage.NextCursor of the same query.
+type ListCompanies struct {
+	CompanyType  *CompanyType
+	Registered   *bool
+	MinEmployees *uint32
+	MaxEmployees *uint32
+	NamePrefix   string
+	SortBy       CompanySortField
+	Desc         bool
+	Limit        int
+	Cursor       string
+}
+
+// ExportCompanies filters the exported companies, nil fields match any company
+type ExportCompanies struct {
+	CompanyType *CompanyType
+	Registered  *bool
+}
+
+// CompanyPage is a page of companies, NextCursor is empty on the last page
+type CompanyPage struct {
+	Companies  []Company
+	NextCursor string
+}
+
+// SearchCompanies finds the companies by the words of their name and description.
+// Query words are matched all together, "quoted phrases" match adjacent words and words ending with * match prefixes.
+type SearchCompanies struct {
+	Query string
+	Limit int
+}
+
+// CompanySearchResult is the found company, the more relevant the higher its Rank.
+// Snippet is the fragment of the description with the matched words highlighted.
+type CompanySearchResult struct {
+	Company Company
+	Rank    float64
+	Snippet string
+}
@@ -8,3 +8,58 @@ var (
 	ErrInternalError = fmt.Errorf("failed with internal error")
 	ErrBadRequest    = fmt.Errorf("failed with invalid request parameters")
 )
+
+// Kinds of Error, check for them with errors.Is
+var (
+	ErrNotFound    = fmt.Errorf("failed with resource not found")
+	ErrConflict    = fmt.Errorf("failed with conflicting resource state")
+	ErrValidation  = fmt.Errorf("failed with invalid resource values")
+	ErrUnavailable = fmt.Errorf("failed with service temporarily unavailable")
+	// ErrPreconditionFailed is the expected version mismatch
+	ErrPreconditionFailed = fmt.Errorf("failed with resource modified")
+	// ErrPreconditionRequired is the missing expected version when it's required
+	ErrPreconditionRequired = fmt.Errorf("failed with resource version required")
+)
+
+// Error is an error of a certain kind, e.g. ErrNotFound, caused by a lower level error
+type Error struct {
+	Kind error
+	// Detail describes the error to the client, so it must not reveal any internals
+	Detail string
+	Cause  error
+}
+
+// NewError creates an error of the kind
+func NewError(kind error, detail string, cause error) error {
+	return &Error{
+		Kind:   kind,
+		Detail: detail,
+		Cause:  cause,
+	}
+}
+
+// Message returns the description of the error safe to show to the client
+func (e *Error) Message() string {
+	if e.Detail == "" {
+		return e.Kind.Error()
+	}
+
+	return e.Kind.Error() + ": " + e.Detail
+}
+
+func (e *Error) Error() string {
+	if e.Cause == nil {
+		return e.Message()
+	}
+
+	return e.Message() + ": " + e.Cause.Error()
+}
+
+// Is reports whether the error is of the target kind
+func (e *Error) Is(target error) bool {
+	return e.Kind == target
+}
+
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
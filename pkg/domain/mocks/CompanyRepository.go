@@ -8,6 +8,8 @@ import (
 
 	mock "github.com/stretchr/testify/mock"
 
+	time "time"
+
 	uuid "github.com/google/uuid"
 )
 
@@ -17,12 +19,56 @@ type CompanyRepository struct {
 }
 
 // Create provides a mock function with given fields: ctx, c
-func (_m *CompanyRepository) Create(ctx context.Context, c domain.CreateCompany) error {
+func (_m *CompanyRepository) Create(ctx context.Context, c domain.CreateCompany) (domain.Company, error) {
 	ret := _m.Called(ctx, c)
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, domain.CreateCompany) error); ok {
+	var r0 domain.Company
+	if rf, ok := ret.Get(0).(func(context.Context, domain.CreateCompany) domain.Company); ok {
 		r0 = rf(ctx, c)
+	} else {
+		r0 = ret.Get(0).(domain.Company)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, domain.CreateCompany) error); ok {
+		r1 = rf(ctx, c)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateBatch provides a mock function with given fields: ctx, b
+func (_m *CompanyRepository) CreateBatch(ctx context.Context, b domain.CreateCompanies) ([]domain.CreateCompanyResult, error) {
+	ret := _m.Called(ctx, b)
+
+	var r0 []domain.CreateCompanyResult
+	if rf, ok := ret.Get(0).(func(context.Context, domain.CreateCompanies) []domain.CreateCompanyResult); ok {
+		r0 = rf(ctx, b)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.CreateCompanyResult)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, domain.CreateCompanies) error); ok {
+		r1 = rf(ctx, b)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Delete provides a mock function with given fields: ctx, id, d
+func (_m *CompanyRepository) Delete(ctx context.Context, id uuid.UUID, d domain.DeleteCompany) error {
+	ret := _m.Called(ctx, id, d)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, domain.DeleteCompany) error); ok {
+		r0 = rf(ctx, id, d)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -30,13 +76,13 @@ func (_m *CompanyRepository) Create(ctx context.Context, c domain.CreateCompany)
 	return r0
 }
 
-// Delete provides a mock function with given fields: ctx, id
-func (_m *CompanyRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	ret := _m.Called(ctx, id)
+// Export provides a mock function with given fields: ctx, q, fn
+func (_m *CompanyRepository) Export(ctx context.Context, q domain.ExportCompanies, fn func(domain.Company) error) error {
+	ret := _m.Called(ctx, q, fn)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) error); ok {
-		r0 = rf(ctx, id)
+	if rf, ok := ret.Get(0).(func(context.Context, domain.ExportCompanies, func(domain.Company) error) error); ok {
+		r0 = rf(ctx, q, fn)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -65,6 +111,48 @@ func (_m *CompanyRepository) GetByID(ctx context.Context, id uuid.UUID) (domain.
 	return r0, r1
 }
 
+// GetByIDAsOf provides a mock function with given fields: ctx, id, at
+func (_m *CompanyRepository) GetByIDAsOf(ctx context.Context, id uuid.UUID, at time.Time) (domain.Company, error) {
+	ret := _m.Called(ctx, id, at)
+
+	var r0 domain.Company
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID, time.Time) domain.Company); ok {
+		r0 = rf(ctx, id, at)
+	} else {
+		r0 = ret.Get(0).(domain.Company)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID, time.Time) error); ok {
+		r1 = rf(ctx, id, at)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// List provides a mock function with given fields: ctx, q
+func (_m *CompanyRepository) List(ctx context.Context, q domain.ListCompanies) (domain.CompanyPage, error) {
+	ret := _m.Called(ctx, q)
+
+	var r0 domain.CompanyPage
+	if rf, ok := ret.Get(0).(func(context.Context, domain.ListCompanies) domain.CompanyPage); ok {
+		r0 = rf(ctx, q)
+	} else {
+		r0 = ret.Get(0).(domain.CompanyPage)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, domain.ListCompanies) error); ok {
+		r1 = rf(ctx, q)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Patch provides a mock function with given fields: ctx, id, c
 func (_m *CompanyRepository) Patch(ctx context.Context, id uuid.UUID, c domain.PatchCompany) (domain.Company, error) {
 	ret := _m.Called(ctx, id, c)
@@ -86,6 +174,50 @@ func (_m *CompanyRepository) Patch(ctx context.Context, id uuid.UUID, c domain.P
 	return r0, r1
 }
 
+// Restore provides a mock function with given fields: ctx, id
+func (_m *CompanyRepository) Restore(ctx context.Context, id uuid.UUID) (domain.Company, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 domain.Company
+	if rf, ok := ret.Get(0).(func(context.Context, uuid.UUID) domain.Company); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(domain.Company)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uuid.UUID) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Search provides a mock function with given fields: ctx, q
+func (_m *CompanyRepository) Search(ctx context.Context, q domain.SearchCompanies) ([]domain.CompanySearchResult, error) {
+	ret := _m.Called(ctx, q)
+
+	var r0 []domain.CompanySearchResult
+	if rf, ok := ret.Get(0).(func(context.Context, domain.SearchCompanies) []domain.CompanySearchResult); ok {
+		r0 = rf(ctx, q)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.CompanySearchResult)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, domain.SearchCompanies) error); ok {
+		r1 = rf(ctx, q)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 type mockConstructorTestingTNewCompanyRepository interface {
 	mock.TestingT
 	Cleanup(func())
@@ -0,0 +1,17 @@
+package domain
+
+import "context"
+
+type tenantKey struct{}
+
+// ContextWithTenant returns the context carrying the tenant of the request,
+// the company repositories see and change only the companies of the tenant
+func ContextWithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant carried by ctx, it's empty if there is none
+func TenantFromContext(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"context"
+	"sync"
+)
+
+// IsolationLevel is the isolation level of the transactions run by TxManager
+type IsolationLevel string
+
+// Isolation levels, the storage default is used if it's empty
+const (
+	ReadCommitted  IsolationLevel = "read_committed"
+	RepeatableRead IsolationLevel = "repeatable_read"
+	Serializable   IsolationLevel = "serializable"
+)
+
+// TxOptions configure the transaction run by TxManager
+type TxOptions struct {
+	Isolation IsolationLevel
+	ReadOnly  bool
+}
+
+// TxManager runs the multi-step operations of the use cases atomically
+type TxManager interface {
+	// WithTx runs fn in a transaction committed if fn returns nil and rolled back otherwise.
+	// The transaction is carried by the context passed to fn, the calls of repo and of the repositories of the
+	// same storage made with it join the transaction. If ctx already carries a transaction fn joins it and opts
+	// are ignored. fn may be run again if the transaction fails to serialize, so it must not have other side effects.
+	WithTx(ctx context.Context, opts TxOptions, fn func(ctx context.Context, repo CompanyRepository) error) error
+}
+
+type txHooksKey struct{}
+
+// txHooks are the funcs registered by AfterCommit in the transaction
+type txHooks struct {
+	mu  sync.Mutex
+	fns []func(ctx context.Context) error
+}
+
+// ContextWithTx marks the context carrying a new transaction for AfterCommit and InTx, the returned commit func
+// runs the funcs registered by AfterCommit once the transaction is committed. It's meant for the TxManager
+// implementations, the funcs are dropped if the transaction is rolled back.
+func ContextWithTx(ctx context.Context) (context.Context, func(ctx context.Context) error) {
+	hooks := &txHooks{}
+
+	commit := func(ctx context.Context) error {
+		hooks.mu.Lock()
+		fns := hooks.fns
+		hooks.mu.Unlock()
+
+		for _, fn := range fns {
+			if err := fn(ctx); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	return context.WithValue(ctx, txHooksKey{}, hooks), commit
+}
+
+// InTx tells whether ctx carries a transaction of TxManager
+func InTx(ctx context.Context) bool {
+	_, ok := ctx.Value(txHooksKey{}).(*txHooks)
+	return ok
+}
+
+// AfterCommit runs fn once the transaction carried by ctx is committed, or at once if there is none,
+// e.g. to send the events of the changes only if they are committed
+func AfterCommit(ctx context.Context, fn func(ctx context.Context) error) error {
+	hooks, ok := ctx.Value(txHooksKey{}).(*txHooks)
+	if !ok {
+		return fn(ctx)
+	}
+
+	hooks.mu.Lock()
+	defer hooks.mu.Unlock()
+
+	hooks.fns = append(hooks.fns, fn)
+
+	return nil
+}